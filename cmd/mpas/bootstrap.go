@@ -5,6 +5,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
@@ -12,6 +13,8 @@ import (
 
 	"github.com/open-component-model/mpas/cmd/mpas/bootstrap"
 	"github.com/open-component-model/mpas/cmd/mpas/config"
+	"github.com/open-component-model/mpas/internal/bootstrap/provider/azuredevops"
+	"github.com/open-component-model/mpas/internal/credentials"
 	"github.com/open-component-model/mpas/internal/env"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
@@ -43,6 +46,9 @@ func NewBootstrap(cfg *config.MpasConfig) *cobra.Command {
 	cmd.AddCommand(NewBootstrapGithub(cfg))
 	cmd.AddCommand(NewBootstrapGitea(cfg))
 	cmd.AddCommand(NewBootstrapGitlab(cfg))
+	cmd.AddCommand(NewBootstrapBitbucketServer(cfg))
+	cmd.AddCommand(NewBootstrapAzureDevOps(cfg))
+	cmd.AddCommand(NewBootstrapGit(cfg))
 
 	return cmd
 }
@@ -64,30 +70,17 @@ func NewBootstrapGithub(cfg *config.MpasConfig) *cobra.Command {
 
     - Bootstrap with a public organization repository
     mpas bootstrap github --owner ocmOrg --repository mpas --registry ghcr.io/open-component-model/mpas-bootstrap-component --private=false --path clusters/my-cluster
+
+    - Render the manifests to a local directory for review without committing or applying them
+    mpas bootstrap github --owner ocmOrg --repository mpas --registry ghcr.io/open-component-model/mpas-bootstrap-component --path clusters/my-cluster --dry-run --output-dir ./out
 `,
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
 			b := bootstrap.GithubCmd{
-				Owner:                 c.Owner,
-				Personal:              c.Personal,
-				Repository:            c.Repository,
-				FromFile:              c.FromFile,
-				Registry:              c.Registry,
-				DockerconfigPath:      cfg.DockerconfigPath,
-				Path:                  c.Path,
-				CommitMessageAppendix: c.CommitMessageAppendix,
-				Hostname:              c.Hostname,
-				Components:            append(env.InstallComponents, c.Components...),
-				CaFile:                c.CaFile,
-			}
-
-			token := os.Getenv(env.GithubTokenVar)
-			if token == "" {
-				token, err = passwdFromStdin("Github token: ")
-				if err != nil {
-					return fmt.Errorf("failed to read token from stdin: %w", err)
-				}
+				Owner:      c.Owner,
+				Personal:   c.Personal,
+				Repository: c.Repository,
+				Hostname:   c.Hostname,
 			}
-			b.Token = token
 
 			if b.Owner == "" {
 				return fmt.Errorf("owner must be set")
@@ -97,22 +90,17 @@ func NewBootstrapGithub(cfg *config.MpasConfig) *cobra.Command {
 				return fmt.Errorf("repository must be set")
 			}
 
-			if b.Registry == "" {
-				return fmt.Errorf("registry must be set")
-			}
-
-			b.Timeout, err = time.ParseDuration(cfg.Timeout)
+			b.Options, err = newBootstrapOptions(cmd.Context(), cfg, &c.BootstrapConfig)
 			if err != nil {
 				return err
 			}
 
-			b.Interval, err = time.ParseDuration(c.Interval)
+			b.Token, err = readToken(cmd.Context(), c.Credentials.TokenSource(env.GithubTokenVar, providerURL(cfg, env.ProviderGithub, c.Hostname)), "Github token: ")
 			if err != nil {
 				return err
 			}
 
 			return b.Execute(cmd.Context(), cfg)
-
 		},
 	}
 
@@ -138,59 +126,42 @@ func NewBootstrapGitea(cfg *config.MpasConfig) *cobra.Command {
 
     - Bootstrap with a public organization repository
     mpas bootstrap gitea --owner ocmOrg --repository mpas --registry ghcr.io/open-component-model/mpas-bootstrap-component --private=false --path clusters/my-cluster --hostname gitea.example.com
+
+    - Render the manifests to a local directory for review without committing or applying them
+    mpas bootstrap gitea --owner ocmOrg --repository mpas --registry ghcr.io/open-component-model/mpas-bootstrap-component --path clusters/my-cluster --hostname gitea.example.com --dry-run --output-dir ./out
 `,
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
 			b := bootstrap.GiteaCmd{
-				Owner:                 c.Owner,
-				Personal:              c.Personal,
-				Repository:            c.Repository,
-				FromFile:              c.FromFile,
-				Registry:              c.Registry,
-				DockerconfigPath:      cfg.DockerconfigPath,
-				Path:                  c.Path,
-				CommitMessageAppendix: c.CommitMessageAppendix,
-				Hostname:              c.Hostname,
-				Components:            append(env.InstallComponents, c.Components...),
-				CaFile:                c.CaFile,
-			}
-
-			token := os.Getenv(env.GiteaTokenVar)
-			if token == "" {
-				token, err = passwdFromStdin("Gitea token: ")
-				if err != nil {
-					return fmt.Errorf("failed to read token from stdin: %w", err)
-				}
-			}
-			b.Token = token
-
-			if b.Owner == "" {
-				return fmt.Errorf("owner must be set")
+				Owner:      c.Owner,
+				Personal:   c.Personal,
+				Repository: c.Repository,
+				Hostname:   c.Hostname,
 			}
 
+			// the credentials are requested for the hostname
 			if b.Hostname == "" {
 				return fmt.Errorf("hostname must be set")
 			}
 
-			if b.Repository == "" {
-				return fmt.Errorf("repository must be set")
+			if b.Owner == "" {
+				return fmt.Errorf("owner must be set")
 			}
 
-			if b.Registry == "" {
-				return fmt.Errorf("registry must be set")
+			if b.Repository == "" {
+				return fmt.Errorf("repository must be set")
 			}
 
-			b.Timeout, err = time.ParseDuration(cfg.Timeout)
+			b.Options, err = newBootstrapOptions(cmd.Context(), cfg, &c.BootstrapConfig)
 			if err != nil {
 				return err
 			}
 
-			b.Interval, err = time.ParseDuration(c.Interval)
+			b.Token, err = readToken(cmd.Context(), c.Credentials.TokenSource(env.GiteaTokenVar, providerURL(cfg, env.ProviderGitea, c.Hostname)), "Gitea token: ")
 			if err != nil {
 				return err
 			}
 
 			return b.Execute(cmd.Context(), cfg)
-
 		},
 	}
 
@@ -219,56 +190,199 @@ func NewBootstrapGitlab(cfg *config.MpasConfig) *cobra.Command {
 
     - Bootstrap with a public organization repository by setting token type to oauth
     mpas bootstrap gitlab --owner ocmOrg --repository mpas --registry ghcr.io/open-component-model/mpas-bootstrap-component --private=false --path clusters/my-cluster --hostname gitlab.example.com --token-type oauth
+
+    - Render the manifests to a local directory for review without committing or applying them
+    mpas bootstrap gitlab --owner ocmOrg --repository mpas --registry ghcr.io/open-component-model/mpas-bootstrap-component --path clusters/my-cluster --hostname gitlab.example.com --dry-run --output-dir ./out
 `,
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
 			b := bootstrap.GitlabCmd{
-				Owner:                 c.Owner,
-				TokenType:             c.TokenType,
-				Personal:              c.Personal,
-				Repository:            c.Repository,
-				FromFile:              c.FromFile,
-				Registry:              c.Registry,
-				DockerconfigPath:      cfg.DockerconfigPath,
-				Path:                  c.Path,
-				CommitMessageAppendix: c.CommitMessageAppendix,
-				Hostname:              c.Hostname,
-				Components:            append(env.InstallComponents, c.Components...),
-				CaFile:                c.CaFile,
-			}
-
-			token := os.Getenv(env.GitlabTokenVar)
-			if token == "" {
-				token, err = passwdFromStdin("Gitlab token: ")
-				if err != nil {
-					return fmt.Errorf("failed to read token from stdin: %w", err)
-				}
+				Owner:      c.Owner,
+				TokenType:  c.TokenType,
+				Personal:   c.Personal,
+				Repository: c.Repository,
+				Hostname:   c.Hostname,
+			}
+
+			if b.Owner == "" {
+				return fmt.Errorf("owner must be set")
+			}
+
+			if b.Repository == "" {
+				return fmt.Errorf("repository must be set")
+			}
+
+			b.Options, err = newBootstrapOptions(cmd.Context(), cfg, &c.BootstrapConfig)
+			if err != nil {
+				return err
+			}
+
+			b.Token, err = readToken(cmd.Context(), c.Credentials.TokenSource(env.GitlabTokenVar, providerURL(cfg, env.ProviderGitlab, c.Hostname)), "Gitlab token: ")
+			if err != nil {
+				return err
+			}
+
+			return b.Execute(cmd.Context(), cfg)
+		},
+	}
+
+	c.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// NewBootstrapBitbucketServer returns a new cobra.Command for bitbucket server bootstrap
+func NewBootstrapBitbucketServer(cfg *config.MpasConfig) *cobra.Command {
+	c := &config.BitbucketServerConfig{}
+	cmd := &cobra.Command{
+		Use:   "bitbucket-server [flags]",
+		Short: "Bootstrap an mpas management repository on Bitbucket Server or Data Center",
+		Example: `  - Bootstrap with a private project repository
+    mpas bootstrap bitbucket-server --owner PROJ --username myUser --repository mpas --registry ghcr.io/open-component-model/mpas-bootstrap-component --path clusters/my-cluster --hostname bitbucket.example.com
+
+    - Bootstrap with a private user repository
+    mpas bootstrap bitbucket-server --owner myUser --username myUser --repository mpas --registry ghcr.io/open-component-model/mpas-bootstrap-component --personal --path clusters/my-cluster --hostname bitbucket.example.com
+`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			b := bootstrap.BitbucketServerCmd{
+				Owner:      c.Owner,
+				Username:   c.Username,
+				Personal:   c.Personal,
+				Repository: c.Repository,
+				Hostname:   c.Hostname,
+			}
+
+			// the credentials are requested for the hostname
+			if b.Hostname == "" {
+				return fmt.Errorf("hostname must be set")
 			}
-			b.Token = token
 
 			if b.Owner == "" {
 				return fmt.Errorf("owner must be set")
 			}
 
+			if b.Username == "" {
+				return fmt.Errorf("username must be set")
+			}
+
 			if b.Repository == "" {
 				return fmt.Errorf("repository must be set")
 			}
 
-			if b.Registry == "" {
-				return fmt.Errorf("registry must be set")
+			b.Options, err = newBootstrapOptions(cmd.Context(), cfg, &c.BootstrapConfig)
+			if err != nil {
+				return err
+			}
+
+			b.Token, err = readToken(cmd.Context(), c.Credentials.TokenSource(env.BitbucketServerTokenVar, providerURL(cfg, env.ProviderBitbucketServer, c.Hostname)), "Bitbucket Server token: ")
+			if err != nil {
+				return err
+			}
+
+			return b.Execute(cmd.Context(), cfg)
+		},
+	}
+
+	c.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// NewBootstrapAzureDevOps returns a new cobra.Command for azure devops bootstrap
+func NewBootstrapAzureDevOps(cfg *config.MpasConfig) *cobra.Command {
+	c := &config.AzureDevOpsConfig{}
+	cmd := &cobra.Command{
+		Use:   "azure-devops [flags]",
+		Short: "Bootstrap an mpas management repository on Azure DevOps",
+		Example: `  - Bootstrap with a repository of an Azure DevOps Services project
+    mpas bootstrap azure-devops --owner myOrg --project myProject --repository mpas --registry ghcr.io/open-component-model/mpas-bootstrap-component --path clusters/my-cluster
+
+    - Bootstrap with a repository of an Azure DevOps Server project
+    mpas bootstrap azure-devops --owner myCollection --project myProject --repository mpas --registry ghcr.io/open-component-model/mpas-bootstrap-component --path clusters/my-cluster --hostname devops.example.com
+`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			b := bootstrap.AzureDevOpsCmd{
+				Owner:      c.Owner,
+				Project:    c.Project,
+				Repository: c.Repository,
+				Hostname:   c.Hostname,
+			}
+
+			if c.SSHKeyAlgorithm != "" || c.PrivateKeyFile != "" {
+				return fmt.Errorf("deploy keys are not supported by Azure DevOps")
+			}
+
+			if b.Owner == "" {
+				return fmt.Errorf("owner must be set")
 			}
 
-			b.Timeout, err = time.ParseDuration(cfg.Timeout)
+			if b.Project == "" {
+				return fmt.Errorf("project must be set")
+			}
+
+			if b.Repository == "" {
+				return fmt.Errorf("repository must be set")
+			}
+
+			b.Options, err = newBootstrapOptions(cmd.Context(), cfg, &c.BootstrapConfig)
 			if err != nil {
 				return err
 			}
 
-			b.Interval, err = time.ParseDuration(c.Interval)
+			b.Token, err = readToken(cmd.Context(), c.Credentials.TokenSource(env.AzureDevOpsTokenVar, providerURL(cfg, env.ProviderAzureDevOps, c.Hostname)), "Azure DevOps token: ")
 			if err != nil {
 				return err
 			}
 
 			return b.Execute(cmd.Context(), cfg)
+		},
+	}
+
+	c.AddFlags(cmd.Flags())
 
+	return cmd
+}
+
+// NewBootstrapGit returns a new cobra.Command for plain git bootstrap
+func NewBootstrapGit(cfg *config.MpasConfig) *cobra.Command {
+	c := &config.GitConfig{}
+	cmd := &cobra.Command{
+		Use:   "git [flags]",
+		Short: "Bootstrap an mpas management repository on a plain git server",
+		Long: `Bootstrap an mpas management repository on a plain git server.
+The repository must already exist, the manifests are committed through a local clone and pushed to it.
+Over HTTP(S) the password is read from the GIT_PASSWORD environment variable or prompted for,
+over SSH the private key given with --private-key-file is used to push and is stored for flux to pull.`,
+		Example: `  - Bootstrap with a repository served over HTTPS
+    mpas bootstrap git --url https://git.example.com/ocm/mpas.git --username mpas --registry ghcr.io/open-component-model/mpas-bootstrap-component --path clusters/my-cluster
+
+    - Bootstrap with a repository served over SSH
+    mpas bootstrap git --url ssh://git@git.example.com/ocm/mpas.git --private-key-file ~/.ssh/id_ed25519 --registry ghcr.io/open-component-model/mpas-bootstrap-component --path clusters/my-cluster
+`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			b := bootstrap.GitCmd{
+				URL:      c.URL,
+				Username: c.Username,
+				Branch:   c.Branch,
+			}
+
+			if b.URL == "" {
+				return fmt.Errorf("url must be set")
+			}
+
+			b.Options, err = newBootstrapOptions(cmd.Context(), cfg, &c.BootstrapConfig)
+			if err != nil {
+				return err
+			}
+
+			// the private key authenticates over SSH, a password is only needed over HTTP(S)
+			if b.PrivateKeyFile == "" && !b.DryRun {
+				b.Password, err = readToken(cmd.Context(), c.Credentials.TokenSource(env.GitPasswordVar, b.URL), "Git password: ")
+				if err != nil {
+					return err
+				}
+			}
+
+			return b.Execute(cmd.Context(), cfg)
 		},
 	}
 
@@ -277,6 +391,136 @@ func NewBootstrapGitlab(cfg *config.MpasConfig) *cobra.Command {
 	return cmd
 }
 
+// newBootstrapOptions returns the options shared by the bootstrap commands of all git providers, with the
+// timeout, the interval and the clusters of the fleet parsed from the given configuration, and the passphrase
+// of the private key read from its source.
+func newBootstrapOptions(ctx context.Context, cfg *config.MpasConfig, c *config.BootstrapConfig) (o bootstrap.Options, err error) {
+	o = bootstrap.Options{
+		FromFile:               c.FromFile,
+		Registry:               c.Registry,
+		DockerconfigPath:       cfg.DockerconfigPath,
+		Path:                   c.Path,
+		CommitMessageAppendix:  c.CommitMessageAppendix,
+		Components:             append(env.InstallComponents, c.Components...),
+		CaFile:                 c.CaFile,
+		DryRun:                 c.DryRun,
+		OutputDir:              c.OutputDir,
+		Resume:                 c.Resume,
+		SingleCommit:           c.SingleCommit,
+		Concurrency:            c.Concurrency,
+		BootstrapVersion:       c.BootstrapVersion,
+		NamespaceMapping:       c.NamespaceMapping,
+		PatchesDir:             c.PatchesDir,
+		ImageRegistryMirror:    c.ImageRegistryMirror,
+		VerifyKey:              c.VerifyKey,
+		VerifySignatureName:    c.VerifySignatureName,
+		CertificateIssuer:      c.CertificateIssuer,
+		CertificateCAFile:      c.CertificateCAFile,
+		CertificateCAKeyFile:   c.CertificateCAKeyFile,
+		CertificateDuration:    c.CertificateDuration,
+		CertificateRenewBefore: c.CertificateRenewBefore,
+		ReportFile:             c.ReportFile,
+		SSHKeyAlgorithm:        c.SSHKeyAlgorithm,
+		PrivateKeyFile:         c.PrivateKeyFile,
+		ExistingFlux:           c.ExistingFlux,
+		ExistingCertManager:    c.ExistingCertManager,
+		Commit:                 c.Commit,
+		PullRequest:            c.PullRequest,
+		RegistryCredHelper:     c.Credentials.RegistryCredentialHelper,
+	}
+
+	if o.Registry == "" {
+		return o, fmt.Errorf("registry must be set")
+	}
+
+	if o.DryRun && o.OutputDir == "" {
+		return o, fmt.Errorf("output-dir must be set when using --dry-run")
+	}
+
+	if o.DryRun && o.Resume {
+		return o, fmt.Errorf("resume cannot be used with --dry-run")
+	}
+
+	o.Timeout, err = time.ParseDuration(cfg.Timeout)
+	if err != nil {
+		return o, err
+	}
+
+	o.Interval, err = time.ParseDuration(c.Interval)
+	if err != nil {
+		return o, err
+	}
+
+	o.Clusters, o.FleetBase, err = c.Fleet()
+	if err != nil {
+		return o, err
+	}
+
+	if o.PrivateKeyFile != "" {
+		o.PrivateKeyPassphrase, err = c.Credentials.PrivateKeyPassphrase(ctx)
+		if err != nil {
+			return o, err
+		}
+	}
+
+	return o, nil
+}
+
+// readToken reads the token from the given source. If the source holds none, it is prompted for,
+// unless the standard input is not a terminal, e.g. in CI.
+func readToken(ctx context.Context, source credentials.Source, prompt string) (string, error) {
+	token, err := source.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	if token != "" {
+		return token, nil
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("no token found, set it with --token-file, --token-exec-command, --git-credential-helper or its environment variable")
+	}
+
+	token, err = passwdFromStdin(prompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token from stdin: %w", err)
+	}
+
+	return token, nil
+}
+
+// defaultHostnames are the hostnames of the git providers used if no hostname is set.
+var defaultHostnames = map[string]string{
+	env.ProviderGithub:      "github.com",
+	env.ProviderGitlab:      "gitlab.com",
+	env.ProviderAzureDevOps: azuredevops.DefaultDomain,
+}
+
+// providerURL returns the URL of the given git provider the token is requested for, at the given hostname
+// or at the default hostname of the provider if none is set.
+func providerURL(cfg *config.MpasConfig, provider, hostname string) string {
+	if hostname == "" {
+		hostname = defaultHostnames[provider]
+	}
+
+	scheme := "https"
+	if cfg.PlainHTTP {
+		scheme = "http"
+	}
+
+	return fmt.Sprintf("%s://%s", scheme, hostname)
+}
+
+// tokenURL returns the URL the token is requested for, the URL of a repository on a plain git server if set,
+// otherwise the URL of the given git provider.
+func tokenURL(cfg *config.MpasConfig, provider, hostname, repositoryURL string) string {
+	if repositoryURL != "" {
+		return repositoryURL
+	}
+
+	return providerURL(cfg, provider, hostname)
+}
+
 // passwdFromStdin reads a password from stdin.
 func passwdFromStdin(prompt string) (string, error) {
 	// Get the initial state of the terminal.
@@ -0,0 +1,64 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+
+	"github.com/open-component-model/mpas/cmd/mpas/config"
+	"github.com/open-component-model/mpas/internal/bootstrap"
+	"github.com/open-component-model/mpas/internal/bootstrap/provider"
+	"github.com/open-component-model/mpas/internal/env"
+)
+
+// AzureDevOpsCmd is a command for bootstrapping an Azure DevOps repository
+type AzureDevOpsCmd struct {
+	Options
+	// Owner is the organization of the repository
+	Owner string
+	// Project is the project of the repository
+	Project string
+	// Token is the personal access token to use for authentication
+	Token string
+	// Hostname is the hostname of the Azure DevOps instance
+	Hostname string
+	// Repository is the name of the repository
+	Repository string
+	// DestructiveActions indicates whether destructive actions are allowed
+	DestructiveActions bool
+	bootstrapper       *bootstrap.Bootstrap
+}
+
+// Execute executes the command and returns an error if one occurred.
+func (b *AzureDevOpsCmd) Execute(ctx context.Context, cfg *config.MpasConfig) (err error) {
+	providerOpts := provider.ProviderOptions{
+		Provider:           env.ProviderAzureDevOps,
+		Hostname:           b.Hostname,
+		Token:              b.Token,
+		DestructiveActions: b.DestructiveActions,
+	}
+
+	providerClient, err := provider.New().Build(providerOpts)
+	if err != nil {
+		return err
+	}
+
+	b.bootstrapper, err = b.run(ctx, cfg, providerClient,
+		bootstrap.WithOwner(b.Owner),
+		// the project is the sub-organization of the repository
+		bootstrap.WithRepositoryName(b.Project+"/"+b.Repository),
+		bootstrap.WithToken(b.Token),
+		bootstrap.WithTransportType(transportType(cfg)),
+	)
+	return err
+}
+
+// Cleanup cleans up the resources created by the command.
+func (b *AzureDevOpsCmd) Cleanup(ctx context.Context) error {
+	if b.bootstrapper != nil {
+		return b.bootstrapper.DeleteManagementRepository(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+
+	"github.com/open-component-model/mpas/cmd/mpas/config"
+	"github.com/open-component-model/mpas/internal/bootstrap"
+	"github.com/open-component-model/mpas/internal/bootstrap/provider"
+	"github.com/open-component-model/mpas/internal/env"
+)
+
+// BitbucketServerCmd is a command for bootstrapping a Bitbucket Server or Data Center repository
+type BitbucketServerCmd struct {
+	Options
+	// Owner is the project key or the user owning the repository
+	Owner string
+	// Username is the username to use for authentication
+	Username string
+	// Token is the token to use for authentication
+	Token string
+	// Personal indicates whether the repository is a personal repository
+	Personal bool
+	// Hostname is the hostname of the Bitbucket Server instance
+	Hostname string
+	// Repository is the name of the repository
+	Repository string
+	// Private indicates whether the repository is private
+	Private bool
+	// DestructiveActions indicates whether destructive actions are allowed
+	DestructiveActions bool
+	bootstrapper       *bootstrap.Bootstrap
+}
+
+// Execute executes the command and returns an error if one occurred.
+func (b *BitbucketServerCmd) Execute(ctx context.Context, cfg *config.MpasConfig) (err error) {
+	providerOpts := provider.ProviderOptions{
+		Provider:           env.ProviderBitbucketServer,
+		Hostname:           b.Hostname,
+		Username:           b.Username,
+		Token:              b.Token,
+		DestructiveActions: b.DestructiveActions,
+	}
+
+	providerClient, err := provider.New().Build(providerOpts)
+	if err != nil {
+		return err
+	}
+
+	b.bootstrapper, err = b.run(ctx, cfg, providerClient,
+		bootstrap.WithOwner(b.Owner),
+		bootstrap.WithRepositoryName(b.Repository),
+		bootstrap.WithPersonal(b.Personal),
+		bootstrap.WithUsername(b.Username),
+		bootstrap.WithToken(b.Token),
+		bootstrap.WithTransportType(transportType(cfg)),
+		bootstrap.WithVisibility(visibility(b.Private)),
+	)
+	return err
+}
+
+// Cleanup cleans up the resources created by the command.
+func (b *BitbucketServerCmd) Cleanup(ctx context.Context) error {
+	if b.bootstrapper != nil {
+		return b.bootstrapper.DeleteManagementRepository(ctx)
+	}
+	return nil
+}
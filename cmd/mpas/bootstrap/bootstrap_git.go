@@ -0,0 +1,37 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+
+	"github.com/open-component-model/mpas/cmd/mpas/config"
+	"github.com/open-component-model/mpas/internal/bootstrap"
+)
+
+// GitCmd is a command for bootstrapping an existing repository on a plain git server
+type GitCmd struct {
+	Options
+	// URL is the HTTP(S) or SSH URL of the repository
+	URL string
+	// Username is the username to use for authentication over HTTP(S)
+	Username string
+	// Password is the password to use for authentication over HTTP(S)
+	Password string
+	// Branch is the branch of the repository
+	Branch       string
+	bootstrapper *bootstrap.Bootstrap
+}
+
+// Execute executes the command and returns an error if one occurred.
+func (b *GitCmd) Execute(ctx context.Context, cfg *config.MpasConfig) (err error) {
+	b.bootstrapper, err = b.run(ctx, cfg, nil,
+		bootstrap.WithRepositoryURL(b.URL),
+		bootstrap.WithUsername(b.Username),
+		bootstrap.WithToken(b.Password),
+		bootstrap.WithDefaultBranch(b.Branch),
+	)
+	return err
+}
@@ -7,17 +7,16 @@ package bootstrap
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"github.com/open-component-model/mpas/cmd/mpas/config"
 	"github.com/open-component-model/mpas/internal/bootstrap"
 	"github.com/open-component-model/mpas/internal/bootstrap/provider"
 	"github.com/open-component-model/mpas/internal/env"
-	"github.com/open-component-model/mpas/internal/kubeutils"
 )
 
 // GiteaCmd is a command for bootstrapping a Gitea repository
 type GiteaCmd struct {
+	Options
 	// Owner is the owner of the repository
 	Owner string
 	// Token is the token to use for authentication
@@ -28,43 +27,15 @@ type GiteaCmd struct {
 	Hostname string
 	// Repository is the name of the repository
 	Repository string
-	// FromFile is the path to a file archive to use for bootstrapping
-	FromFile string
-	// Registry is the registry to use for the bootstrap components
-	Registry string
-	// DockerconfigPath is the path to the docker config file
-	DockerconfigPath string
-	// Path is the path in the repository to use to host the bootstrapped components yamls
-	Path string
-	// CommitMessageAppendix is the appendix to add to the commit message
-	// for example to skip CI
-	CommitMessageAppendix string
 	// Private indicates whether the repository is private
 	Private bool
-	// Interval is the interval to use for reconciling
-	Interval time.Duration
-	// Timeout is the timeout to use for operations
-	Timeout time.Duration
-	// Components is the list of components to install
-	Components []string
 	// DestructiveActions indicates whether destructive actions are allowed
 	DestructiveActions bool
-	// TestURL is the URL to use for testing the management repository
-	TestURL string
-	// CaFile defines and optional root certificate for the git repository used by flux.
-	CaFile       string
-	bootstrapper *bootstrap.Bootstrap
+	bootstrapper       *bootstrap.Bootstrap
 }
 
 // Execute executes the command and returns an error if one occurred.
-func (b *GiteaCmd) Execute(ctx context.Context, cfg *config.MpasConfig) error {
-	t, err := time.ParseDuration(cfg.Timeout)
-	if err != nil {
-		return err
-	}
-	ctx, cancel := context.WithTimeout(ctx, t)
-	defer cancel()
-
+func (b *GiteaCmd) Execute(ctx context.Context, cfg *config.MpasConfig) (err error) {
 	if b.Hostname == "" {
 		return fmt.Errorf("hostname must be specified")
 	}
@@ -81,48 +52,15 @@ func (b *GiteaCmd) Execute(ctx context.Context, cfg *config.MpasConfig) error {
 		return err
 	}
 
-	kubeClient, err := kubeutils.KubeClient(cfg.KubeConfigArgs)
-	if err != nil {
-		return err
-	}
-
-	visibility := "public"
-	if b.Private {
-		visibility = "private"
-	}
-
-	transport := "https"
-	if cfg.PlainHTTP {
-		transport = "http"
-	}
-
-	b.bootstrapper, err = bootstrap.New(providerClient,
+	b.bootstrapper, err = b.run(ctx, cfg, providerClient,
 		bootstrap.WithOwner(b.Owner),
 		bootstrap.WithRepositoryName(b.Repository),
 		bootstrap.WithPersonal(b.Personal),
-		bootstrap.WithFromFile(b.FromFile),
-		bootstrap.WithRegistry(b.Registry),
-		bootstrap.WithPrinter(cfg.Printer),
-		bootstrap.WithComponents(b.Components),
 		bootstrap.WithToken(b.Token),
-		bootstrap.WithTransportType(transport),
-		bootstrap.WithDockerConfigPath(b.DockerconfigPath),
-		bootstrap.WithTarget(b.Path),
-		bootstrap.WithKubeClient(kubeClient),
-		bootstrap.WithRESTClientGetter(cfg.KubeConfigArgs),
-		bootstrap.WithInterval(b.Interval),
-		bootstrap.WithTimeout(b.Timeout),
-		bootstrap.WithCommitMessageAppendix(b.CommitMessageAppendix),
-		bootstrap.WithVisibility(visibility),
-		bootstrap.WithTestURL(b.TestURL),
-		bootstrap.WithRootFile(b.CaFile),
+		bootstrap.WithTransportType(transportType(cfg)),
+		bootstrap.WithVisibility(visibility(b.Private)),
 	)
-
-	if err != nil {
-		return err
-	}
-
-	return b.bootstrapper.Run(ctx)
+	return err
 }
 
 // Cleanup cleans up the resources created by the command.
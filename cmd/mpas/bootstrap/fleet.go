@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/open-component-model/mpas/cmd/mpas/config"
+	"github.com/open-component-model/mpas/internal/bootstrap"
+	"github.com/open-component-model/mpas/internal/kubeutils"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// kubeClientOptions returns the options of the bootstrap targeting the cluster of the given kubeconfig arguments.
+func kubeClientOptions(kubeConfigArgs *genericclioptions.ConfigFlags, dryRun bool) ([]bootstrap.Option, error) {
+	// a dry-run does not talk to the cluster
+	var kubeClient client.WithWatch
+	if !dryRun {
+		var err error
+		kubeClient, err = kubeutils.KubeClient(kubeConfigArgs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return []bootstrap.Option{
+		bootstrap.WithKubeClient(kubeClient),
+		bootstrap.WithRESTClientGetter(kubeConfigArgs),
+	}, nil
+}
+
+// contextConfigFlags returns the kubeconfig arguments of the given ones targeting the given kube context.
+func contextConfigFlags(kubeConfigArgs *genericclioptions.ConfigFlags, kubeContext string) *genericclioptions.ConfigFlags {
+	flags := genericclioptions.NewConfigFlags(false)
+	flags.KubeConfig = kubeConfigArgs.KubeConfig
+	flags.CAFile = kubeConfigArgs.CAFile
+	flags.Insecure = kubeConfigArgs.Insecure
+	flags.Timeout = kubeConfigArgs.Timeout
+	flags.Context = &kubeContext
+
+	return flags
+}
+
+// runFleet bootstraps the given clusters of a fleet into the management repository, each with the given options
+// and a client of its kube context.
+func runFleet(ctx context.Context, cfg *config.MpasConfig, providerClient gitprovider.Client, clusters []bootstrap.Cluster, base string, dryRun bool, opts []bootstrap.Option) error {
+	fleet, err := bootstrap.NewFleet(clusters, base, cfg.Printer, func(cluster bootstrap.Cluster, clusterOpts ...bootstrap.Option) (*bootstrap.Bootstrap, error) {
+		kubeArgs := contextConfigFlags(cfg.KubeConfigArgs, cluster.Context)
+		kubeOpts, err := kubeClientOptions(kubeArgs, dryRun)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create client of context %s: %w", cluster.Context, err)
+		}
+
+		clusterOpts = append(append(append([]bootstrap.Option{}, opts...), kubeOpts...), clusterOpts...)
+		return bootstrap.New(providerClient, clusterOpts...)
+	})
+	if err != nil {
+		return err
+	}
+
+	return fleet.Run(ctx)
+}
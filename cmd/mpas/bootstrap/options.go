@@ -0,0 +1,180 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/open-component-model/mpas/cmd/mpas/config"
+	"github.com/open-component-model/mpas/internal/bootstrap"
+)
+
+// Options are the options shared by the bootstrap commands of all git providers.
+type Options struct {
+	// FromFile is the path to a file archive to use for bootstrapping
+	FromFile string
+	// Registry is the registry to use for the bootstrap components
+	Registry string
+	// DockerconfigPath is the path to the docker config file
+	DockerconfigPath string
+	// Path is the path in the repository to use to host the bootstrapped components yaml files
+	Path string
+	// CommitMessageAppendix is the appendix to add to the commit message
+	// for example to skip CI
+	CommitMessageAppendix string
+	// Interval is the interval to use for reconciling
+	Interval time.Duration
+	// Timeout is the timeout to use for operations
+	Timeout time.Duration
+	// Components is the list of components to install
+	Components []string
+	// CaFile defines and optional root certificate for the git repository used by flux.
+	CaFile string
+	// TestURL is the URL to use for testing the management repository
+	TestURL string
+	// DryRun indicates whether to only render the manifests to OutputDir
+	DryRun bool
+	// OutputDir is the directory the manifests are written to during a dry-run
+	OutputDir string
+	// Resume indicates whether to skip the phases completed by a previous bootstrap
+	Resume bool
+	// SingleCommit indicates whether to commit all component manifests at once
+	SingleCommit bool
+	// Concurrency is the number of component manifests generated concurrently
+	Concurrency int
+	// BootstrapVersion is the version, or semver constraint, of the bootstrap component to install
+	BootstrapVersion string
+	// NamespaceMapping maps the default namespaces of the components to the namespaces to install them to
+	NamespaceMapping map[string]string
+	// PatchesDir is the directory holding the kustomize patches of the components
+	PatchesDir string
+	// ImageRegistryMirror is the registry the components are copied to with their images
+	ImageRegistryMirror string
+	// VerifyKey is the public key file the signature of the bootstrap component is verified with
+	VerifyKey string
+	// VerifySignatureName is the name of the signature of the bootstrap component to verify
+	VerifySignatureName string
+	// CertificateIssuer is the existing cert-manager issuer signing the certificates
+	CertificateIssuer string
+	// CertificateCAFile is the CA certificate file the certificates are signed with
+	CertificateCAFile string
+	// CertificateCAKeyFile is the private key file of the CA certificate
+	CertificateCAKeyFile string
+	// CertificateDuration is the duration of the certificates
+	CertificateDuration string
+	// CertificateRenewBefore is how long before they expire the certificates are renewed
+	CertificateRenewBefore string
+	// ReportFile is the file the bootstrap report is written to
+	ReportFile string
+	// SSHKeyAlgorithm is the algorithm of the keypair generated for the deploy key used by flux
+	SSHKeyAlgorithm string
+	// PrivateKeyFile is the private key file used by flux to pull over SSH, and to push to a plain git server
+	PrivateKeyFile string
+	// PrivateKeyPassphrase is the passphrase of the private key file, if it is encrypted
+	PrivateKeyPassphrase string
+	// Clusters are the clusters of the fleet bootstrapped into the repository
+	Clusters []bootstrap.Cluster
+	// FleetBase is the path of the manifests shared by the clusters of the fleet
+	FleetBase string
+	// ExistingFlux indicates whether the flux installed in the cluster is adopted
+	ExistingFlux bool
+	// ExistingCertManager indicates whether the cert-manager installed in the cluster is adopted
+	ExistingCertManager bool
+	// PullRequest configures the pull request proposing the manifests
+	PullRequest config.PullRequestConfig
+	// RegistryCredHelper is the docker credential helper holding the credentials of the registries
+	RegistryCredHelper string
+	// Commit configures the author and the signature of the commits
+	Commit config.CommitConfig
+}
+
+// run bootstraps the cluster, or the clusters of the fleet, into the management repository with the shared options
+// and the given ones of the git provider. The provider client is nil for a repository on a plain git server.
+// The bootstrapper of a single cluster is returned even if the bootstrap failed, so that it can be cleaned up.
+func (o *Options) run(ctx context.Context, cfg *config.MpasConfig, providerClient gitprovider.Client, providerOpts ...bootstrap.Option) (*bootstrap.Bootstrap, error) {
+	t, err := time.ParseDuration(cfg.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, o.PullRequest.Timeout(t))
+	defer cancel()
+
+	opts := append(providerOpts,
+		bootstrap.WithFromFile(o.FromFile),
+		bootstrap.WithRegistry(o.Registry),
+		bootstrap.WithPrinter(cfg.Printer),
+		bootstrap.WithComponents(o.Components),
+		bootstrap.WithDockerConfigPath(o.DockerconfigPath),
+		bootstrap.WithTarget(o.Path),
+		bootstrap.WithInterval(o.Interval),
+		bootstrap.WithTimeout(o.Timeout),
+		bootstrap.WithCommitMessageAppendix(o.CommitMessageAppendix),
+		bootstrap.WithRootFile(o.CaFile),
+		bootstrap.WithTestURL(o.TestURL),
+		bootstrap.WithDryRun(o.DryRun),
+		bootstrap.WithOutputDir(o.OutputDir),
+		bootstrap.WithResume(o.Resume),
+		bootstrap.WithSingleCommit(o.SingleCommit),
+		bootstrap.WithConcurrency(o.Concurrency),
+		bootstrap.WithBootstrapVersion(o.BootstrapVersion),
+		bootstrap.WithNamespaces(o.NamespaceMapping),
+		bootstrap.WithPatchesDir(o.PatchesDir),
+		bootstrap.WithImageRegistryMirror(o.ImageRegistryMirror),
+		bootstrap.WithVerifyKey(o.VerifyKey),
+		bootstrap.WithVerifySignatureName(o.VerifySignatureName),
+		bootstrap.WithIssuerRef(o.CertificateIssuer),
+		bootstrap.WithCAKeyPair(o.CertificateCAFile, o.CertificateCAKeyFile),
+		bootstrap.WithCertificateDuration(o.CertificateDuration, o.CertificateRenewBefore),
+		bootstrap.WithReportFile(o.ReportFile),
+		bootstrap.WithSSHKeyAlgorithm(o.SSHKeyAlgorithm),
+		bootstrap.WithPrivateKeyFile(o.PrivateKeyFile),
+		bootstrap.WithPrivateKeyPassphrase(o.PrivateKeyPassphrase),
+		bootstrap.WithExistingFlux(o.ExistingFlux),
+		bootstrap.WithExistingCertManager(o.ExistingCertManager),
+		bootstrap.WithRegistryCredentialHelper(o.RegistryCredHelper),
+	)
+	commitOpts, err := o.Commit.Options(ctx)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, commitOpts...)
+	opts = append(opts, o.PullRequest.Options()...)
+
+	if len(o.Clusters) > 0 {
+		return nil, runFleet(ctx, cfg, providerClient, o.Clusters, o.FleetBase, o.DryRun, opts)
+	}
+
+	kubeOpts, err := kubeClientOptions(cfg.KubeConfigArgs, o.DryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := bootstrap.New(providerClient, append(opts, kubeOpts...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return b, b.Run(ctx)
+}
+
+// transportType returns the transport flux uses to pull the management repository of a git provider.
+func transportType(cfg *config.MpasConfig) string {
+	if cfg.PlainHTTP {
+		return "http"
+	}
+
+	return "https"
+}
+
+// visibility returns the visibility of the management repository created on a git provider.
+func visibility(private bool) string {
+	if private {
+		return "private"
+	}
+
+	return "public"
+}
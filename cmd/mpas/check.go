@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/open-component-model/mpas/cmd/mpas/config"
+	"github.com/open-component-model/mpas/internal/bootstrap"
+	"github.com/open-component-model/mpas/internal/bootstrap/provider"
+	"github.com/open-component-model/mpas/internal/env"
+	"github.com/open-component-model/mpas/internal/kubeutils"
+	"github.com/spf13/cobra"
+)
+
+// tokenVars are the environment variables holding the tokens of the git providers whose token can be checked.
+var tokenVars = map[string]string{
+	env.ProviderGithub: env.GithubTokenVar,
+	env.ProviderGitea:  env.GiteaTokenVar,
+	env.ProviderGitlab: env.GitlabTokenVar,
+}
+
+// NewCheck returns a new cobra.Command for check
+func NewCheck(cfg *config.MpasConfig) *cobra.Command {
+	c := &config.CheckConfig{}
+	cmd := &cobra.Command{
+		Use:   "check [flags]",
+		Short: "Check the prerequisites of bootstrapping MPAS into a Kubernetes cluster.",
+		Long: `Check the prerequisites of bootstrapping MPAS into a Kubernetes cluster.
+These are the preflight checks mpas bootstrap runs before committing anything: the Kubernetes version,
+the permissions of the user, the installed flux, conflicting installations of cert-manager and external-secrets,
+the registry, the bootstrap component and the permissions of the git token.`,
+		Example: `  - Check the prerequisites of bootstrapping the current cluster from a GitHub repository
+    mpas check --pre --provider github
+
+    - Check the prerequisites of bootstrapping a given version from an exported bundle
+    mpas check --pre --from-file /tmp/mpas-bundle.tar.gz --registry ghcr.io/my-org/mpas --bootstrap-version v0.5.0
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !c.Pre {
+				return fmt.Errorf("only the prerequisites of a bootstrap can be checked, use --pre")
+			}
+
+			return runPreflight(cmd.Context(), cfg, c)
+		},
+	}
+
+	c.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func runPreflight(ctx context.Context, cfg *config.MpasConfig, c *config.CheckConfig) error {
+	t, err := time.ParseDuration(cfg.Timeout)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, t)
+	defer cancel()
+
+	var providerClient gitprovider.Client
+	if c.Provider != "" {
+		providerClient, err = checkedProviderClient(ctx, cfg, c)
+		if err != nil {
+			return err
+		}
+	}
+
+	kubeClient, err := kubeutils.KubeClient(cfg.KubeConfigArgs)
+	if err != nil {
+		return err
+	}
+
+	transport := "https"
+	if cfg.PlainHTTP {
+		transport = "http"
+	}
+
+	preflight, err := bootstrap.NewPreflight(providerClient,
+		bootstrap.WithPrinter(cfg.Printer),
+		bootstrap.WithKubeClient(kubeClient),
+		bootstrap.WithRESTClientGetter(cfg.KubeConfigArgs),
+		bootstrap.WithRegistry(c.Registry),
+		bootstrap.WithFromFile(c.FromFile),
+		bootstrap.WithDockerConfigPath(cfg.DockerconfigPath),
+		bootstrap.WithTransportType(transport),
+		bootstrap.WithBootstrapVersion(c.BootstrapVersion),
+		bootstrap.WithComponents(c.Components),
+		bootstrap.WithExistingFlux(c.ExistingFlux),
+		bootstrap.WithExistingCertManager(c.ExistingCertManager),
+		bootstrap.WithRegistryCredentialHelper(c.Credentials.RegistryCredentialHelper),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := preflight.Run(ctx); err != nil {
+		return err
+	}
+
+	cfg.Printer.Printf("All prerequisites are met\n")
+	return nil
+}
+
+// checkedProviderClient returns the client of the git provider whose token is checked.
+func checkedProviderClient(ctx context.Context, cfg *config.MpasConfig, c *config.CheckConfig) (gitprovider.Client, error) {
+	tokenVar, ok := tokenVars[c.Provider]
+	if !ok {
+		return nil, fmt.Errorf("provider %s not supported", c.Provider)
+	}
+
+	token, err := readToken(ctx, c.Credentials.TokenSource(tokenVar, providerURL(cfg, c.Provider, c.Hostname)), fmt.Sprintf("%s token: ", c.Provider))
+	if err != nil {
+		return nil, err
+	}
+
+	hostname := c.Hostname
+	if hostname == "" && c.Provider == env.ProviderGithub {
+		hostname = "github.com"
+	}
+
+	return provider.New().Build(provider.ProviderOptions{
+		Provider: c.Provider,
+		Hostname: hostname,
+		Token:    token,
+	})
+}
@@ -5,8 +5,12 @@
 package config
 
 import (
+	"context"
+	"fmt"
 	"time"
 
+	"github.com/open-component-model/mpas/internal/bootstrap"
+	"github.com/open-component-model/mpas/internal/credentials"
 	"github.com/open-component-model/mpas/internal/env"
 	"github.com/open-component-model/mpas/internal/printer"
 	"github.com/spf13/pflag"
@@ -67,6 +71,60 @@ type BootstrapConfig struct {
 	Private bool
 	// CaFile defines and optional root certificate for the git repository used by flux.
 	CaFile string
+	// DryRun indicates whether to only render the manifests to OutputDir.
+	DryRun bool
+	// OutputDir is the directory the manifests are written to during a dry-run.
+	OutputDir string
+	// Resume indicates whether to skip the phases completed by a previous bootstrap.
+	Resume bool
+	// SingleCommit indicates whether to commit all component manifests at once.
+	SingleCommit bool
+	// Concurrency is the number of component manifests generated concurrently.
+	Concurrency int
+	// BootstrapVersion is the version, or semver constraint, of the bootstrap component to install.
+	BootstrapVersion string
+	// NamespaceMapping maps the default namespaces of the components to the namespaces to install them to.
+	NamespaceMapping map[string]string
+	// PatchesDir is the directory holding the kustomize patches of the components.
+	PatchesDir string
+	// ImageRegistryMirror is the registry the components are copied to with their images.
+	ImageRegistryMirror string
+	// VerifyKey is the public key file the signature of the bootstrap component is verified with.
+	VerifyKey string
+	// VerifySignatureName is the name of the signature of the bootstrap component to verify.
+	VerifySignatureName string
+	// CertificateIssuer is the existing cert-manager issuer signing the certificates of the components.
+	CertificateIssuer string
+	// CertificateCAFile is the CA certificate file the certificates of the components are signed with.
+	CertificateCAFile string
+	// CertificateCAKeyFile is the private key file of the CA certificate.
+	CertificateCAKeyFile string
+	// CertificateDuration is the duration of the certificates of the components.
+	CertificateDuration string
+	// CertificateRenewBefore is how long before they expire the certificates of the components are renewed.
+	CertificateRenewBefore string
+	// ReportFile is the file the bootstrap report is written to in JSON.
+	ReportFile string
+	// SSHKeyAlgorithm is the algorithm of the keypair generated for the deploy key used by flux.
+	SSHKeyAlgorithm string
+	// PrivateKeyFile is the private key file of the keypair to use for the deploy key used by flux.
+	PrivateKeyFile string
+	// Clusters are the clusters of a fleet, as name=context:path.
+	Clusters []string
+	// FleetFile is the file listing the clusters of a fleet.
+	FleetFile string
+	// FleetBase is the path of the manifests shared by the clusters of a fleet.
+	FleetBase string
+	// ExistingFlux indicates whether to adopt the flux installed in the cluster instead of installing it.
+	ExistingFlux bool
+	// ExistingCertManager indicates whether to adopt the cert-manager installed in the cluster instead of installing it.
+	ExistingCertManager bool
+	// Commit configures the author and the signature of the commits.
+	Commit CommitConfig
+	// PullRequest configures the pull request proposing the manifests.
+	PullRequest PullRequestConfig
+	// Credentials configures the sources of the credentials.
+	Credentials CredentialsConfig
 }
 
 // AddFlags adds the bootstrap flags to the given flag set.
@@ -74,7 +132,7 @@ func (m *BootstrapConfig) AddFlags(flags *pflag.FlagSet) {
 	flags.StringSliceVar(&m.Components, "components", []string{env.ExternalSecretsName}, "The components to include in the management repository")
 	flags.StringVar(&m.Owner, "owner", "", "The owner of the management repository")
 	flags.StringVar(&m.Repository, "repository", "", "The name of the management repository")
-	flags.StringVar(&m.FromFile, "from-file", "", "The path to a file containing the bootstrap component in archive format")
+	flags.StringVar(&m.FromFile, "from-file", "", "The bootstrap bundle to install from, either the tar.gz archive exported with --export, a CTF directory or an OCI image layout holding the archive")
 	flags.StringVar(&m.Registry, "registry", env.DefaultBootstrapComponentLocation, "The registry to use to retrieve the bootstrap component. Defaults to ghcr.io/open-component-model/mpas-bootstrap-component")
 	flags.StringVar(&m.Hostname, "hostname", "", "The hostname of the Git provider")
 	flags.StringVar(&m.Path, "path", ".", "The target path to use in the management repository to store the bootstrap component")
@@ -82,6 +140,211 @@ func (m *BootstrapConfig) AddFlags(flags *pflag.FlagSet) {
 	flags.StringVar(&m.CommitMessageAppendix, "commit-message-appendix", "", "The appendix to add to the commit message, e.g. [ci skip]")
 	flags.BoolVar(&m.Private, "private", false, "Whether the management repository should be private")
 	flags.StringVar(&m.CaFile, "ca-file", "", "Root certificate for the remote git server.")
+	flags.BoolVar(&m.DryRun, "dry-run", false, "Render the manifests to --output-dir instead of committing them and applying them to the cluster")
+	flags.StringVar(&m.OutputDir, "output-dir", "", "The directory to write the rendered manifests to when --dry-run is set")
+	flags.BoolVar(&m.Resume, "resume", false, "Resume a previous bootstrap, skipping the phases it completed")
+	flags.BoolVar(&m.SingleCommit, "single-commit", false, "Commit the manifests of all components to the management repository at once instead of one commit per component")
+	flags.IntVar(&m.Concurrency, "concurrency", env.DefaultConcurrency, "The number of component manifests generated concurrently")
+	flags.StringVar(&m.BootstrapVersion, "bootstrap-version", "", "The version, or semver constraint, of the bootstrap component to install. Defaults to the latest version")
+	flags.StringToStringVar(&m.NamespaceMapping, "namespace-mapping", nil, "Maps the default namespaces of the components to the namespaces to install them to, e.g. default=tenant-external-secrets,ocm-system=tenant-ocm-system")
+	flags.StringVar(&m.PatchesDir, "patches-dir", "", "The directory holding the kustomize patches of the components, in a subdirectory named after each component")
+	flags.StringVar(&m.ImageRegistryMirror, "image-registry-mirror", "", "The registry to copy the components to with their images, the manifests refer to the copied images")
+	flags.StringVar(&m.VerifyKey, "verify-key", "", "The public key file to verify the signature of the bootstrap component and the components it references with before installing them")
+	flags.StringVar(&m.VerifySignatureName, "verify-signature-name", "", "The name of the signature to verify with --verify-key. Defaults to the only signature of the bootstrap component")
+	flags.StringVar(&m.CertificateIssuer, "certificate-issuer", "", "The existing cert-manager issuer signing the certificates of the components, as Issuer/<name> or ClusterIssuer/<name>. Defaults to a self-signed CA")
+	flags.StringVar(&m.CertificateCAFile, "certificate-ca-file", "", "The CA certificate file the certificates of the components are signed with instead of a self-signed CA, requires --certificate-ca-key-file")
+	flags.StringVar(&m.CertificateCAKeyFile, "certificate-ca-key-file", "", "The private key file of the CA certificate given with --certificate-ca-file")
+	flags.StringVar(&m.CertificateDuration, "certificate-duration", "", "The duration of the certificates of the components, e.g. 2160h. Defaults to the cert-manager default")
+	flags.StringVar(&m.CertificateRenewBefore, "certificate-renew-before", "", "How long before they expire the certificates of the components are renewed, e.g. 360h. Defaults to the cert-manager default")
+	flags.StringVar(&m.ReportFile, "report-file", "", "The file to write the bootstrap report to in JSON, with the installed components and the result of each phase. It is written even if the bootstrap fails")
+	flags.StringVar(&m.SSHKeyAlgorithm, "ssh-key-algorithm", "", "Make flux pull the management repository over SSH with a generated read-only deploy key of the given algorithm (rsa, ecdsa or ed25519)")
+	flags.StringVar(&m.PrivateKeyFile, "private-key-file", "", "Make flux pull the management repository over SSH with a read-only deploy key using the given private key file")
+	flags.StringArrayVar(&m.Clusters, "cluster", nil, "A cluster of a fleet bootstrapped into the management repository, as name=kubecontext:path. Repeat it for each cluster")
+	flags.StringVar(&m.FleetFile, "fleet-file", "", "The file listing the clusters of a fleet bootstrapped into the management repository, instead of --cluster")
+	flags.StringVar(&m.FleetBase, "fleet-base", "", "The path of the component manifests shared by the clusters of a fleet, defaults to fleet/base")
+	flags.BoolVar(&m.ExistingFlux, "existing-flux", false, "Adopt the flux installed in the cluster instead of installing it, flux is configured to sync the management repository")
+	flags.BoolVar(&m.ExistingFlux, "skip-flux", false, "Alias of --existing-flux")
+	flags.BoolVar(&m.ExistingCertManager, "existing-cert-manager", false, "Adopt the cert-manager installed in the cluster instead of installing it")
+	m.Commit.AddFlags(flags)
+	m.PullRequest.AddFlags(flags)
+	m.Credentials.AddFlags(flags)
+}
+
+// Fleet returns the clusters of the fleet given with --cluster or --fleet-file, and the path of their shared base.
+// It returns no clusters if a single cluster is bootstrapped.
+func (m *BootstrapConfig) Fleet() ([]bootstrap.Cluster, string, error) {
+	if len(m.Clusters) > 0 && m.FleetFile != "" {
+		return nil, "", fmt.Errorf("--cluster and --fleet-file cannot be used together")
+	}
+
+	var (
+		clusters []bootstrap.Cluster
+		base     string
+	)
+	switch {
+	case m.FleetFile != "":
+		var err error
+		clusters, base, err = bootstrap.LoadFleetFile(m.FleetFile)
+		if err != nil {
+			return nil, "", err
+		}
+	case len(m.Clusters) > 0:
+		for _, c := range m.Clusters {
+			cluster, err := bootstrap.ParseCluster(c)
+			if err != nil {
+				return nil, "", err
+			}
+			clusters = append(clusters, cluster)
+		}
+	case m.FleetBase != "":
+		return nil, "", fmt.Errorf("--fleet-base requires --cluster or --fleet-file")
+	}
+
+	if m.FleetBase != "" {
+		base = m.FleetBase
+	}
+
+	return clusters, base, nil
+}
+
+// CommitConfig is the configuration of the commits made to the management repository.
+type CommitConfig struct {
+	// AuthorName is the name of the author of the commits.
+	AuthorName string
+	// AuthorEmail is the email of the author of the commits.
+	AuthorEmail string
+	// GPGKeyRing is the armored OpenPGP key ring to sign the commits with.
+	GPGKeyRing string
+	// GPGPassphraseFile is the file holding the passphrase of the OpenPGP private key.
+	GPGPassphraseFile string
+	// GPGKeyID is the ID of the OpenPGP key of the key ring to sign the commits with.
+	GPGKeyID string
+	// SSHSigningKey is the SSH private key file to sign the commits with.
+	SSHSigningKey string
+	// SSHSigningKeyPassphraseFile is the file holding the passphrase of the SSH private key.
+	SSHSigningKeyPassphraseFile string
+}
+
+// AddFlags adds the commit flags to the given flag set.
+func (c *CommitConfig) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&c.AuthorName, "author-name", "", "The name of the author of the commits made to the management repository")
+	flags.StringVar(&c.AuthorEmail, "author-email", "", "The email of the author of the commits made to the management repository")
+	flags.StringVar(&c.GPGKeyRing, "gpg-key-ring", "", "The armored OpenPGP key ring to sign the commits made to the management repository with")
+	flags.StringVar(&c.GPGPassphraseFile, "gpg-passphrase-file", "", "The file holding the passphrase of the OpenPGP private key given with --gpg-key-ring. Defaults to $"+env.GPGPassphraseVar)
+	flags.StringVar(&c.GPGKeyID, "gpg-key-id", "", "The ID of the OpenPGP key to sign the commits with. Defaults to the first key of the key ring")
+	flags.StringVar(&c.SSHSigningKey, "ssh-signing-key", "", "The SSH private key file to sign the commits made to the management repository with")
+	flags.StringVar(&c.SSHSigningKeyPassphraseFile, "ssh-signing-key-passphrase-file", "", "The file holding the passphrase of the SSH private key given with --ssh-signing-key. Defaults to $"+env.SSHSigningKeyPassphraseVar)
+}
+
+// Options returns the bootstrap options setting the author and the signature of the commits.
+// The passphrases are read from their files or environment variables, so that they are not passed on the command line.
+func (c *CommitConfig) Options(ctx context.Context) ([]bootstrap.Option, error) {
+	gpgPassphrase, err := credentials.Chain(credentials.File(c.GPGPassphraseFile), credentials.Env(env.GPGPassphraseVar)).Token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the OpenPGP key passphrase: %w", err)
+	}
+
+	sshPassphrase, err := credentials.Chain(credentials.File(c.SSHSigningKeyPassphraseFile), credentials.Env(env.SSHSigningKeyPassphraseVar)).Token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the SSH signing key passphrase: %w", err)
+	}
+
+	return []bootstrap.Option{
+		bootstrap.WithCommitAuthor(c.AuthorName, c.AuthorEmail),
+		bootstrap.WithGPGSigning(c.GPGKeyRing, gpgPassphrase, c.GPGKeyID),
+		bootstrap.WithSSHSigning(c.SSHSigningKey, sshPassphrase),
+	}, nil
+}
+
+// CredentialsConfig is the configuration of the sources of the token authenticating against the git provider,
+// and of the credentials of the registries.
+type CredentialsConfig struct {
+	// TokenFile is the file holding the token.
+	TokenFile string
+	// GitCredentialHelper indicates whether the token is read from the git credential helpers.
+	GitCredentialHelper bool
+	// TokenExecCommand is the exec plugin returning the token.
+	TokenExecCommand string
+	// TokenExecArgs are the arguments of the exec plugin.
+	TokenExecArgs []string
+	// RegistryCredentialHelper is the docker credential helper holding the credentials of the registries.
+	RegistryCredentialHelper string
+	// PrivateKeyPassphraseFile is the file holding the passphrase of the private key file.
+	PrivateKeyPassphraseFile string
+}
+
+// AddFlags adds the credentials flags to the given flag set.
+func (c *CredentialsConfig) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&c.TokenFile, "token-file", "", "The file holding the token of the git provider, e.g. a mounted secret")
+	flags.BoolVar(&c.GitCredentialHelper, "git-credential-helper", false, "Read the token of the git provider from the configured git credential helpers")
+	flags.StringVar(&c.TokenExecCommand, "token-exec-command", "", "The exec plugin returning the token of the git provider as an ExecCredential of API version "+credentials.ExecAPIVersion)
+	flags.StringSliceVar(&c.TokenExecArgs, "token-exec-arg", nil, "The arguments of the exec plugin given with --token-exec-command")
+	flags.StringVar(&c.RegistryCredentialHelper, "registry-credential-helper", "", "The docker credential helper holding the credentials of the registries, e.g. ecr-login for docker-credential-ecr-login")
+	flags.StringVar(&c.PrivateKeyPassphraseFile, "private-key-passphrase-file", "", "The file holding the passphrase of the private key given with --private-key-file, if it is encrypted. Defaults to $"+env.PrivateKeyPassphraseVar)
+}
+
+// PrivateKeyPassphrase returns the passphrase of the private key file, read from the passphrase file if set,
+// otherwise from its environment variable. It is empty if the private key is not encrypted.
+func (c *CredentialsConfig) PrivateKeyPassphrase(ctx context.Context) (string, error) {
+	passphrase, err := credentials.Chain(credentials.File(c.PrivateKeyPassphraseFile), credentials.Env(env.PrivateKeyPassphraseVar)).Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the private key passphrase: %w", err)
+	}
+
+	return passphrase, nil
+}
+
+// TokenSource returns the source of the token authenticating against the given URL. The token is read from
+// the token file, the exec plugin or the git credential helpers if set, otherwise from the given environment variable.
+func (c *CredentialsConfig) TokenSource(tokenVar, url string) credentials.Source {
+	sources := []credentials.Source{
+		credentials.File(c.TokenFile),
+		credentials.Exec(c.TokenExecCommand, c.TokenExecArgs, url),
+	}
+	if c.GitCredentialHelper {
+		sources = append(sources, credentials.GitCredentialHelper(url))
+	}
+
+	return credentials.Chain(append(sources, credentials.Env(tokenVar))...)
+}
+
+// PullRequestConfig is the configuration of the pull request proposing the manifests to the management repository.
+type PullRequestConfig struct {
+	// ViaPullRequest indicates whether the manifests are proposed with a pull request.
+	ViaPullRequest bool
+	// Branch is the branch the manifests are pushed to.
+	Branch string
+	// WaitForMerge indicates whether to wait for the pull request to be merged.
+	WaitForMerge bool
+	// MergeTimeout is how long to wait for the pull request to be merged.
+	MergeTimeout time.Duration
+}
+
+// AddFlags adds the pull request flags to the given flag set.
+func (p *PullRequestConfig) AddFlags(flags *pflag.FlagSet) {
+	flags.BoolVar(&p.ViaPullRequest, "via-pull-request", false, "Push the manifests to a branch and propose them to the default branch with a pull request instead of committing them to it")
+	flags.StringVar(&p.Branch, "pull-request-branch", "", "The branch the manifests are pushed to with --via-pull-request. Defaults to mpas/<bootstrap|upgrade>-<version>")
+	flags.BoolVar(&p.WaitForMerge, "wait-for-merge", false, "Wait for the pull request to be merged, within --merge-timeout, and the merged manifests to be reconciled, within --timeout")
+	flags.DurationVar(&p.MergeTimeout, "merge-timeout", time.Hour, "How long to wait for the pull request to be merged with --wait-for-merge")
+}
+
+// Timeout returns the given timeout of the command, extended by the merge timeout if the merge is waited for.
+func (p *PullRequestConfig) Timeout(timeout time.Duration) time.Duration {
+	if p.WaitForMerge {
+		return timeout + p.MergeTimeout
+	}
+
+	return timeout
+}
+
+// Options returns the bootstrap options proposing the manifests with a pull request.
+func (p *PullRequestConfig) Options() []bootstrap.Option {
+	return []bootstrap.Option{
+		bootstrap.WithViaPullRequest(p.ViaPullRequest),
+		bootstrap.WithPullRequestBranch(p.Branch),
+		bootstrap.WithWaitForMerge(p.WaitForMerge),
+		bootstrap.WithMergeTimeout(p.MergeTimeout),
+	}
 }
 
 // GithubConfig is the configuration for the GitHub bootstrap command.
@@ -122,6 +385,335 @@ func (g *GitlabConfig) AddFlags(flags *pflag.FlagSet) {
 	g.BootstrapConfig.AddFlags(flags)
 }
 
+// BitbucketServerConfig is the configuration for the Bitbucket Server bootstrap command.
+type BitbucketServerConfig struct {
+	BootstrapConfig
+	Personal bool
+	Username string
+}
+
+// AddFlags adds the Bitbucket Server bootstrap flags to the given flag set.
+func (b *BitbucketServerConfig) AddFlags(flags *pflag.FlagSet) {
+	flags.BoolVar(&b.Personal, "personal", false, "Whether the management repository is a personal repository of the user")
+	flags.StringVar(&b.Username, "username", "", "The username the token belongs to")
+	b.BootstrapConfig.AddFlags(flags)
+}
+
+// AzureDevOpsConfig is the configuration for the Azure DevOps bootstrap command.
+type AzureDevOpsConfig struct {
+	BootstrapConfig
+	Project string
+}
+
+// AddFlags adds the Azure DevOps bootstrap flags to the given flag set.
+func (a *AzureDevOpsConfig) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&a.Project, "project", "", "The project of the management repository")
+	a.BootstrapConfig.AddFlags(flags)
+
+	// repositories inherit the visibility of their project and deploy keys are not supported
+	for _, name := range []string{"private", "ssh-key-algorithm", "private-key-file"} {
+		_ = flags.MarkHidden(name)
+	}
+}
+
+// GitConfig is the configuration for the plain git bootstrap command.
+type GitConfig struct {
+	BootstrapConfig
+	// URL is the URL of the existing management repository.
+	URL string
+	// Username is the username to authenticate against the git server over HTTP(S).
+	Username string
+	// Branch is the branch of the management repository.
+	Branch string
+}
+
+// AddFlags adds the plain git bootstrap flags to the given flag set.
+func (g *GitConfig) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&g.URL, "url", "", "The HTTP(S) or SSH URL of the existing management repository")
+	flags.StringVar(&g.Username, "username", "git", "The username to authenticate against the git server over HTTP(S), the password is read from GIT_PASSWORD")
+	flags.StringVar(&g.Branch, "branch", "main", "The branch of the management repository")
+	g.BootstrapConfig.AddFlags(flags)
+
+	// the repository is neither created nor registered through a provider API, which opens the pull requests
+	for _, name := range []string{"owner", "repository", "hostname", "private", "ssh-key-algorithm",
+		"via-pull-request", "pull-request-branch", "wait-for-merge", "merge-timeout"} {
+		_ = flags.MarkHidden(name)
+	}
+}
+
+// UninstallConfig is the configuration shared by the uninstall commands.
+type UninstallConfig struct {
+	// Owner is the owner of the management repository.
+	Owner string
+	// Repository is the name of the management repository.
+	Repository string
+	// Hostname is the hostname of the Git provider.
+	Hostname string
+	// Personal indicates whether the management repository is a personal repository.
+	Personal bool
+	// Path is the target path of the bootstrap component in the management repository.
+	Path string
+	// CommitMessageAppendix is the appendix to add to the commit message
+	// for example to skip CI
+	CommitMessageAppendix string
+	// DeleteRepository indicates whether to delete the management repository.
+	DeleteRepository bool
+	// KeepCertManager indicates whether to keep cert-manager installed.
+	KeepCertManager bool
+	// KeepExternalSecrets indicates whether to keep external-secrets installed.
+	KeepExternalSecrets bool
+	// NamespaceMapping maps the default namespaces of the components to the namespaces they were installed to.
+	NamespaceMapping map[string]string
+	// Commit configures the author and the signature of the commit removing the manifests.
+	Commit CommitConfig
+	// Credentials configures the sources of the token.
+	Credentials CredentialsConfig
+}
+
+// AddFlags adds the uninstall flags to the given flag set.
+func (u *UninstallConfig) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&u.Owner, "owner", "", "The owner of the management repository")
+	flags.StringVar(&u.Repository, "repository", "", "The name of the management repository")
+	flags.StringVar(&u.Hostname, "hostname", "", "The hostname of the Git provider")
+	flags.BoolVar(&u.Personal, "personal", false, "Whether the management repository is a personal repository")
+	flags.StringVar(&u.Path, "path", ".", "The target path of the bootstrap component in the management repository")
+	flags.StringVar(&u.CommitMessageAppendix, "commit-message-appendix", "", "The appendix to add to the commit message, e.g. [ci skip]")
+	flags.BoolVar(&u.DeleteRepository, "delete-repository", false, "Delete the management repository after uninstalling")
+	flags.BoolVar(&u.KeepCertManager, "keep-cert-manager", false, "Keep cert-manager installed in the cluster")
+	flags.BoolVar(&u.KeepExternalSecrets, "keep-external-secrets", false, "Keep external-secrets installed in the cluster")
+	flags.StringToStringVar(&u.NamespaceMapping, "namespace-mapping", nil, "Maps the default namespaces of the components to the namespaces they were installed to, at least the flux-system namespace if it was mapped")
+	u.Commit.AddFlags(flags)
+	u.Credentials.AddFlags(flags)
+
+	// the bootstrap component is not fetched when uninstalling
+	_ = flags.MarkHidden("registry-credential-helper")
+}
+
+// GitlabUninstallConfig is the configuration for the Gitlab uninstall command.
+type GitlabUninstallConfig struct {
+	UninstallConfig
+	TokenType string
+}
+
+// AddFlags adds the Gitlab uninstall flags to the given flag set.
+func (g *GitlabUninstallConfig) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&g.TokenType, "token-type", "oauth2", "The token type of the Gitlab token. By default it's set to oauth2.")
+	g.UninstallConfig.AddFlags(flags)
+}
+
+// BitbucketServerUninstallConfig is the configuration for the Bitbucket Server uninstall command.
+type BitbucketServerUninstallConfig struct {
+	UninstallConfig
+	Username string
+}
+
+// AddFlags adds the Bitbucket Server uninstall flags to the given flag set.
+func (b *BitbucketServerUninstallConfig) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&b.Username, "username", "", "The username the token belongs to")
+	b.UninstallConfig.AddFlags(flags)
+}
+
+// AzureDevOpsUninstallConfig is the configuration for the Azure DevOps uninstall command.
+type AzureDevOpsUninstallConfig struct {
+	UninstallConfig
+	Project string
+}
+
+// AddFlags adds the Azure DevOps uninstall flags to the given flag set.
+func (a *AzureDevOpsUninstallConfig) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&a.Project, "project", "", "The project of the management repository")
+	a.UninstallConfig.AddFlags(flags)
+
+	// repositories belong to projects
+	_ = flags.MarkHidden("personal")
+}
+
+// GitUninstallConfig is the configuration for the plain git uninstall command.
+type GitUninstallConfig struct {
+	UninstallConfig
+	// URL is the URL of the management repository.
+	URL string
+	// Username is the username to authenticate against the git server over HTTP(S).
+	Username string
+	// Branch is the branch of the management repository.
+	Branch string
+	// PrivateKeyFile is the private key file used to push over SSH.
+	PrivateKeyFile string
+}
+
+// AddFlags adds the plain git uninstall flags to the given flag set.
+func (g *GitUninstallConfig) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&g.URL, "url", "", "The HTTP(S) or SSH URL of the management repository")
+	flags.StringVar(&g.Username, "username", "git", "The username to authenticate against the git server over HTTP(S), the password is read from GIT_PASSWORD")
+	flags.StringVar(&g.Branch, "branch", "main", "The branch of the management repository")
+	flags.StringVar(&g.PrivateKeyFile, "private-key-file", "", "The private key file used to push over SSH")
+	g.UninstallConfig.AddFlags(flags)
+
+	// the repository is neither looked up nor deleted through a provider API
+	for _, name := range []string{"owner", "repository", "hostname", "personal", "delete-repository"} {
+		_ = flags.MarkHidden(name)
+	}
+}
+
+// CheckConfig is the configuration of the check command.
+type CheckConfig struct {
+	// Pre indicates whether to check the prerequisites of a bootstrap.
+	Pre bool
+	// Registry is the registry to retrieve the bootstrap component from.
+	Registry string
+	// FromFile is the bootstrap bundle to install from.
+	FromFile string
+	// BootstrapVersion is the version, or semver constraint, of the bootstrap component to install.
+	BootstrapVersion string
+	// Components is the list of components to install.
+	Components []string
+	// Provider is the git provider whose token is checked.
+	Provider string
+	// Hostname is the hostname of the Git provider.
+	Hostname string
+	// ExistingFlux indicates whether the flux installed in the cluster is adopted.
+	ExistingFlux bool
+	// ExistingCertManager indicates whether the cert-manager installed in the cluster is adopted.
+	ExistingCertManager bool
+	// Credentials configures the sources of the token and of the credentials of the registry.
+	Credentials CredentialsConfig
+}
+
+// AddFlags adds the check flags to the given flag set.
+func (c *CheckConfig) AddFlags(flags *pflag.FlagSet) {
+	flags.BoolVar(&c.Pre, "pre", false, "Check the prerequisites of bootstrapping MPAS into the cluster")
+	flags.StringVar(&c.Registry, "registry", env.DefaultBootstrapComponentLocation, "The registry to use to retrieve the bootstrap component. Defaults to ghcr.io/open-component-model/mpas-bootstrap-component")
+	flags.StringVar(&c.FromFile, "from-file", "", "The bootstrap bundle to install from, either the tar.gz archive exported with --export, a CTF directory or an OCI image layout holding the archive")
+	flags.StringVar(&c.BootstrapVersion, "bootstrap-version", "", "The version, or semver constraint, of the bootstrap component to install. Defaults to the latest version")
+	flags.StringSliceVar(&c.Components, "components", []string{env.ExternalSecretsName}, "The components to include in the management repository")
+	flags.StringVar(&c.Provider, "provider", "", "The git provider whose token to check (github, gitea or gitlab), read from its environment variable, e.g. GITHUB_TOKEN, unless another source is set")
+	flags.StringVar(&c.Hostname, "hostname", "", "The hostname of the Git provider")
+	flags.BoolVar(&c.ExistingFlux, "existing-flux", false, "Check the flux installed in the cluster to be adopted instead of installed")
+	flags.BoolVar(&c.ExistingCertManager, "existing-cert-manager", false, "Check the cert-manager installed in the cluster to be adopted instead of installed")
+	c.Credentials.AddFlags(flags)
+}
+
+// UpgradeConfig is the configuration shared by the upgrade commands.
+type UpgradeConfig struct {
+	// Owner is the owner of the management repository.
+	Owner string
+	// Repository is the name of the management repository.
+	Repository string
+	// Registry is the registry to retrieve the bootstrap component from.
+	Registry string
+	// Hostname is the hostname of the Git provider.
+	Hostname string
+	// Personal indicates whether the management repository is a personal repository.
+	Personal bool
+	// Path is the target path of the bootstrap component in the management repository.
+	Path string
+	// Interval is the interval to use to sync the bootstrap component.
+	Interval string
+	// CommitMessageAppendix is the appendix to add to the commit message
+	// for example to skip CI
+	CommitMessageAppendix string
+	// CaFile defines and optional root certificate for the git repository used by flux.
+	CaFile string
+	// To is the version, or semver constraint, of the bootstrap component to upgrade to.
+	To string
+	// NamespaceMapping maps the default namespaces of the components to the namespaces they were installed to.
+	NamespaceMapping map[string]string
+	// ImageRegistryMirror is the registry the upgraded components are copied to with their images.
+	ImageRegistryMirror string
+	// Commit configures the author and the signature of the commits.
+	Commit CommitConfig
+	// PullRequest configures the pull request proposing the manifests.
+	PullRequest PullRequestConfig
+	// Credentials configures the sources of the credentials.
+	Credentials CredentialsConfig
+}
+
+// AddFlags adds the upgrade flags to the given flag set.
+func (u *UpgradeConfig) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&u.Owner, "owner", "", "The owner of the management repository")
+	flags.StringVar(&u.Repository, "repository", "", "The name of the management repository")
+	flags.StringVar(&u.Registry, "registry", env.DefaultBootstrapComponentLocation, "The registry to use to retrieve the bootstrap component. Defaults to ghcr.io/open-component-model/mpas-bootstrap-component")
+	flags.StringVar(&u.Hostname, "hostname", "", "The hostname of the Git provider")
+	flags.BoolVar(&u.Personal, "personal", false, "Whether the management repository is a personal repository")
+	flags.StringVar(&u.Path, "path", ".", "The target path of the bootstrap component in the management repository")
+	flags.StringVar(&u.Interval, "interval", "5m", "The interval to use to sync the bootstrap component")
+	flags.StringVar(&u.CommitMessageAppendix, "commit-message-appendix", "", "The appendix to add to the commit message, e.g. [ci skip]")
+	flags.StringVar(&u.CaFile, "ca-file", "", "Root certificate for the remote git server.")
+	flags.StringToStringVar(&u.NamespaceMapping, "namespace-mapping", nil, "Maps the default namespaces of the components to the namespaces they were installed to, at least the flux-system namespace if it was mapped")
+	flags.StringVar(&u.ImageRegistryMirror, "image-registry-mirror", "", "The registry to copy the upgraded components to with their images. Defaults to the mirror used when bootstrapping")
+	flags.StringVar(&u.To, "to", "", "The version, or semver constraint, of the bootstrap component to upgrade to. Defaults to the latest version")
+	u.Commit.AddFlags(flags)
+	u.PullRequest.AddFlags(flags)
+	u.Credentials.AddFlags(flags)
+}
+
+// GitlabUpgradeConfig is the configuration for the Gitlab upgrade command.
+type GitlabUpgradeConfig struct {
+	UpgradeConfig
+	TokenType string
+}
+
+// AddFlags adds the Gitlab upgrade flags to the given flag set.
+func (g *GitlabUpgradeConfig) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&g.TokenType, "token-type", "oauth2", "The token type of the Gitlab token. By default it's set to oauth2.")
+	g.UpgradeConfig.AddFlags(flags)
+}
+
+// BitbucketServerUpgradeConfig is the configuration for the Bitbucket Server upgrade command.
+type BitbucketServerUpgradeConfig struct {
+	UpgradeConfig
+	Username string
+}
+
+// AddFlags adds the Bitbucket Server upgrade flags to the given flag set.
+func (b *BitbucketServerUpgradeConfig) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&b.Username, "username", "", "The username the token belongs to")
+	b.UpgradeConfig.AddFlags(flags)
+}
+
+// AzureDevOpsUpgradeConfig is the configuration for the Azure DevOps upgrade command.
+type AzureDevOpsUpgradeConfig struct {
+	UpgradeConfig
+	Project string
+}
+
+// AddFlags adds the Azure DevOps upgrade flags to the given flag set.
+func (a *AzureDevOpsUpgradeConfig) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&a.Project, "project", "", "The project of the management repository")
+	a.UpgradeConfig.AddFlags(flags)
+
+	// repositories belong to projects
+	_ = flags.MarkHidden("personal")
+}
+
+// GitUpgradeConfig is the configuration for the plain git upgrade command.
+type GitUpgradeConfig struct {
+	UpgradeConfig
+	// URL is the URL of the management repository.
+	URL string
+	// Username is the username to authenticate against the git server over HTTP(S).
+	Username string
+	// Branch is the branch of the management repository.
+	Branch string
+	// PrivateKeyFile is the private key file used to push over SSH.
+	PrivateKeyFile string
+}
+
+// AddFlags adds the plain git upgrade flags to the given flag set.
+func (g *GitUpgradeConfig) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&g.URL, "url", "", "The HTTP(S) or SSH URL of the management repository")
+	flags.StringVar(&g.Username, "username", "git", "The username to authenticate against the git server over HTTP(S), the password is read from GIT_PASSWORD")
+	flags.StringVar(&g.Branch, "branch", "main", "The branch of the management repository")
+	flags.StringVar(&g.PrivateKeyFile, "private-key-file", "", "The private key file used to push over SSH")
+	g.UpgradeConfig.AddFlags(flags)
+
+	// the repository is not looked up through a provider API, which opens the pull requests
+	for _, name := range []string{"owner", "repository", "hostname", "personal",
+		"via-pull-request", "pull-request-branch", "wait-for-merge", "merge-timeout"} {
+		_ = flags.MarkHidden(name)
+	}
+}
+
 // CreateConfig is the configuration shared by the create commands.
 type CreateConfig struct {
 	Prune    bool
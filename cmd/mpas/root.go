@@ -56,7 +56,10 @@ func New(ctx context.Context, args []string) (*cobra.Command, error) {
 	cfg.PollInterval = 2 * time.Second
 
 	cmd.AddCommand(NewBootstrap(cfg))
+	cmd.AddCommand(NewCheck(cfg))
 	cmd.AddCommand(NewCreate(cfg))
+	cmd.AddCommand(NewUninstall(cfg))
+	cmd.AddCommand(NewUpgrade(cfg))
 	cmd.AddCommand(NewVersion(cfg))
 
 	cmd.InitDefaultHelpCmd()
@@ -0,0 +1,243 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/open-component-model/mpas/cmd/mpas/config"
+	"github.com/open-component-model/mpas/cmd/mpas/uninstall"
+	"github.com/open-component-model/mpas/internal/env"
+	"github.com/spf13/cobra"
+)
+
+// NewUninstall returns a new cobra.Command for uninstall
+func NewUninstall(cfg *config.MpasConfig) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "uninstall [provider] [flags]",
+		Short: "Uninstall the MPAS system from a Kubernetes cluster.",
+		Long: `Uninstall the MPAS system from a Kubernetes cluster.
+The projects and product deployments are deleted first, then the controllers, cert-manager and external-secrets
+are removed from the management repository and pruned, before flux is uninstalled.
+The management repository is only deleted when --delete-repository is set.`,
+	}
+
+	cmd.AddCommand(NewUninstallGithub(cfg))
+	cmd.AddCommand(NewUninstallGitea(cfg))
+	cmd.AddCommand(NewUninstallGitlab(cfg))
+	cmd.AddCommand(NewUninstallBitbucketServer(cfg))
+	cmd.AddCommand(NewUninstallAzureDevOps(cfg))
+	cmd.AddCommand(NewUninstallGit(cfg))
+
+	return cmd
+}
+
+// NewUninstallGithub returns a new cobra.Command for github uninstall
+func NewUninstallGithub(cfg *config.MpasConfig) *cobra.Command {
+	c := &config.UninstallConfig{}
+	cmd := &cobra.Command{
+		Use:   "github [flags]",
+		Short: "Uninstall mpas bootstrapped from a management repository on Github",
+		Example: `  - Uninstall mpas bootstrapped from an organization repository
+    mpas uninstall github --owner ocmOrg --repository mpas --path clusters/my-cluster
+
+    - Uninstall mpas and delete the management repository
+    mpas uninstall github --owner myUser --repository mpas --personal --path clusters/my-cluster --delete-repository
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			u := newUninstallCmd(env.ProviderGithub, c)
+			return runUninstall(cmd, cfg, c, u, env.GithubTokenVar, "Github token: ")
+		},
+	}
+
+	c.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// NewUninstallGitea returns a new cobra.Command for gitea uninstall
+func NewUninstallGitea(cfg *config.MpasConfig) *cobra.Command {
+	c := &config.UninstallConfig{}
+	cmd := &cobra.Command{
+		Use:   "gitea [flags]",
+		Short: "Uninstall mpas bootstrapped from a management repository on Gitea",
+		Example: `  - Uninstall mpas bootstrapped from an organization repository
+    mpas uninstall gitea --owner ocmOrg --repository mpas --path clusters/my-cluster --hostname gitea.example.com
+
+    - Uninstall mpas but keep cert-manager installed
+    mpas uninstall gitea --owner ocmOrg --repository mpas --path clusters/my-cluster --hostname gitea.example.com --keep-cert-manager
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			u := newUninstallCmd(env.ProviderGitea, c)
+			if u.Hostname == "" {
+				return fmt.Errorf("hostname must be set")
+			}
+			return runUninstall(cmd, cfg, c, u, env.GiteaTokenVar, "Gitea token: ")
+		},
+	}
+
+	c.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// NewUninstallGitlab returns a new cobra.Command for gitlab uninstall
+func NewUninstallGitlab(cfg *config.MpasConfig) *cobra.Command {
+	c := &config.GitlabUninstallConfig{}
+	cmd := &cobra.Command{
+		Use:   "gitlab [flags]",
+		Short: "Uninstall mpas bootstrapped from a management repository on Gitlab",
+		Example: `  - Uninstall mpas bootstrapped from an organization repository
+    mpas uninstall gitlab --owner ocmOrg --repository mpas --path clusters/my-cluster --hostname gitlab.example.com
+
+    - Uninstall mpas and delete the management repository
+    mpas uninstall gitlab --owner myUser --repository mpas --personal --path clusters/my-cluster --hostname gitlab.example.com --delete-repository
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			u := newUninstallCmd(env.ProviderGitlab, &c.UninstallConfig)
+			u.TokenType = c.TokenType
+			return runUninstall(cmd, cfg, &c.UninstallConfig, u, env.GitlabTokenVar, "Gitlab token: ")
+		},
+	}
+
+	c.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// NewUninstallBitbucketServer returns a new cobra.Command for bitbucket server uninstall
+func NewUninstallBitbucketServer(cfg *config.MpasConfig) *cobra.Command {
+	c := &config.BitbucketServerUninstallConfig{}
+	cmd := &cobra.Command{
+		Use:   "bitbucket-server [flags]",
+		Short: "Uninstall mpas bootstrapped from a management repository on Bitbucket Server or Data Center",
+		Example: `  - Uninstall mpas bootstrapped from a project repository
+    mpas uninstall bitbucket-server --owner PROJ --username myUser --repository mpas --path clusters/my-cluster --hostname bitbucket.example.com
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			u := newUninstallCmd(env.ProviderBitbucketServer, &c.UninstallConfig)
+			u.Username = c.Username
+			// the credentials are requested for the hostname
+			if u.Hostname == "" {
+				return fmt.Errorf("hostname must be set")
+			}
+			if u.Username == "" {
+				return fmt.Errorf("username must be set")
+			}
+			return runUninstall(cmd, cfg, &c.UninstallConfig, u, env.BitbucketServerTokenVar, "Bitbucket Server token: ")
+		},
+	}
+
+	c.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// NewUninstallAzureDevOps returns a new cobra.Command for azure devops uninstall
+func NewUninstallAzureDevOps(cfg *config.MpasConfig) *cobra.Command {
+	c := &config.AzureDevOpsUninstallConfig{}
+	cmd := &cobra.Command{
+		Use:   "azure-devops [flags]",
+		Short: "Uninstall mpas bootstrapped from a management repository on Azure DevOps",
+		Example: `  - Uninstall mpas bootstrapped from a repository of an Azure DevOps Services project
+    mpas uninstall azure-devops --owner myOrg --project myProject --repository mpas --path clusters/my-cluster
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if c.Project == "" {
+				return fmt.Errorf("project must be set")
+			}
+
+			u := newUninstallCmd(env.ProviderAzureDevOps, &c.UninstallConfig)
+			// the project is the sub-organization of the repository
+			if u.Repository != "" {
+				u.Repository = c.Project + "/" + u.Repository
+			}
+			return runUninstall(cmd, cfg, &c.UninstallConfig, u, env.AzureDevOpsTokenVar, "Azure DevOps token: ")
+		},
+	}
+
+	c.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// NewUninstallGit returns a new cobra.Command for plain git uninstall
+func NewUninstallGit(cfg *config.MpasConfig) *cobra.Command {
+	c := &config.GitUninstallConfig{}
+	cmd := &cobra.Command{
+		Use:   "git [flags]",
+		Short: "Uninstall mpas bootstrapped from a management repository on a plain git server",
+		Example: `  - Uninstall over HTTPS, the password is read from GIT_PASSWORD
+    mpas uninstall git --url https://git.example.com/ocm/mpas.git --username git --path clusters/my-cluster
+
+    - Uninstall over SSH with a private key authorized to push to the repository
+    mpas uninstall git --url ssh://git@git.example.com/ocm/mpas.git --private-key-file ~/.ssh/id_ed25519 --path clusters/my-cluster
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if c.URL == "" {
+				return fmt.Errorf("url must be set")
+			}
+
+			u := newUninstallCmd("", &c.UninstallConfig)
+			u.URL = c.URL
+			u.Username = c.Username
+			u.Branch = c.Branch
+			u.PrivateKeyFile = c.PrivateKeyFile
+			return runUninstall(cmd, cfg, &c.UninstallConfig, u, env.GitPasswordVar, "Git password: ")
+		},
+	}
+
+	c.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func newUninstallCmd(provider string, c *config.UninstallConfig) *uninstall.UninstallCmd {
+	return &uninstall.UninstallCmd{
+		Provider:              provider,
+		Owner:                 c.Owner,
+		Personal:              c.Personal,
+		Hostname:              c.Hostname,
+		Repository:            c.Repository,
+		Path:                  c.Path,
+		CommitMessageAppendix: c.CommitMessageAppendix,
+		DeleteRepository:      c.DeleteRepository,
+		KeepCertManager:       c.KeepCertManager,
+		KeepExternalSecrets:   c.KeepExternalSecrets,
+		NamespaceMapping:      c.NamespaceMapping,
+		Commit:                c.Commit,
+	}
+}
+
+func runUninstall(cmd *cobra.Command, cfg *config.MpasConfig, c *config.UninstallConfig, u *uninstall.UninstallCmd, tokenVar, prompt string) (err error) {
+	// a repository on a plain git server is identified by its URL
+	if u.URL == "" {
+		if u.Owner == "" {
+			return fmt.Errorf("owner must be set")
+		}
+
+		if u.Repository == "" {
+			return fmt.Errorf("repository must be set")
+		}
+	}
+
+	// the private key authenticates over SSH, a password is only needed over HTTP(S)
+	if u.PrivateKeyFile == "" {
+		u.Token, err = readToken(cmd.Context(), c.Credentials.TokenSource(tokenVar, tokenURL(cfg, u.Provider, u.Hostname, u.URL)), prompt)
+	} else {
+		u.PrivateKeyPassphrase, err = c.Credentials.PrivateKeyPassphrase(cmd.Context())
+	}
+	if err != nil {
+		return err
+	}
+
+	u.Timeout, err = time.ParseDuration(cfg.Timeout)
+	if err != nil {
+		return err
+	}
+
+	return u.Execute(cmd.Context(), cfg)
+}
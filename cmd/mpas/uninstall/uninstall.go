@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package uninstall
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/open-component-model/mpas/cmd/mpas/config"
+	"github.com/open-component-model/mpas/internal/bootstrap"
+	"github.com/open-component-model/mpas/internal/bootstrap/provider"
+	"github.com/open-component-model/mpas/internal/env"
+	"github.com/open-component-model/mpas/internal/kubeutils"
+)
+
+const (
+	githubDefaultHostname = "github.com"
+)
+
+// UninstallCmd is a command for uninstalling MPAS from a cluster bootstrapped from a management repository
+type UninstallCmd struct {
+	// Provider is the git provider hosting the management repository
+	Provider string
+	// Owner is the owner of the repository
+	Owner string
+	// Token is the token to use for authentication
+	Token string
+	// TokenType is the type of the token, only used by gitlab
+	TokenType string
+	// Personal indicates whether the repository is a personal repository
+	Personal bool
+	// Hostname is the hostname of the git provider
+	Hostname string
+	// Repository is the name of the repository
+	Repository string
+	// URL is the HTTP(S) or SSH URL of a repository on a plain git server
+	URL string
+	// Username is the username the token of Bitbucket Server belongs to, or the username to use for
+	// authentication over HTTP(S) against a plain git server
+	Username string
+	// Branch is the branch of a repository on a plain git server
+	Branch string
+	// PrivateKeyFile is the private key file used to push over SSH to a plain git server
+	PrivateKeyFile string
+	// PrivateKeyPassphrase is the passphrase of the private key file, if it is encrypted
+	PrivateKeyPassphrase string
+	// Path is the path in the repository hosting the bootstrapped components yamls
+	Path string
+	// CommitMessageAppendix is the appendix to add to the commit message
+	// for example to skip CI
+	CommitMessageAppendix string
+	// Timeout is the timeout to use for operations
+	Timeout time.Duration
+	// DeleteRepository indicates whether to delete the management repository
+	DeleteRepository bool
+	// KeepCertManager indicates whether to keep cert-manager installed
+	KeepCertManager bool
+	// KeepExternalSecrets indicates whether to keep external-secrets installed
+	KeepExternalSecrets bool
+	// NamespaceMapping maps the default namespaces of the components to the namespaces they were installed to
+	NamespaceMapping map[string]string
+	// Commit configures the author and the signature of the commits
+	Commit config.CommitConfig
+}
+
+// Execute executes the command and returns an error if one occurred.
+func (u *UninstallCmd) Execute(ctx context.Context, cfg *config.MpasConfig) error {
+	ctx, cancel := context.WithTimeout(ctx, u.Timeout)
+	defer cancel()
+
+	// a repository on a plain git server is committed to through local clones, without a git provider
+	var providerClient gitprovider.Client
+	if u.URL == "" {
+		hostname := u.Hostname
+		if hostname == "" {
+			switch u.Provider {
+			case env.ProviderGithub:
+				hostname = githubDefaultHostname
+			case env.ProviderGitea:
+				return fmt.Errorf("hostname must be specified")
+			}
+		}
+
+		providerOpts := provider.ProviderOptions{
+			Provider:           u.Provider,
+			Hostname:           hostname,
+			Token:              u.Token,
+			Username:           u.Username,
+			TokenType:          u.TokenType,
+			DestructiveActions: u.DeleteRepository,
+		}
+
+		var err error
+		providerClient, err = provider.New().Build(providerOpts)
+		if err != nil {
+			return err
+		}
+	}
+
+	kubeClient, err := kubeutils.KubeClient(cfg.KubeConfigArgs)
+	if err != nil {
+		return err
+	}
+
+	transport := "https"
+	if cfg.PlainHTTP {
+		transport = "http"
+	}
+
+	opts := []bootstrap.Option{
+		bootstrap.WithOwner(u.Owner),
+		bootstrap.WithRepositoryName(u.Repository),
+		bootstrap.WithRepositoryURL(u.URL),
+		bootstrap.WithUsername(u.Username),
+		bootstrap.WithDefaultBranch(u.Branch),
+		bootstrap.WithPrivateKeyFile(u.PrivateKeyFile),
+		bootstrap.WithPrivateKeyPassphrase(u.PrivateKeyPassphrase),
+		bootstrap.WithPersonal(u.Personal),
+		bootstrap.WithPrinter(cfg.Printer),
+		bootstrap.WithToken(u.Token),
+		bootstrap.WithTransportType(transport),
+		bootstrap.WithTarget(u.Path),
+		bootstrap.WithKubeClient(kubeClient),
+		bootstrap.WithRESTClientGetter(cfg.KubeConfigArgs),
+		bootstrap.WithTimeout(u.Timeout),
+		bootstrap.WithCommitMessageAppendix(u.CommitMessageAppendix),
+		bootstrap.WithDeleteRepository(u.DeleteRepository),
+		bootstrap.WithKeepCertManager(u.KeepCertManager),
+		bootstrap.WithKeepExternalSecrets(u.KeepExternalSecrets),
+		bootstrap.WithNamespaces(u.NamespaceMapping),
+	}
+	commitOpts, err := u.Commit.Options(ctx)
+	if err != nil {
+		return err
+	}
+	opts = append(opts, commitOpts...)
+
+	b, err := bootstrap.New(providerClient, opts...)
+	if err != nil {
+		return err
+	}
+
+	return b.Uninstall(ctx)
+}
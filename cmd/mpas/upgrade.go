@@ -0,0 +1,253 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/open-component-model/mpas/cmd/mpas/config"
+	"github.com/open-component-model/mpas/cmd/mpas/upgrade"
+	"github.com/open-component-model/mpas/internal/env"
+	"github.com/spf13/cobra"
+)
+
+// NewUpgrade returns a new cobra.Command for upgrade
+func NewUpgrade(cfg *config.MpasConfig) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "upgrade [provider] [flags]",
+		Short: "Upgrade the MPAS system of a Kubernetes cluster to a newer bootstrap component.",
+		Long: `Upgrade the MPAS system of a Kubernetes cluster to a newer bootstrap component.
+The installed component versions are compared with the ones of the target bootstrap component,
+only the manifests of the components whose version changed are regenerated and committed.
+Downgrades and upgrades skipping incompatible versions are refused.`,
+	}
+
+	cmd.AddCommand(NewUpgradeGithub(cfg))
+	cmd.AddCommand(NewUpgradeGitea(cfg))
+	cmd.AddCommand(NewUpgradeGitlab(cfg))
+	cmd.AddCommand(NewUpgradeBitbucketServer(cfg))
+	cmd.AddCommand(NewUpgradeAzureDevOps(cfg))
+	cmd.AddCommand(NewUpgradeGit(cfg))
+
+	return cmd
+}
+
+// NewUpgradeGithub returns a new cobra.Command for github upgrade
+func NewUpgradeGithub(cfg *config.MpasConfig) *cobra.Command {
+	c := &config.UpgradeConfig{}
+	cmd := &cobra.Command{
+		Use:   "github [flags]",
+		Short: "Upgrade mpas bootstrapped from a management repository on Github",
+		Example: `  - Upgrade to the latest bootstrap component
+    mpas upgrade github --owner ocmOrg --repository mpas --path clusters/my-cluster
+
+    - Upgrade to a given bootstrap component version
+    mpas upgrade github --owner ocmOrg --repository mpas --path clusters/my-cluster --to v0.5.0
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			u := newUpgradeCmd(env.ProviderGithub, cfg, c)
+			return runUpgrade(cmd, cfg, c, u, env.GithubTokenVar, "Github token: ")
+		},
+	}
+
+	c.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// NewUpgradeGitea returns a new cobra.Command for gitea upgrade
+func NewUpgradeGitea(cfg *config.MpasConfig) *cobra.Command {
+	c := &config.UpgradeConfig{}
+	cmd := &cobra.Command{
+		Use:   "gitea [flags]",
+		Short: "Upgrade mpas bootstrapped from a management repository on Gitea",
+		Example: `  - Upgrade to the latest bootstrap component
+    mpas upgrade gitea --owner ocmOrg --repository mpas --path clusters/my-cluster --hostname gitea.example.com
+
+    - Upgrade to the latest patch of a bootstrap component minor version
+    mpas upgrade gitea --owner ocmOrg --repository mpas --path clusters/my-cluster --hostname gitea.example.com --to "~0.5.0"
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			u := newUpgradeCmd(env.ProviderGitea, cfg, c)
+			if u.Hostname == "" {
+				return fmt.Errorf("hostname must be set")
+			}
+			return runUpgrade(cmd, cfg, c, u, env.GiteaTokenVar, "Gitea token: ")
+		},
+	}
+
+	c.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// NewUpgradeGitlab returns a new cobra.Command for gitlab upgrade
+func NewUpgradeGitlab(cfg *config.MpasConfig) *cobra.Command {
+	c := &config.GitlabUpgradeConfig{}
+	cmd := &cobra.Command{
+		Use:   "gitlab [flags]",
+		Short: "Upgrade mpas bootstrapped from a management repository on Gitlab",
+		Example: `  - Upgrade to the latest bootstrap component
+    mpas upgrade gitlab --owner ocmOrg --repository mpas --path clusters/my-cluster --hostname gitlab.example.com
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			u := newUpgradeCmd(env.ProviderGitlab, cfg, &c.UpgradeConfig)
+			u.TokenType = c.TokenType
+			return runUpgrade(cmd, cfg, &c.UpgradeConfig, u, env.GitlabTokenVar, "Gitlab token: ")
+		},
+	}
+
+	c.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// NewUpgradeBitbucketServer returns a new cobra.Command for bitbucket server upgrade
+func NewUpgradeBitbucketServer(cfg *config.MpasConfig) *cobra.Command {
+	c := &config.BitbucketServerUpgradeConfig{}
+	cmd := &cobra.Command{
+		Use:   "bitbucket-server [flags]",
+		Short: "Upgrade mpas bootstrapped from a management repository on Bitbucket Server or Data Center",
+		Example: `  - Upgrade mpas bootstrapped from a project repository
+    mpas upgrade bitbucket-server --owner PROJ --username myUser --repository mpas --path clusters/my-cluster --hostname bitbucket.example.com --to v0.5.0
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			u := newUpgradeCmd(env.ProviderBitbucketServer, cfg, &c.UpgradeConfig)
+			u.Username = c.Username
+			// the credentials are requested for the hostname
+			if u.Hostname == "" {
+				return fmt.Errorf("hostname must be set")
+			}
+			if u.Username == "" {
+				return fmt.Errorf("username must be set")
+			}
+			return runUpgrade(cmd, cfg, &c.UpgradeConfig, u, env.BitbucketServerTokenVar, "Bitbucket Server token: ")
+		},
+	}
+
+	c.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// NewUpgradeAzureDevOps returns a new cobra.Command for azure devops upgrade
+func NewUpgradeAzureDevOps(cfg *config.MpasConfig) *cobra.Command {
+	c := &config.AzureDevOpsUpgradeConfig{}
+	cmd := &cobra.Command{
+		Use:   "azure-devops [flags]",
+		Short: "Upgrade mpas bootstrapped from a management repository on Azure DevOps",
+		Example: `  - Upgrade mpas bootstrapped from a repository of an Azure DevOps Services project
+    mpas upgrade azure-devops --owner myOrg --project myProject --repository mpas --path clusters/my-cluster --to v0.5.0
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if c.Project == "" {
+				return fmt.Errorf("project must be set")
+			}
+
+			u := newUpgradeCmd(env.ProviderAzureDevOps, cfg, &c.UpgradeConfig)
+			// the project is the sub-organization of the repository
+			if u.Repository != "" {
+				u.Repository = c.Project + "/" + u.Repository
+			}
+			return runUpgrade(cmd, cfg, &c.UpgradeConfig, u, env.AzureDevOpsTokenVar, "Azure DevOps token: ")
+		},
+	}
+
+	c.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// NewUpgradeGit returns a new cobra.Command for plain git upgrade
+func NewUpgradeGit(cfg *config.MpasConfig) *cobra.Command {
+	c := &config.GitUpgradeConfig{}
+	cmd := &cobra.Command{
+		Use:   "git [flags]",
+		Short: "Upgrade mpas bootstrapped from a management repository on a plain git server",
+		Example: `  - Upgrade over HTTPS, the password is read from GIT_PASSWORD
+    mpas upgrade git --url https://git.example.com/ocm/mpas.git --username git --path clusters/my-cluster
+
+    - Upgrade over SSH with a private key authorized to push to the repository
+    mpas upgrade git --url ssh://git@git.example.com/ocm/mpas.git --private-key-file ~/.ssh/id_ed25519 --path clusters/my-cluster
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if c.URL == "" {
+				return fmt.Errorf("url must be set")
+			}
+
+			u := newUpgradeCmd("", cfg, &c.UpgradeConfig)
+			u.URL = c.URL
+			u.Username = c.Username
+			u.Branch = c.Branch
+			u.PrivateKeyFile = c.PrivateKeyFile
+			return runUpgrade(cmd, cfg, &c.UpgradeConfig, u, env.GitPasswordVar, "Git password: ")
+		},
+	}
+
+	c.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func newUpgradeCmd(provider string, cfg *config.MpasConfig, c *config.UpgradeConfig) *upgrade.UpgradeCmd {
+	return &upgrade.UpgradeCmd{
+		Provider:              provider,
+		Owner:                 c.Owner,
+		Personal:              c.Personal,
+		Hostname:              c.Hostname,
+		Repository:            c.Repository,
+		Registry:              c.Registry,
+		DockerconfigPath:      cfg.DockerconfigPath,
+		Path:                  c.Path,
+		CommitMessageAppendix: c.CommitMessageAppendix,
+		CaFile:                c.CaFile,
+		To:                    c.To,
+		NamespaceMapping:      c.NamespaceMapping,
+		ImageRegistryMirror:   c.ImageRegistryMirror,
+		Commit:                c.Commit,
+		PullRequest:           c.PullRequest,
+		Credentials:           c.Credentials,
+	}
+}
+
+func runUpgrade(cmd *cobra.Command, cfg *config.MpasConfig, c *config.UpgradeConfig, u *upgrade.UpgradeCmd, tokenVar, prompt string) (err error) {
+	// a repository on a plain git server is identified by its URL
+	if u.URL == "" {
+		if u.Owner == "" {
+			return fmt.Errorf("owner must be set")
+		}
+
+		if u.Repository == "" {
+			return fmt.Errorf("repository must be set")
+		}
+	}
+
+	if u.Registry == "" {
+		return fmt.Errorf("registry must be set")
+	}
+
+	// the private key authenticates over SSH, a password is only needed over HTTP(S)
+	if u.PrivateKeyFile == "" {
+		u.Token, err = readToken(cmd.Context(), c.Credentials.TokenSource(tokenVar, tokenURL(cfg, u.Provider, u.Hostname, u.URL)), prompt)
+	} else {
+		u.PrivateKeyPassphrase, err = c.Credentials.PrivateKeyPassphrase(cmd.Context())
+	}
+	if err != nil {
+		return err
+	}
+
+	u.Timeout, err = time.ParseDuration(cfg.Timeout)
+	if err != nil {
+		return err
+	}
+
+	u.Interval, err = time.ParseDuration(c.Interval)
+	if err != nil {
+		return err
+	}
+
+	return u.Execute(cmd.Context(), cfg)
+}
@@ -0,0 +1,162 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/open-component-model/mpas/cmd/mpas/config"
+	"github.com/open-component-model/mpas/internal/bootstrap"
+	"github.com/open-component-model/mpas/internal/bootstrap/provider"
+	"github.com/open-component-model/mpas/internal/env"
+	"github.com/open-component-model/mpas/internal/kubeutils"
+)
+
+const (
+	githubDefaultHostname = "github.com"
+)
+
+// UpgradeCmd is a command for upgrading an MPAS installation to a newer bootstrap component
+type UpgradeCmd struct {
+	// Provider is the git provider hosting the management repository
+	Provider string
+	// Owner is the owner of the repository
+	Owner string
+	// Token is the token to use for authentication
+	Token string
+	// TokenType is the type of the token, only used by gitlab
+	TokenType string
+	// Personal indicates whether the repository is a personal repository
+	Personal bool
+	// Hostname is the hostname of the git provider
+	Hostname string
+	// Repository is the name of the repository
+	Repository string
+	// URL is the HTTP(S) or SSH URL of a repository on a plain git server
+	URL string
+	// Username is the username the token of Bitbucket Server belongs to, or the username to use for
+	// authentication over HTTP(S) against a plain git server
+	Username string
+	// Branch is the branch of a repository on a plain git server
+	Branch string
+	// PrivateKeyFile is the private key file used to push over SSH to a plain git server
+	PrivateKeyFile string
+	// PrivateKeyPassphrase is the passphrase of the private key file, if it is encrypted
+	PrivateKeyPassphrase string
+	// Registry is the registry to retrieve the bootstrap component from
+	Registry string
+	// DockerconfigPath is the path to the docker config file
+	DockerconfigPath string
+	// Path is the path in the repository hosting the bootstrapped components yamls
+	Path string
+	// CommitMessageAppendix is the appendix to add to the commit message
+	// for example to skip CI
+	CommitMessageAppendix string
+	// CaFile defines and optional root certificate for the git repository used by flux.
+	CaFile string
+	// Interval is the interval to use for reconciling
+	Interval time.Duration
+	// Timeout is the timeout to use for operations
+	Timeout time.Duration
+	// To is the version, or semver constraint, of the bootstrap component to upgrade to
+	To string
+	// NamespaceMapping maps the default namespaces of the components to the namespaces they were installed to
+	NamespaceMapping map[string]string
+	// ImageRegistryMirror is the registry the upgraded components are copied to with their images
+	ImageRegistryMirror string
+	// Commit configures the author and the signature of the commits
+	Commit config.CommitConfig
+	// PullRequest configures the pull request proposing the upgraded manifests
+	PullRequest config.PullRequestConfig
+	// Credentials configures the sources of the credentials of the registries
+	Credentials config.CredentialsConfig
+}
+
+// Execute executes the command and returns an error if one occurred.
+func (u *UpgradeCmd) Execute(ctx context.Context, cfg *config.MpasConfig) error {
+	ctx, cancel := context.WithTimeout(ctx, u.PullRequest.Timeout(u.Timeout))
+	defer cancel()
+
+	// a repository on a plain git server is committed to through local clones, without a git provider
+	var providerClient gitprovider.Client
+	if u.URL == "" {
+		hostname := u.Hostname
+		if hostname == "" {
+			switch u.Provider {
+			case env.ProviderGithub:
+				hostname = githubDefaultHostname
+			case env.ProviderGitea:
+				return fmt.Errorf("hostname must be specified")
+			}
+		}
+
+		providerOpts := provider.ProviderOptions{
+			Provider:  u.Provider,
+			Hostname:  hostname,
+			Token:     u.Token,
+			Username:  u.Username,
+			TokenType: u.TokenType,
+		}
+
+		var err error
+		providerClient, err = provider.New().Build(providerOpts)
+		if err != nil {
+			return err
+		}
+	}
+
+	kubeClient, err := kubeutils.KubeClient(cfg.KubeConfigArgs)
+	if err != nil {
+		return err
+	}
+
+	transport := "https"
+	if cfg.PlainHTTP {
+		transport = "http"
+	}
+
+	opts := []bootstrap.Option{
+		bootstrap.WithOwner(u.Owner),
+		bootstrap.WithRepositoryName(u.Repository),
+		bootstrap.WithRepositoryURL(u.URL),
+		bootstrap.WithUsername(u.Username),
+		bootstrap.WithDefaultBranch(u.Branch),
+		bootstrap.WithPrivateKeyFile(u.PrivateKeyFile),
+		bootstrap.WithPrivateKeyPassphrase(u.PrivateKeyPassphrase),
+		bootstrap.WithPersonal(u.Personal),
+		bootstrap.WithRegistry(u.Registry),
+		bootstrap.WithPrinter(cfg.Printer),
+		bootstrap.WithToken(u.Token),
+		bootstrap.WithTransportType(transport),
+		bootstrap.WithDockerConfigPath(u.DockerconfigPath),
+		bootstrap.WithTarget(u.Path),
+		bootstrap.WithKubeClient(kubeClient),
+		bootstrap.WithRESTClientGetter(cfg.KubeConfigArgs),
+		bootstrap.WithInterval(u.Interval),
+		bootstrap.WithTimeout(u.Timeout),
+		bootstrap.WithCommitMessageAppendix(u.CommitMessageAppendix),
+		bootstrap.WithRootFile(u.CaFile),
+		bootstrap.WithUpgradeVersion(u.To),
+		bootstrap.WithNamespaces(u.NamespaceMapping),
+		bootstrap.WithImageRegistryMirror(u.ImageRegistryMirror),
+		bootstrap.WithRegistryCredentialHelper(u.Credentials.RegistryCredentialHelper),
+	}
+	commitOpts, err := u.Commit.Options(ctx)
+	if err != nil {
+		return err
+	}
+	opts = append(opts, commitOpts...)
+	opts = append(opts, u.PullRequest.Options()...)
+
+	b, err := bootstrap.New(providerClient, opts...)
+	if err != nil {
+		return err
+	}
+
+	return b.Upgrade(ctx)
+}
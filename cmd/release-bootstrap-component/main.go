@@ -16,6 +16,7 @@ import (
 
 	"github.com/open-component-model/mpas/cmd/release-bootstrap-component/release"
 	"github.com/open-component-model/mpas/cmd/release-bootstrap-component/version"
+	"github.com/open-component-model/mpas/internal/credentials"
 	"github.com/open-component-model/mpas/internal/env"
 	"github.com/open-component-model/mpas/internal/fs"
 	"github.com/open-component-model/mpas/internal/oci"
@@ -49,6 +50,16 @@ var (
 	targetOS string
 	// The target arch.
 	targetArch string
+	// The file holding the Github token.
+	tokenFile string
+	// The exec plugin returning the Github token.
+	tokenExecCommand string
+	// The arguments of the exec plugin.
+	tokenExecArgs []string
+	// Whether the Github token is read from the git credential helpers.
+	gitCredentialHelper bool
+	// The docker credential helper holding the credentials of the registry.
+	registryCredentialHelper string
 )
 
 func main() {
@@ -65,12 +76,30 @@ func main() {
 	flag.StringVar(&username, "username", "", "The username to use.")
 	flag.StringVar(&targetOS, "target-os", "linux", "The target OS to use.")
 	flag.StringVar(&targetArch, "target-arch", "amd64", "The target arch to use.")
+	flag.StringVar(&tokenFile, "token-file", "", "The file holding the Github token.")
+	flag.StringVar(&tokenExecCommand, "token-exec-command", "", "The exec plugin returning the Github token.")
+	flag.StringSliceVar(&tokenExecArgs, "token-exec-arg", nil, "The arguments of the exec plugin.")
+	flag.BoolVar(&gitCredentialHelper, "git-credential-helper", false, "Read the Github token from the configured git credential helpers.")
+	flag.StringVar(&registryCredentialHelper, "registry-credential-helper", "", "The docker credential helper holding the credentials of the registry, instead of the Github token.")
 
 	flag.Parse()
 
-	token := os.Getenv(env.GithubTokenVar)
+	ctx := context.Background()
+
+	sources := []credentials.Source{
+		credentials.File(tokenFile),
+		credentials.Exec(tokenExecCommand, tokenExecArgs, "https://github.com"),
+	}
+	if gitCredentialHelper {
+		sources = append(sources, credentials.GitCredentialHelper("https://github.com"))
+	}
+	token, err := credentials.Chain(append(sources, credentials.Env(env.GithubTokenVar))...).Token(ctx)
+	if err != nil {
+		fmt.Println("Failed to read token: ", err)
+		os.Exit(1)
+	}
 	if token == "" {
-		fmt.Println("token must be provided via environment variable")
+		fmt.Println("token must be provided via --token-file, --token-exec-command, --git-credential-helper or environment variable")
 		os.Exit(1)
 	}
 
@@ -84,9 +113,27 @@ func main() {
 		os.Exit(1)
 	}
 
-	ctx := context.Background()
 	octx := om.New(datacontext.MODE_SHARED)
 
+	// the registry is pushed to with the Github token, unless the credential helper holds its credentials
+	registryUsername, registryPassword := username, token
+	if registryCredentialHelper != "" {
+		creds, err := credentials.DockerCredentialHelper(ctx, registryCredentialHelper, repositoryURL)
+		if err != nil {
+			fmt.Println("Failed to read registry credentials: ", err)
+			os.Exit(1)
+		}
+		if creds.IdentityToken != "" {
+			fmt.Println("identity tokens of the registry are not supported")
+			os.Exit(1)
+		}
+		if err := ocm.SetRegistryCredentials(octx, repositoryURL, creds.Username, creds.Password, ""); err != nil {
+			fmt.Println("Failed to set registry credentials: ", err)
+			os.Exit(1)
+		}
+		registryUsername, registryPassword = creds.Username, creds.Password
+	}
+
 	fmt.Println("Releasing bootstrap component...")
 	tmpDir, err := os.MkdirTemp("", "mpas-bootstrap")
 	if err != nil {
@@ -116,8 +163,8 @@ func main() {
 
 	ociRepo := oci.Repository{
 		RepositoryURL: repositoryURL + "-bundle",
-		Username:      username,
-		Password:      token,
+		Username:      registryUsername,
+		Password:      registryPassword,
 	}
 	if err := ociRepo.PushArtifact(ctx, src, version.Tag); err != nil {
 		fmt.Println("Failed to push bundle: ", err)
@@ -11,10 +11,13 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"time"
 
+	"github.com/Masterminds/semver/v3"
+	"github.com/fluxcd/flux2/v2/pkg/manifestgen/sourcesecret"
 	"github.com/fluxcd/go-git-providers/gitprovider"
 	"github.com/open-component-model/mpas/internal/env"
 	"github.com/open-component-model/mpas/internal/kubeutils"
@@ -54,6 +57,49 @@ type options struct {
 	printer               *printer.Printer
 	testURL               string
 	caFile                string
+	dryRun                bool
+	outputDir             string
+	resume                bool
+	deleteRepository      bool
+	keepCertManager       bool
+	keepExternalSecrets   bool
+	upgradeVersion        string
+	sshKeyAlgorithm       string
+	privateKeyFile        string
+	privateKeyPassphrase  string
+	repositoryURL         string
+	username              string
+	singleCommit          bool
+	concurrency           int
+	bootstrapVersion      string
+	// namespaces maps the default namespaces to the namespaces the components are installed to
+	namespaces          map[string]string
+	patchesDir          string
+	imageRegistryMirror string
+	verifyKey           string
+	verifySignatureName string
+	// issuerRef, the CA keypair and the certificate durations configure the namespace certificates
+	issuerRef              string
+	caCertFile             string
+	caKeyFile              string
+	certificateDuration    string
+	certificateRenewBefore string
+	reportFile             string
+	fleetBase              string
+	existingFlux           bool
+	existingCertManager    bool
+	authorName             string
+	authorEmail            string
+	gpgKeyRing             string
+	gpgPassphrase          string
+	gpgKeyID               string
+	sshSigningKey          string
+	sshSigningPassphrase   string
+	viaPullRequest         bool
+	pullRequestBranch      string
+	waitForMerge           bool
+	mergeTimeout           time.Duration
+	registryCredHelper     string
 }
 
 // Option is a function that sets an option on the bootstrap
@@ -66,6 +112,22 @@ type Bootstrap struct {
 	providerClient gitprovider.Client
 	repository     gitprovider.UserRepository
 	url            string
+	// sshURL is the SSH clone URL flux pulls the management repository from, empty if it pulls over HTTPS
+	sshURL string
+	// state holds the completed phases, it is persisted in the cluster to resume a failed bootstrap
+	state *bootstrapState
+	// resuming is true as long as the phases run were all completed by a previous run
+	resuming bool
+	// batch stages the commits of the installers when all manifests are committed at once
+	batch *batchRepository
+	// patches holds the patch files of the components, keyed by component
+	patches map[string][]patchFile
+	// report collects the result of the bootstrap if a report file is set
+	report *report
+	// bundlePath is the CTF directory of the bundle given with --from-file
+	bundlePath string
+	// committer sets the author of the commits made through local clones and signs them
+	committer *committer
 	options
 }
 
@@ -216,6 +278,28 @@ func WithTransportType(transportType string) Option {
 	}
 }
 
+// WithDryRun sets whether the bootstrap only renders the manifests instead of committing
+// them to the management repository and applying them to the cluster.
+func WithDryRun(dryRun bool) Option {
+	return func(o *options) {
+		o.dryRun = dryRun
+	}
+}
+
+// WithOutputDir sets the directory the manifests are written to during a dry-run
+func WithOutputDir(outputDir string) Option {
+	return func(o *options) {
+		o.outputDir = outputDir
+	}
+}
+
+// WithResume sets whether to skip the phases completed by a previous bootstrap
+func WithResume(resume bool) Option {
+	return func(o *options) {
+		o.resume = resume
+	}
+}
+
 // New returns a new Bootstrap. It accepts a gitprovider.Client and a list of options.
 func New(providerClient gitprovider.Client, opts ...Option) (*Bootstrap, error) {
 	b := &Bootstrap{
@@ -232,31 +316,115 @@ func New(providerClient gitprovider.Client, opts ...Option) (*Bootstrap, error)
 		return nil, err
 	}
 
+	committer, err := newCommitter(&b.options)
+	if err != nil {
+		return nil, err
+	}
+	b.committer = committer
+
 	return b, nil
 }
 
 // Run runs the bootstrap of mpas and returns an error if it fails.
+// The report of the bootstrap is written to the report file, if set, even if it fails.
 func (b *Bootstrap) Run(ctx context.Context) error {
+	if b.reportFile == "" {
+		return b.run(ctx)
+	}
+
+	b.report = newReport()
+	b.report.BootstrapComponent.Constraint = b.bootstrapVersion
+	err := b.run(ctx)
+	if rerr := b.writeReport(err); rerr != nil {
+		return errors.Join(err, rerr)
+	}
+
+	return err
+}
+
+func (b *Bootstrap) run(ctx context.Context) error {
 	octx := om.DefaultContext()
 	if _, err := utils.Configure(octx, ""); err != nil {
 		return fmt.Errorf("failed to configure ocm context: %w", err)
 	}
 	// set default log level to 1 which is ERROR level to avoid printing INFO messages
 	octx.LoggingContext().SetDefaultLevel(1)
+	if err := b.configureRegistryCredentials(ctx, octx); err != nil {
+		return err
+	}
 
 	b.printer.Printf("Running %s ...\n",
 		printer.BoldBlue("mpas bootstrap"))
 
+	b.state = newBootstrapState()
+	if b.resume && !b.dryRun {
+		state, err := loadState(ctx, b.kubeclient, b.namespace(env.DefaultFluxNamespace))
+		if err != nil {
+			return fmt.Errorf("failed to load bootstrap state: %w", err)
+		}
+		if err := b.adoptNamespaces(state); err != nil {
+			return err
+		}
+		b.state = state
+		b.resuming = true
+	}
+	b.state.Namespaces = b.namespaces
+	b.adoptImageRegistryMirror(b.state)
+	b.state.FleetBase = b.fleetBase
+	b.state.ExistingFlux = b.existingFlux
+	b.state.ExistingCertManager = b.existingCertManager
+
+	if b.patchesDir != "" {
+		patches, err := loadPatchesDir(b.patchesDir)
+		if err != nil {
+			return err
+		}
+		b.patches = patches
+	}
+
+	if b.fromFile != "" {
+		cleanupBundle := func() {}
+		if err := b.inSpinner(fmt.Sprintf("Verifying bootstrap bundle %s", printer.BoldBlue(b.fromFile)), func() error {
+			bundlePath, cleanup, err := ocm.ResolveBundle(b.fromFile)
+			if err != nil {
+				return err
+			}
+			b.bundlePath = bundlePath
+			cleanupBundle = cleanup
+			return nil
+		}); err != nil {
+			return fmt.Errorf("failed to prepare from file: %w", err)
+		}
+		defer cleanupBundle()
+	}
+
+	// nothing is committed before the cluster, the registry and the git token are checked
+	if err := b.preflight(ctx, octx); err != nil {
+		return err
+	}
+
 	if err := b.inSpinner(fmt.Sprintf("Preparing Management repository %s",
 		printer.BoldBlue(b.repositoryName)), func() error {
+		if b.dryRun {
+			return b.prepareDryRunRepository()
+		}
+		if b.isPlainGit() {
+			return b.preparePlainGitRepository(ctx)
+		}
 		return b.reconcileManagementRepository(ctx)
 	}); err != nil {
 		return fmt.Errorf("failed to prepare management repository: %w", err)
 	}
 
-	if b.fromFile != "" {
+	if b.singleCommit {
+		b.batch = newBatchRepository(b.repository)
+		b.repository = b.batch
+	}
+
+	// during a dry-run the archive is read directly, nothing is transferred to the registry
+	if b.fromFile != "" && !b.dryRun {
 		fromFileToOciRepo := func() error {
-			ctf, err := ocm.RepositoryFromCTF(b.fromFile)
+			ctf, err := ocm.RepositoryFromCTF(b.bundlePath)
 			if err != nil {
 				return fmt.Errorf("failed to create CTF from file %q: %w", b.fromFile, err)
 			}
@@ -275,8 +443,10 @@ func (b *Bootstrap) Run(ctx context.Context) error {
 			return nil
 		}
 
-		if err := b.inSpinner(fmt.Sprintf("Transferring bootstrap component from %s to %s",
-			printer.BoldBlue(b.fromFile), printer.BoldBlue(b.registry)), fromFileToOciRepo); err != nil {
+		if _, err := b.inPhase(ctx, "transfer", b.fromFile, fmt.Sprintf("Transferring bootstrap component from %s to %s",
+			printer.BoldBlue(b.fromFile), printer.BoldBlue(b.registry)), func() (string, error) {
+			return "", fromFileToOciRepo()
+		}); err != nil {
 			return fmt.Errorf("failed to prepare from file: %w", err)
 		}
 	}
@@ -287,14 +457,17 @@ func (b *Bootstrap) Run(ctx context.Context) error {
 		err     error
 	)
 
-	if err := b.inSpinner(fmt.Sprintf("Fetching bootstrap component from %s",
-		printer.BoldBlue(b.registry)), func() error {
-		ociRepo, err = ocm.MakeRepositoryWithDockerConfig(octx, b.registry, b.dockerConfigPath)
+	fetchMsg := fmt.Sprintf("Fetching bootstrap component from %s", printer.BoldBlue(b.componentLocation()))
+	if b.bootstrapVersion != "" {
+		fetchMsg = fmt.Sprintf("Fetching bootstrap component %s from %s", printer.BoldBlue(b.bootstrapVersion), printer.BoldBlue(b.componentLocation()))
+	}
+	if err := b.inSpinner(fetchMsg, func() error {
+		ociRepo, err = b.componentRepository(octx)
 		if err != nil {
 			return fmt.Errorf("failed to fetch bootstrap component references: %w", err)
 		}
 
-		refs, err = b.fetchBootstrapComponentReferences(ociRepo)
+		b.state.Version, refs, err = b.fetchBootstrapComponentReferences(ociRepo, b.bootstrapVersion)
 		if err != nil {
 			return fmt.Errorf("failed to fetch bootstrap component references: %w", err)
 		}
@@ -304,70 +477,203 @@ func (b *Bootstrap) Run(ctx context.Context) error {
 		return fmt.Errorf("failed to fetch bootstrap components: %w", err)
 	}
 
+	// the bootstrap component is verified before anything is copied or generated from it
+	if b.verifyKey != "" {
+		if err := b.inSpinner(fmt.Sprintf("Verifying signature of bootstrap component %s",
+			printer.BoldBlue(b.state.Version)), func() error {
+			return b.verifyBootstrapComponent(ociRepo, b.state.Version)
+		}); err != nil {
+			return fmt.Errorf("failed to verify bootstrap component: %w", err)
+		}
+	}
+
+	b.state.Components = getOrderedKeys(refs)
+
+	if b.imageRegistryMirror != "" {
+		ociRepo, err = b.mirrorComponents(ctx, octx, ociRepo, refs, "mirror", b.state.Version)
+		if err != nil {
+			return err
+		}
+	}
+
+	if b.report != nil {
+		b.report.BootstrapComponent.Version = b.state.Version
+	}
+
+	if err := validatePatches(b.patches, b.state.Components); err != nil {
+		return err
+	}
+	b.state.Patches = b.patchedComponents()
+
+	b.setPullRequestBranch("bootstrap", b.state.Version)
+	if b.viaPullRequest && !b.dryRun {
+		if err := b.inSpinner(fmt.Sprintf("Preparing branch %s", printer.BoldBlue(b.pullRequestBranch)), func() error {
+			return b.preparePullRequestBranch(ctx)
+		}); err != nil {
+			return fmt.Errorf("failed to prepare pull request branch: %w", err)
+		}
+	}
+
 	sha, err := b.installInfrastructure(ctx, ociRepo, refs)
 	if err != nil {
 		return fmt.Errorf("failed to install infrastructure: %w", err)
 	}
 
-	if err := b.inSpinner("Reconciling bootstrap components", func() error {
-		return b.syncManagementRepository(ctx, sha)
-	}); err != nil {
-		return err
-	}
+	// with a single commit cert-manager is reconciled together with the components,
+	// an existing cert-manager commits nothing to reconcile
+	if !b.dryRun && !b.singleCommit {
+		if sha != "" {
+			if _, err := b.inPhase(ctx, "sync-infrastructure", sha, "Reconciling bootstrap components", func() (string, error) {
+				return sha, b.syncManagementRepository(ctx, sha)
+			}); err != nil {
+				return err
+			}
+		}
 
-	if err := b.inSpinner("Waiting for cert-manager to be available", func() error {
-		if err := kubeutils.ReportComponentsHealth(ctx, b.restClientGetter, b.timeout, []string{
-			certManager,
-			certManagerCAInjector,
-			certManagerWebhook,
-		}, "cert-manager"); err != nil {
-			return fmt.Errorf("failed to report health, please try again in a few minutes: %w", err)
+		if err := b.waitForCertManager(ctx); err != nil {
+			return err
 		}
+	}
 
-		return nil
-	}); err != nil {
-		return fmt.Errorf("failed to wait for cert-manager to be available: %w", err)
+	levels, err := installLevels(getOrderedKeys(refs), refs)
+	if err != nil {
+		return fmt.Errorf("failed to order components: %w", err)
+	}
+
+	// the manifests are generated concurrently, but committed level after level
+	var comps []string
+	for _, level := range levels {
+		comps = append(comps, level...)
+	}
+	if err := b.reportComponents(ociRepo, comps, refs); err != nil {
+		return err
 	}
 
-	compNs := make(map[string][]string)
-	var latestSHA string
-	// install components in order by using the ordered keys
-	comps := getOrderedKeys(refs)
-	for _, comp := range comps {
-		ref := refs[comp]
+	manifests, err := b.generateManifests(ctx, ociRepo, refs, b.componentsToGenerate(comps, refs))
+	if err != nil {
+		return fmt.Errorf("failed to generate manifests: %w", err)
+	}
 
-		if err := b.inSpinner(fmt.Sprintf("Generating %s manifest with version %s",
-			printer.BoldBlue(comp),
-			printer.BoldBlue(ref.GetVersion())), func() error {
-			latestSHA, err = b.generateControllerManifest(ctx, ociRepo, comp, ref, compNs)
+	var (
+		latestSHA string
+		compNs    = make(map[string][]string)
+		// the certificates of a namespace are committed with the first level installing to it,
+		// the cluster issuer with the first certificates
+		certified = make(map[string]bool)
+		issuer    = true
+	)
+	for i, level := range levels {
+		var (
+			levelNs = make(map[string][]string)
+			certNs  []string
+		)
+		for _, comp := range level {
+			ref := refs[comp]
+
+			inst, err := installerFor(comp, ref)
 			if err != nil {
 				return err
 			}
+			ns := b.namespace(inst.namespace)
+
+			latestSHA, err = b.inPhase(ctx, comp, ref.GetVersion(), fmt.Sprintf("Committing %s manifest with version %s",
+				printer.BoldBlue(comp),
+				printer.BoldBlue(ref.GetVersion())), func() (string, error) {
+				manifest, ok := manifests[comp]
+				if !ok {
+					return "", fmt.Errorf("manifest of %s was not generated", comp)
+				}
+
+				return b.installComponent(ctx, ref, ns, compNs, manifest)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to generate manifest: %w", err)
+			}
 
-			return nil
-		}); err != nil {
-			return fmt.Errorf("failed to generate manifest: %w", err)
+			compNs[ns] = append(compNs[ns], inst.deployments...)
+			levelNs[ns] = append(levelNs[ns], inst.deployments...)
+
+			if _, ok := namespaceCertificates[inst.namespace]; ok && !certified[inst.namespace] {
+				certified[inst.namespace] = true
+				certNs = append(certNs, inst.namespace)
+			}
+		}
+
+		if len(certNs) > 0 {
+			latestSHA, err = b.inPhase(ctx, "certificates-"+strings.Join(certNs, "-"), "", "Generating certificate manifests", func() (string, error) {
+				sha, err := b.generateCertificateManifests(ctx, certNs, issuer)
+				if err != nil {
+					return "", fmt.Errorf("failed to generate manifests: %w", err)
+				}
+
+				return sha, nil
+			})
+			if err != nil {
+				return fmt.Errorf("failed to generate certificate manifests: %w", err)
+			}
+			issuer = false
+		}
+
+		// the components depending on the level are committed once it is ready, the last level is
+		// reconciled with the remaining manifests
+		if i < len(levels)-1 && !b.dryRun && !b.singleCommit {
+			if err := b.waitForLevel(ctx, i, level, latestSHA, levelNs); err != nil {
+				return err
+			}
 		}
 	}
 
-	if err := b.inSpinner("Generating certificate manifests", func() error {
-		latestSHA, err = b.generateCertificateManifests(ctx)
+	latestSHA, err = b.inPhase(ctx, "bootstrap-version", b.state.Version, fmt.Sprintf("Recording bootstrap component version %s",
+		printer.BoldBlue(b.state.Version)), func() (string, error) {
+		return b.commitBootstrapVersion(ctx, b.state.Version, b.bootstrapVersion)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record bootstrap component version: %w", err)
+	}
 
+	if b.singleCommit {
+		latestSHA, err = b.inPhase(ctx, "commit", b.state.Version, "Committing component manifests", func() (string, error) {
+			return b.commitBatch(ctx, b.state.Version, latestSHA)
+		})
 		if err != nil {
-			return fmt.Errorf("failed to generate manifests: %w", err)
+			return fmt.Errorf("failed to commit component manifests: %w", err)
 		}
+	}
+
+	if b.dryRun {
+		b.printer.Printf("\n")
+		b.printer.Printf("Dry-run completed, manifests written to %s\n", printer.BoldBlue(b.outputDir))
 
 		return nil
-	}); err != nil {
-		return fmt.Errorf("failed to generate certificate manifests: %w", err)
 	}
 
-	if err := b.inSpinner("Reconciling component manifests", func() error {
-		return b.syncManagementRepository(ctx, latestSHA)
+	if b.viaPullRequest {
+		latestSHA, err = b.proposeManifests(ctx, fmt.Sprintf("Bootstrap MPAS %s", b.state.Version),
+			fmt.Sprintf("Installs the components of the MPAS bootstrap component %s to %s.", b.state.Version, b.targetPath))
+		if err != nil {
+			return fmt.Errorf("failed to propose component manifests: %w", err)
+		}
+
+		if !b.waitForMerge {
+			b.printer.Printf("\n")
+			b.printer.Printf("Bootstrap proposed successfully, flux installs the components once the pull request is merged!\n")
+
+			return nil
+		}
+	}
+
+	if _, err := b.inPhase(ctx, "sync-components", latestSHA, "Reconciling component manifests", func() (string, error) {
+		return latestSHA, b.syncManagementRepository(ctx, latestSHA)
 	}); err != nil {
 		return err
 	}
 
+	if b.singleCommit {
+		if err := b.waitForCertManager(ctx); err != nil {
+			return err
+		}
+	}
+
 	if err := b.inSpinner("Waiting for components to be ready", func() error {
 		for ns, comps := range compNs {
 			if err := kubeutils.ReportComponentsHealth(ctx, b.restClientGetter, b.timeout, comps, ns); err != nil {
@@ -377,8 +683,10 @@ func (b *Bootstrap) Run(ctx context.Context) error {
 
 		return nil
 	}); err != nil {
+		b.report.setHealth(healthNotReady)
 		return fmt.Errorf("failed to wait for components to be ready: %w", err)
 	}
+	b.report.setHealth(healthReady)
 
 	b.printer.Printf("\n")
 	b.printer.Printf("Bootstrap completed successfully!\n")
@@ -386,6 +694,48 @@ func (b *Bootstrap) Run(ctx context.Context) error {
 	return nil
 }
 
+// waitForLevel reconciles the management repository at the given commit and waits for the components
+// of the given install level to be ready.
+func (b *Bootstrap) waitForLevel(ctx context.Context, i int, level []string, sha string, compNs map[string][]string) error {
+	_, err := b.inPhase(ctx, fmt.Sprintf("sync-level-%d", i), sha, fmt.Sprintf("Waiting for %s to be ready",
+		printer.BoldBlue(strings.Join(level, ", "))), func() (string, error) {
+		if err := b.syncManagementRepository(ctx, sha); err != nil {
+			return "", err
+		}
+
+		for ns, comps := range compNs {
+			if err := kubeutils.ReportComponentsHealth(ctx, b.restClientGetter, b.timeout, comps, ns); err != nil {
+				return "", fmt.Errorf("failed to report health, please try again in a few minutes: %w", err)
+			}
+		}
+
+		return sha, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to wait for components to be ready: %w", err)
+	}
+
+	return nil
+}
+
+func (b *Bootstrap) waitForCertManager(ctx context.Context) error {
+	if err := b.inSpinner("Waiting for cert-manager to be available", func() error {
+		if err := kubeutils.ReportComponentsHealth(ctx, b.restClientGetter, b.timeout, []string{
+			certManager,
+			certManagerCAInjector,
+			certManagerWebhook,
+		}, b.namespace(env.DefaultCertManagerNamespace)); err != nil {
+			return fmt.Errorf("failed to report health, please try again in a few minutes: %w", err)
+		}
+
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to wait for cert-manager to be available: %w", err)
+	}
+
+	return nil
+}
+
 func (b *Bootstrap) inSpinner(msg string, f func() error) (err error) {
 	if err := b.printer.PrintSpinner(msg); err != nil {
 		return err
@@ -402,95 +752,205 @@ func (b *Bootstrap) inSpinner(msg string, f func() error) (err error) {
 	return b.printer.StopSpinner(msg)
 }
 
+// inPhase runs f in a spinner as the bootstrap phase with the given name and records the phase,
+// with the given version and the commit returned by f, as completed.
+// When resuming, the phases completed by a previous run with the same version are skipped until
+// the first phase that has to run, and the recorded commit is returned instead.
+// A phase staging files to be committed at once is only recorded once they are committed.
+func (b *Bootstrap) inPhase(ctx context.Context, name, version, msg string, f func() (string, error)) (string, error) {
+	started := time.Now()
+	if b.resuming {
+		if p, ok := b.state.completed(name, version); ok {
+			b.report.recordPhase(name, version, phaseSkipped, p.SHA, nil, started)
+			return p.SHA, b.inSpinner(fmt.Sprintf("%s (completed by a previous run)", msg), func() error {
+				return nil
+			})
+		}
+		b.resuming = false
+	}
+
+	var staged int
+	if b.batch != nil {
+		staged = b.batch.commitClient.staged()
+	}
+
+	var sha string
+	if err := b.inSpinner(msg, func() (err error) {
+		sha, err = f()
+		return err
+	}); err != nil {
+		b.report.recordPhase(name, version, phaseFailed, "", err, started)
+		return "", err
+	}
+	b.report.recordPhase(name, version, phaseCompleted, sha, nil, started)
+
+	if b.batch != nil && b.batch.commitClient.staged() != staged {
+		b.batch.pending = append(b.batch.pending, stagedPhase{name: name, version: version})
+		return sha, nil
+	}
+
+	if b.dryRun {
+		return sha, nil
+	}
+
+	b.state.complete(name, version, sha)
+	if err := saveState(ctx, b.kubeclient, b.namespace(env.DefaultFluxNamespace), b.state); err != nil {
+		return "", fmt.Errorf("failed to save bootstrap state: %w", err)
+	}
+
+	return sha, nil
+}
+
 func (b *Bootstrap) syncManagementRepository(ctx context.Context, latestSHA string) error {
 	expectedRevision := fmt.Sprintf("%s@sha1:%s", b.defaultBranch, latestSHA)
-	if err := kubeutils.ReconcileGitrepository(ctx, b.kubeclient, env.DefaultFluxNamespace, env.DefaultFluxNamespace); err != nil {
+	fluxNamespace, name := b.namespace(env.DefaultFluxNamespace), b.syncName()
+	if err := kubeutils.ReconcileGitrepository(ctx, b.kubeclient, name, fluxNamespace); err != nil {
 		return err
 	}
 
-	if err := kubeutils.ReportGitrepositoryHealth(ctx, b.kubeclient, env.DefaultFluxNamespace, env.DefaultFluxNamespace, expectedRevision, env.DefaultPollInterval, b.timeout); err != nil {
+	if err := kubeutils.ReportGitrepositoryHealth(ctx, b.kubeclient, name, fluxNamespace, expectedRevision, env.DefaultPollInterval, b.timeout); err != nil {
 		return fmt.Errorf("failed to report gitrepository health: %w", err)
 	}
 
-	if err := kubeutils.ReconcileKustomization(ctx, b.kubeclient, env.DefaultFluxNamespace, env.DefaultFluxNamespace); err != nil {
+	if err := kubeutils.ReconcileKustomization(ctx, b.kubeclient, name, fluxNamespace); err != nil {
 		return err
 	}
 
-	if err := kubeutils.ReportKustomizationHealth(ctx, b.kubeclient, env.DefaultFluxNamespace, env.DefaultFluxNamespace, expectedRevision, env.DefaultPollInterval, b.timeout); err != nil {
+	if err := kubeutils.ReportKustomizationHealth(ctx, b.kubeclient, name, fluxNamespace, expectedRevision, env.DefaultPollInterval, b.timeout); err != nil {
 		return fmt.Errorf("failed to report kustomization health: %w", err)
 	}
 
 	return nil
 }
 
-func (b *Bootstrap) installComponent(ctx context.Context, ociRepo om.Repository, ref compdesc.ComponentReference, comp, ns string, compNs map[string][]string) (string, error) {
-	dir, err := mkdirTempDir(fmt.Sprintf("%s-install", comp))
-	if err != nil {
-		return "", err
-	}
-	defer os.RemoveAll(dir)
+// installComponent commits the given generated manifest of the component.
+func (b *Bootstrap) installComponent(ctx context.Context, ref compdesc.ComponentReference, ns string, compNs map[string][]string, manifest []byte) (string, error) {
 	opts := &componentOptions{
 		gitRepository:         b.repository,
 		branch:                b.defaultBranch,
-		targetPath:            b.targetPath,
+		targetPath:            b.manifestsPath(),
 		commitMessageAppendix: b.commitMessageAppendix,
 		namespace:             ns,
-		provider:              string(b.providerClient.ProviderID()),
-		dir:                   dir,
+		provider:              b.providerID(),
 		timeout:               b.timeout,
 		installedNS:           compNs,
+		patchFiles:            b.patchCommitFiles(ref.Name, b.providerID()),
 	}
-
-	inst, err := newComponentInstall(ref.GetComponentName(), ref.GetVersion(), ociRepo, opts)
-	if err != nil {
-		return "", err
+	// the manifest of a fleet is shared in the base, the cluster includes it with a kustomization
+	if b.fleetBase != "" {
+		overlay, err := b.fleetOverlay(ns, ref.GetComponentName(), b.providerID())
+		if err != nil {
+			return "", err
+		}
+		opts.patchFiles = append(opts.patchFiles, overlay)
 	}
-	sha, err := inst.install(ctx, fmt.Sprintf("%s-file", comp))
+
+	sha, err := newComponentInstall(ref.GetComponentName(), ref.GetVersion(), opts).reconcileComponents(ctx, manifest)
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("failed to reconcile components: %w", err)
 	}
 	return sha, nil
 }
 
-func (b *Bootstrap) installFlux(ctx context.Context, ociRepo om.Repository, ref compdesc.ComponentReference) error {
+// installFlux installs flux, or only commits its rendered manifests if render is set, so that they are applied
+// by the flux syncing them, e.g. once the pull request proposing them is merged.
+func (b *Bootstrap) installFlux(ctx context.Context, ociRepo om.Repository, ref compdesc.ComponentReference, render bool) error {
 	dir, err := mkdirTempDir("flux-install")
 	if err != nil {
 		return err
 	}
 	defer os.RemoveAll(dir)
 
-	var caBundle []byte
+	opts, err := b.fluxOptions(dir)
+	if err != nil {
+		return err
+	}
+	opts.patchFiles = b.patchCommitFiles(ref.Name, "")
+
+	opts.patches, err = b.componentPatches(ref.Name)
+	if err != nil {
+		return err
+	}
+
+	inst, err := newFluxInstall(ref.GetComponentName(), ref.GetVersion(), ociRepo, opts)
+	if err != nil {
+		return err
+	}
+
+	if render {
+		files, err := inst.Render("flux")
+		if err != nil {
+			return err
+		}
+		for i, file := range files {
+			files[i].Content = gitprovider.StringVar(SetProviderDataFormat(b.providerID(), []byte(*file.Content)))
+		}
+
+		if _, err := b.repository.Commits().Create(ctx, b.defaultBranch, fmt.Sprintf("Add Flux %s component manifests", ref.GetVersion()), files); err != nil {
+			return fmt.Errorf("failed to write flux manifests: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := inst.Install(ctx, "flux"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// fluxOptions returns the options of flux syncing the management repository, working in the given directory.
+func (b *Bootstrap) fluxOptions(dir string) (*fluxOptions, error) {
+	var (
+		caBundle []byte
+		err      error
+	)
 	if b.caFile != "" {
 		caBundle, err = os.ReadFile(b.caFile)
 		if err != nil {
-			return fmt.Errorf("failed to read CA file: %w", err)
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
 		}
 	}
 
+	auth, err := b.gitAuthOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	username := "git"
+	if b.username != "" {
+		username = b.username
+	}
+
 	opts := &fluxOptions{
+		auth:                  auth,
+		username:              username,
 		kubeClient:            b.kubeclient,
 		restClientGetter:      b.restClientGetter,
 		url:                   b.url,
 		testURL:               b.testURL,
 		transport:             b.transportType,
 		branch:                b.defaultBranch,
+		pushBranch:            b.commitBranch(),
 		targetPath:            b.targetPath,
 		commitMessageAppendix: b.commitMessageAppendix,
 		dir:                   dir,
 		interval:              b.interval,
 		timeout:               b.timeout,
 		token:                 b.token,
-		namespace:             env.DefaultFluxNamespace,
+		namespace:             b.namespace(env.DefaultFluxNamespace),
+		syncName:              b.syncName(),
+		committer:             b.committer,
+		namespaces:            b.namespaces,
 		caFile:                caBundle,
 	}
-	inst, err := newFluxInstall(ref.GetComponentName(), ref.GetVersion(), b.owner, ociRepo, opts)
+
+	opts.ssh, err = b.sshOptions()
 	if err != nil {
-		return err
-	}
-	if err := inst.Install(ctx, "flux"); err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+
+	return opts, nil
 }
 
 func (b *Bootstrap) installCertManager(ctx context.Context, ociRepo om.Repository, ref compdesc.ComponentReference) (string, error) {
@@ -505,10 +965,17 @@ func (b *Bootstrap) installCertManager(ctx context.Context, ociRepo om.Repositor
 		dir:                   dir,
 		branch:                b.defaultBranch,
 		targetPath:            b.targetPath,
-		namespace:             env.DefaultCertManagerNamespace,
-		provider:              string(b.providerClient.ProviderID()),
+		namespace:             b.namespace(env.DefaultCertManagerNamespace),
+		namespaces:            b.namespaces,
+		provider:              b.providerID(),
 		timeout:               b.timeout,
 		commitMessageAppendix: b.commitMessageAppendix,
+		patchFiles:            b.patchCommitFiles(ref.Name, b.providerID()),
+	}
+
+	opts.patches, err = b.componentPatches(ref.Name)
+	if err != nil {
+		return "", err
 	}
 
 	inst, err := newCertManagerInstall(ref.GetComponentName(), ref.GetVersion(), ociRepo, opts)
@@ -522,45 +989,49 @@ func (b *Bootstrap) installCertManager(ctx context.Context, ociRepo om.Repositor
 	return sha, nil
 }
 
-func (b *Bootstrap) installExternalSecrets(ctx context.Context, ociRepo om.Repository, ref compdesc.ComponentReference) (string, error) {
-	dir, err := mkdirTempDir("external-secrets-install")
-	if err != nil {
-		return "", err
+// componentRepository returns the repository holding the bootstrap component.
+// During a dry-run the archive given with --from-file is read directly.
+func (b *Bootstrap) componentRepository(octx om.Context) (om.Repository, error) {
+	if b.dryRun && b.fromFile != "" {
+		return ocm.RepositoryFromCTF(b.bundlePath)
 	}
-	defer os.RemoveAll(dir)
 
-	opts := &externalSecretOptions{
-		gitRepository:         b.repository,
-		dir:                   dir,
-		branch:                b.defaultBranch,
-		targetPath:            b.targetPath,
-		namespace:             env.DefaultExternalSecretsNamespace,
-		provider:              string(b.providerClient.ProviderID()),
-		timeout:               b.timeout,
-		commitMessageAppendix: b.commitMessageAppendix,
-	}
+	return ocm.MakeRepositoryWithDockerConfig(octx, b.registry, b.dockerConfigPath)
+}
 
-	inst, err := newExternalSecretInstall(ref.GetComponentName(), ref.GetVersion(), ociRepo, opts)
-	if err != nil {
-		return "", fmt.Errorf("failed to create new external secrets installer: %w", err)
+func (b *Bootstrap) componentLocation() string {
+	if b.dryRun && b.fromFile != "" {
+		return b.fromFile
 	}
 
-	sha, err := inst.Install(ctx, env.ExternalSecretsName)
-	if err != nil {
-		return "", fmt.Errorf("failed to install external secrets: %w", err)
+	return b.registry
+}
+
+// providerID returns the id of the git provider used to format the committed data.
+// During a dry-run and on a plain git server the data is written as is.
+func (b *Bootstrap) providerID() string {
+	if b.dryRun || b.isPlainGit() {
+		return ""
 	}
 
-	return sha, nil
+	return string(b.providerClient.ProviderID())
 }
 
-func (b *Bootstrap) fetchBootstrapComponentReferences(ociRepo om.Repository) (map[string]compdesc.ComponentReference, error) {
-	cv, err := ocm.FetchLatestComponentVersion(ociRepo, env.DefaultBootstrapComponent)
+// fetchBootstrapComponentReferences fetches the highest version of the bootstrap component matching the given
+// constraint, the latest if it is empty, and returns its version and the references of the components to install.
+func (b *Bootstrap) fetchBootstrapComponentReferences(ociRepo om.Repository, constraint string) (string, map[string]compdesc.ComponentReference, error) {
+	cv, err := ocm.FetchComponentVersion(ociRepo, env.DefaultBootstrapComponent, constraint)
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
 	defer cv.Close()
 
-	return ocm.FetchComponentReferences(cv, b.components)
+	refs, err := ocm.FetchComponentReferences(cv, b.components)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return cv.GetVersion(), refs, nil
 }
 
 // reconcileManagementRepository reconciles the management repository. It creates it if it does not exist.
@@ -575,12 +1046,54 @@ func (b *Bootstrap) reconcileManagementRepository(ctx context.Context) error {
 		return err
 	}
 
-	b.repository = repo
+	b.repository = b.commitLocally(repo)
 	b.url = cloneURL
 
+	if b.useSSH() {
+		b.sshURL, err = b.getCloneURL(repo, gitprovider.TransportTypeSSH)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// prepareDryRunRepository sets up a repository that writes every commit to the output directory.
+// The repository is neither looked up nor created, the clone URL is derived from its reference.
+func (b *Bootstrap) prepareDryRunRepository() error {
+	if err := os.MkdirAll(b.outputDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory %q: %w", b.outputDir, err)
+	}
+
+	b.repository = newDryRunRepository(b.outputDir)
+	if b.isPlainGit() {
+		b.setPlainGitURLs()
+		return nil
+	}
+
+	b.url = b.repositoryRef().GetCloneURL(gitprovider.TransportTypeHTTPS)
+	if b.useSSH() {
+		b.sshURL = b.repositoryRef().GetCloneURL(gitprovider.TransportTypeSSH)
+	}
+
 	return nil
 }
 
+// repositoryRef returns the reference of the management repository.
+func (b *Bootstrap) repositoryRef() gitprovider.RepositoryRef {
+	subOrgs, repoName := splitSubOrganizationsFromRepositoryName(b.repositoryName)
+	if b.personal {
+		return newUserRepositoryRef(newUserRef(b.providerClient.SupportedDomain(), b.owner), repoName)
+	}
+
+	return newOrgRepositoryRef(gitprovider.OrganizationRef{
+		Domain:           b.providerClient.SupportedDomain(),
+		Organization:     b.owner,
+		SubOrganizations: subOrgs,
+	}, repoName)
+}
+
 // DeleteManagementRepository deletes the management repository.
 func (b *Bootstrap) DeleteManagementRepository(ctx context.Context) error {
 	if b.repository == nil {
@@ -652,49 +1165,56 @@ func (b *Bootstrap) getCloneURL(repository gitprovider.UserRepository, transport
 		url = repository.Repository().GetCloneURL(transport)
 	}
 
-	if transport == gitprovider.TransportTypeSSH {
-		return "", fmt.Errorf("SSH transport is not supported")
+	if url == "" {
+		return "", fmt.Errorf("failed to get %s clone URL of repository %s", transport, repository.Repository().String())
 	}
 
 	return url, nil
 }
 
+// installInfrastructure installs flux and cert-manager, or adopts the existing installations, and returns the sha
+// of the commit to reconcile before the other components are installed. It is empty if nothing was committed.
 func (b *Bootstrap) installInfrastructure(ctx context.Context, ociRepo om.Repository, refs map[string]compdesc.ComponentReference) (string, error) {
-	fluxRef, ok := refs[env.FluxName]
-	if !ok {
-		return "", fmt.Errorf("flux component not found")
-	}
+	if b.existingFlux {
+		if _, err := b.inPhase(ctx, "flux-sync", "", fmt.Sprintf("Configuring the existing %s to sync %s",
+			printer.BoldBlue(env.FluxName),
+			printer.BoldBlue(b.targetPath)), func() (string, error) {
+			return "", b.adoptFlux(ctx)
+		}); err != nil {
+			return "", fmt.Errorf("failed to configure the existing flux: %w", err)
+		}
+	} else {
+		fluxRef, ok := refs[env.FluxName]
+		if !ok {
+			return "", fmt.Errorf("flux component not found")
+		}
 
-	if err := b.inSpinner(fmt.Sprintf("Installing %s with version %s",
-		printer.BoldBlue(env.FluxName),
-		printer.BoldBlue(fluxRef.GetVersion())), func() error {
+		if _, err := b.inPhase(ctx, env.FluxName, fluxRef.GetVersion(), fmt.Sprintf("Installing %s with version %s",
+			printer.BoldBlue(env.FluxName),
+			printer.BoldBlue(fluxRef.GetVersion())), func() (string, error) {
+			return "", b.installFlux(ctx, ociRepo, fluxRef, b.dryRun)
+		}); err != nil {
+			return "", fmt.Errorf("failed to install flux: %w", err)
+		}
 
-		return b.installFlux(ctx, ociRepo, fluxRef)
-	}); err != nil {
-		return "", fmt.Errorf("failed to install flux: %w", err)
+		delete(refs, env.FluxName)
 	}
 
-	delete(refs, env.FluxName)
+	if b.existingCertManager {
+		return "", nil
+	}
 
 	certManagerRef, ok := refs[env.CertManagerName]
 	if !ok {
 		return "", fmt.Errorf("cert-manager component not found")
 	}
 
-	var (
-		sha string
-		err error
-	)
-	if err := b.inSpinner(fmt.Sprintf("Installing %s with version %s",
+	sha, err := b.inPhase(ctx, env.CertManagerName, certManagerRef.GetVersion(), fmt.Sprintf("Installing %s with version %s",
 		printer.BoldBlue(env.CertManagerName),
-		printer.BoldBlue(certManagerRef.GetVersion())), func() error {
-		sha, err = b.installCertManager(ctx, ociRepo, certManagerRef)
-		if err != nil {
-			return err
-		}
-
-		return nil
-	}); err != nil {
+		printer.BoldBlue(certManagerRef.GetVersion())), func() (string, error) {
+		return b.installCertManager(ctx, ociRepo, certManagerRef)
+	})
+	if err != nil {
 		return "", fmt.Errorf("failed to install cert-manager: %w", err)
 	}
 
@@ -703,49 +1223,26 @@ func (b *Bootstrap) installInfrastructure(ctx context.Context, ociRepo om.Reposi
 	return sha, nil
 }
 
-func (b *Bootstrap) generateControllerManifest(ctx context.Context, ociRepo om.Repository, comp string, ref compdesc.ComponentReference, compNs map[string][]string) (string, error) {
-	var latestSHA string
-	switch comp {
-	case env.OcmControllerName, env.GitControllerName, env.ReplicationControllerName:
-		sha, err := b.installComponent(ctx, ociRepo, ref, comp, env.DefaultOCMNamespace, compNs)
-		if err != nil {
-			return "", err
-		}
-		latestSHA = sha
-		compNs[env.DefaultOCMNamespace] = append(compNs[env.DefaultOCMNamespace], comp)
-	case env.MpasProductControllerName, env.MpasProjectControllerName:
-		sha, err := b.installComponent(ctx, ociRepo, ref, comp, "mpas-system", compNs)
-		if err != nil {
-			return "", err
-		}
-		latestSHA = sha
-		compNs["mpas-system"] = append(compNs["mpas-system"], comp)
-	case env.ExternalSecretsName:
-		sha, err := b.installExternalSecrets(ctx, ociRepo, ref)
-		if err != nil {
-			return "", err
-		}
-		latestSHA = sha
-		compNs["default"] = append(compNs["default"], externalSecret, externalSecretCertController, externalSecretWebhook)
-	default:
-		return "", fmt.Errorf("unknown component %q", comp)
+func (b *Bootstrap) generateCertificateManifests(ctx context.Context, namespaces []string, issuer bool) (string, error) {
+	certIssuer, err := b.certificateIssuer()
+	if err != nil {
+		return "", err
 	}
 
-	return latestSHA, nil
-}
-
-func (b *Bootstrap) generateCertificateManifests(ctx context.Context) (string, error) {
 	installer := newCertificateManifestInstaller(&certificateManifestOptions{
 		gitRepository:         b.repository,
 		branch:                b.defaultBranch,
 		targetPath:            b.targetPath,
-		provider:              string(b.providerClient.ProviderID()),
+		provider:              b.providerID(),
 		timeout:               b.timeout,
 		commitMessageAppendix: b.commitMessageAppendix,
 		kubeClient:            b.kubeclient,
+		dryRun:                b.dryRun,
+		namespaces:            b.namespaces,
+		issuer:                certIssuer,
 	})
 
-	return installer.Install(ctx)
+	return installer.Install(ctx, namespaces, issuer)
 }
 
 func splitSubOrganizationsFromRepositoryName(name string) ([]string, string) {
@@ -759,6 +1256,8 @@ func splitSubOrganizationsFromRepositoryName(name string) ([]string, string) {
 }
 
 func newOrgRepositoryRef(organizationRef gitprovider.OrganizationRef, name string) gitprovider.OrgRepositoryRef {
+	// bitbucket server looks up repositories by the key of their project, which is the owner
+	organizationRef.SetKey(organizationRef.Organization)
 	return gitprovider.OrgRepositoryRef{
 		OrganizationRef: organizationRef,
 		RepositoryName:  name,
@@ -809,6 +1308,22 @@ func setDefaults(b *Bootstrap) {
 	if b.transportType == "" {
 		b.transportType = "https"
 	}
+
+	if b.repositoryName == "" && b.repositoryURL != "" {
+		b.repositoryName = repositoryNameFromURL(b.repositoryURL)
+	}
+
+	if b.concurrency <= 0 {
+		b.concurrency = env.DefaultConcurrency
+	}
+
+	// the adopted components are not installed from the bootstrap component
+	b.components = slices.DeleteFunc(slices.Clone(b.components), b.adopted)
+
+	// the manifests proposed with a pull request are committed at once to its branch
+	if b.viaPullRequest {
+		b.singleCommit = true
+	}
 }
 
 func validateOptions(opts *options) error {
@@ -816,18 +1331,66 @@ func validateOptions(opts *options) error {
 		return fmt.Errorf("repository name must be set")
 	}
 
-	if opts.restClientGetter == nil {
-		return fmt.Errorf("rest client getter must be set")
-	}
+	if opts.dryRun {
+		if opts.outputDir == "" {
+			return fmt.Errorf("output directory must be set for a dry-run")
+		}
 
-	if opts.kubeclient == nil {
-		return fmt.Errorf("kubeclient must be set")
+		if opts.imageRegistryMirror != "" {
+			return fmt.Errorf("the images cannot be copied to an image registry mirror during a dry-run")
+		}
+	} else {
+		if opts.restClientGetter == nil {
+			return fmt.Errorf("rest client getter must be set")
+		}
+
+		if opts.kubeclient == nil {
+			return fmt.Errorf("kubeclient must be set")
+		}
 	}
 
 	if opts.printer == nil {
 		return fmt.Errorf("printer must be set")
 	}
 
+	if opts.viaPullRequest && opts.repositoryURL != "" {
+		return fmt.Errorf("pull requests cannot be opened on a plain git server")
+	}
+
+	if opts.waitForMerge && !opts.viaPullRequest {
+		return fmt.Errorf("waiting for the merge requires the manifests to be proposed with a pull request")
+	}
+
+	if opts.repositoryURL != "" {
+		if err := validatePlainGitOptions(opts); err != nil {
+			return err
+		}
+	}
+
+	if err := validateNamespaces(opts.namespaces); err != nil {
+		return err
+	}
+
+	if err := validateIssuerOptions(opts); err != nil {
+		return err
+	}
+
+	if opts.verifySignatureName != "" && opts.verifyKey == "" {
+		return fmt.Errorf("the verification key must be set to verify the signature %s", opts.verifySignatureName)
+	}
+
+	if opts.bootstrapVersion != "" {
+		if _, err := semver.NewConstraint(opts.bootstrapVersion); err != nil {
+			return fmt.Errorf("invalid bootstrap component version %q: %w", opts.bootstrapVersion, err)
+		}
+	}
+
+	switch sourcesecret.PrivateKeyAlgorithm(opts.sshKeyAlgorithm) {
+	case "", sourcesecret.RSAPrivateKeyAlgorithm, sourcesecret.ECDSAPrivateKeyAlgorithm, sourcesecret.Ed25519PrivateKeyAlgorithm:
+	default:
+		return fmt.Errorf("unsupported SSH key algorithm %q, must be one of rsa, ecdsa or ed25519", opts.sshKeyAlgorithm)
+	}
+
 	return nil
 }
 
@@ -0,0 +1,183 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/open-component-model/mpas/internal/env"
+	"sigs.k8s.io/kustomize/api/konfig"
+	kustypes "sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/yaml"
+)
+
+// bootstrapVersionConfigMapName is the name of the ConfigMap recording the installed bootstrap component version.
+const bootstrapVersionConfigMapName = "mpas-bootstrap-component"
+
+// WithBootstrapVersion sets the version, or semver constraint, of the bootstrap component to install.
+// The latest version is installed if it is empty.
+func WithBootstrapVersion(version string) Option {
+	return func(o *options) {
+		o.bootstrapVersion = version
+	}
+}
+
+// commitBootstrapVersion commits a ConfigMap recording the version of the bootstrap component, resolved from
+// the given constraint, to the management repository, so that the installed stack can be reproduced.
+// The ConfigMap is committed to the flux sync directory and added to the resources of its kustomization.
+func (b *Bootstrap) commitBootstrapVersion(ctx context.Context, version, constraint string) (string, error) {
+	namespace := b.namespace(env.DefaultFluxNamespace)
+	content, err := bootstrapVersionManifest(namespace, version, constraint, b.registry)
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(b.targetPath, namespace)
+	file := fmt.Sprintf("%s.yaml", bootstrapVersionConfigMapName)
+	files := []gitprovider.CommitFile{
+		newCommitFile(filepath.Join(dir, file), SetProviderDataFormat(b.providerID(), content)),
+	}
+
+	kus, err := b.syncKustomization(ctx, dir, file)
+	if err != nil {
+		return "", err
+	}
+	if kus != nil {
+		files = append(files, newCommitFile(filepath.Join(dir, konfig.DefaultKustomizationFileName()),
+			SetProviderDataFormat(b.providerID(), kus)))
+	}
+
+	commitMsg := fmt.Sprintf("Record MPAS bootstrap component version %s", version)
+	if b.commitMessageAppendix != "" {
+		commitMsg = commitMsg + "\n\n" + b.commitMessageAppendix
+	}
+
+	commit, err := b.repository.Commits().Create(ctx, b.defaultBranch, commitMsg, files)
+	if err != nil {
+		return "", fmt.Errorf("failed to commit bootstrap component version: %w", err)
+	}
+
+	return commit.Get().Sha, nil
+}
+
+// syncKustomization returns the kustomization of the given flux sync directory with the given file added to its
+// resources, as flux applies only the listed resources. Nil is returned if the directory has no kustomization,
+// then all its manifests are applied, or if the file is listed already.
+func (b *Bootstrap) syncKustomization(ctx context.Context, dir, file string) ([]byte, error) {
+	path := filepath.Join(dir, konfig.DefaultKustomizationFileName())
+	content, err := b.readManifest(ctx, path)
+	if err != nil || content == nil {
+		return nil, err
+	}
+
+	var kus kustypes.Kustomization
+	if err := yaml.Unmarshal(content, &kus); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %w", path, err)
+	}
+	if slices.Contains(kus.Resources, file) {
+		return nil, nil
+	}
+	kus.Resources = append(kus.Resources, file)
+
+	data, err := yaml.Marshal(kus)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s: %w", path, err)
+	}
+
+	return data, nil
+}
+
+// readManifest returns the content of the given file of the management repository, or nil if it does not exist.
+// The files staged for a single commit and the ones written during a dry-run take precedence.
+func (b *Bootstrap) readManifest(ctx context.Context, path string) ([]byte, error) {
+	if b.batch != nil {
+		if content, ok := b.batch.commitClient.get(path); ok {
+			if content == nil {
+				return nil, nil
+			}
+			if b.providerID() != env.ProviderGitea {
+				return []byte(*content), nil
+			}
+
+			data, err := base64.StdEncoding.DecodeString(*content)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+			}
+			return data, nil
+		}
+	}
+
+	if b.dryRun {
+		file, err := securejoin.SecureJoin(b.outputDir, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve path %q: %w", path, err)
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		return content, nil
+	}
+
+	files, err := newPlainGitRepository(b.gitClient, b.committer).Files().Get(ctx, filepath.Dir(path), b.commitBranch())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	for _, f := range files {
+		if *f.Path == filepath.ToSlash(path) {
+			return []byte(*f.Content), nil
+		}
+	}
+
+	return nil, nil
+}
+
+// bootstrapVersionManifest returns the manifest of the ConfigMap, in the given namespace, recording the bootstrap
+// component version, the constraint it was resolved from and the registry it was fetched from.
+func bootstrapVersionManifest(namespace, version, constraint, registry string) ([]byte, error) {
+	data := map[string]string{
+		"component": env.DefaultBootstrapComponent,
+		"version":   version,
+	}
+	if constraint != "" {
+		data["constraint"] = constraint
+	}
+	if registry != "" {
+		data["registry"] = registry
+	}
+
+	// a typed ConfigMap would be rendered with an empty creationTimestamp
+	cm := map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]string{
+			"name":      bootstrapVersionConfigMapName,
+			"namespace": namespace,
+		},
+		"data": data,
+	}
+
+	content, err := yaml.Marshal(cm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal bootstrap component version: %w", err)
+	}
+
+	return content, nil
+}
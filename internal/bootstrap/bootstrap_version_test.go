@@ -0,0 +1,164 @@
+package bootstrap
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/open-component-model/mpas/internal/printer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitBootstrapVersion(t *testing.T) {
+	mc := &mockCommitClient{
+		commit: &mockCommit{
+			sha: "sha",
+		},
+	}
+	b := &Bootstrap{
+		repository: &mockGitRepository{
+			commitClient: mc,
+		},
+		options: options{
+			// no provider client is needed to write the manifest as is
+			dryRun:           true,
+			defaultBranch:    "main",
+			targetPath:       "clusters/my-cluster",
+			registry:         "ghcr.io/open-component-model/mpas-bootstrap-component",
+			bootstrapVersion: "~0.5.0",
+		},
+	}
+
+	sha, err := b.commitBootstrapVersion(context.Background(), "v0.5.2", b.bootstrapVersion)
+	require.NoError(t, err)
+	assert.Equal(t, "sha", sha)
+
+	require.Len(t, mc.calledWidth, 1)
+	assert.Equal(t, "Record MPAS bootstrap component version v0.5.2", mc.calledWidth[0][1])
+	files := mc.calledWidth[0][2].([]gitprovider.CommitFile)
+	require.Len(t, files, 1)
+	assert.Equal(t, "clusters/my-cluster/flux-system/mpas-bootstrap-component.yaml", *files[0].Path)
+	assert.Equal(t, `apiVersion: v1
+data:
+  component: ocm.software/mpas/bootstrap
+  constraint: ~0.5.0
+  registry: ghcr.io/open-component-model/mpas-bootstrap-component
+  version: v0.5.2
+kind: ConfigMap
+metadata:
+  name: mpas-bootstrap-component
+  namespace: flux-system
+`, *files[0].Content)
+}
+
+func TestCommitBootstrapVersionKustomization(t *testing.T) {
+	testCases := []struct {
+		name      string
+		resources string
+		expected  string
+	}{
+		{
+			name: "not listed",
+			resources: `- gotk-components.yaml
+- gotk-sync.yaml
+`,
+			expected: `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+- gotk-components.yaml
+- gotk-sync.yaml
+- mpas-bootstrap-component.yaml
+`,
+		},
+		{
+			name: "listed",
+			resources: `- gotk-sync.yaml
+- mpas-bootstrap-component.yaml
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			kus := "apiVersion: kustomize.config.k8s.io/v1beta1\nkind: Kustomization\nresources:\n" + tc.resources
+			require.NoError(t, os.MkdirAll(filepath.Join(dir, "clusters/my-cluster/flux-system"), 0o755))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "clusters/my-cluster/flux-system/kustomization.yaml"), []byte(kus), 0o644))
+
+			b := &Bootstrap{
+				repository: newDryRunRepository(dir),
+				options: options{
+					dryRun:        true,
+					outputDir:     dir,
+					defaultBranch: "main",
+					targetPath:    "clusters/my-cluster",
+				},
+			}
+
+			_, err := b.commitBootstrapVersion(context.Background(), "v0.5.2", "")
+			require.NoError(t, err)
+
+			assert.FileExists(t, filepath.Join(dir, "clusters/my-cluster/flux-system/mpas-bootstrap-component.yaml"))
+			content, err := os.ReadFile(filepath.Join(dir, "clusters/my-cluster/flux-system/kustomization.yaml"))
+			require.NoError(t, err)
+			if tc.expected == "" {
+				tc.expected = kus
+			}
+			assert.Equal(t, tc.expected, string(content))
+		})
+	}
+}
+
+func TestCommitBootstrapVersionStagedKustomization(t *testing.T) {
+	batch := newBatchRepository(nil)
+	_, err := batch.Commits().Create(context.Background(), "main", "Add Flux v2.0.0 component manifests", []gitprovider.CommitFile{
+		newCommitFile("clusters/my-cluster/flux-system/kustomization.yaml", `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+- gotk-components.yaml
+- gotk-sync.yaml
+`),
+	})
+	require.NoError(t, err)
+
+	b := &Bootstrap{
+		repository: batch,
+		batch:      batch,
+		options: options{
+			// no provider client is needed to stage the manifests as is
+			repositoryURL: "https://example.com/mpas.git",
+			defaultBranch: "main",
+			targetPath:    "clusters/my-cluster",
+		},
+	}
+
+	_, err = b.commitBootstrapVersion(context.Background(), "v0.5.2", "")
+	require.NoError(t, err)
+
+	files, _ := batch.commitClient.flush()
+	require.Len(t, files, 2)
+	assert.Equal(t, "clusters/my-cluster/flux-system/kustomization.yaml", *files[0].Path)
+	assert.Contains(t, *files[0].Content, "- mpas-bootstrap-component.yaml\n")
+	assert.Equal(t, "clusters/my-cluster/flux-system/mpas-bootstrap-component.yaml", *files[1].Path)
+}
+
+func TestValidateBootstrapVersion(t *testing.T) {
+	p, err := printer.Newprinter(io.Discard)
+	require.NoError(t, err)
+
+	opts := &options{
+		printer:          p,
+		repositoryName:   "mpas",
+		dryRun:           true,
+		outputDir:        t.TempDir(),
+		bootstrapVersion: ">= 0.5.0, < 0.6.0",
+	}
+	require.NoError(t, validateOptions(opts))
+
+	opts.bootstrapVersion = "latest"
+	assert.ErrorContains(t, validateOptions(opts), `invalid bootstrap component version "latest"`)
+}
@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/open-component-model/mpas/internal/env"
+	"github.com/open-component-model/ocm/pkg/contexts/ocm/compdesc"
+)
+
+// dependsOnLabel is the label of a component reference of the bootstrap component holding the names
+// of the components that have to be ready before the component is installed, e.g. ["ocm-controller"].
+const dependsOnLabel = "mpas.ocm.software/depends-on"
+
+// defaultDependencies are the dependencies of the components whose reference has no depends-on label.
+var defaultDependencies = map[string][]string{
+	env.GitControllerName:         {env.OcmControllerName},
+	env.ReplicationControllerName: {env.OcmControllerName},
+	env.MpasProductControllerName: {env.GitControllerName, env.ReplicationControllerName},
+	env.MpasProjectControllerName: {env.GitControllerName},
+}
+
+// componentDependencies returns the dependencies of the given component and whether they were declared
+// by the depends-on label of its reference rather than taken from the default dependencies.
+func componentDependencies(name string, ref compdesc.ComponentReference) ([]string, bool, error) {
+	var deps []string
+	ok, err := ref.GetLabels().GetValue(dependsOnLabel, &deps)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read label %s of %s: %w", dependsOnLabel, name, err)
+	}
+
+	if !ok {
+		return defaultDependencies[name], false, nil
+	}
+
+	return deps, true, nil
+}
+
+// installLevels sorts the given components topologically by their dependencies. Each level holds the components
+// depending only on the components of the previous levels, in alphabetical order, so that the components of
+// a level can be installed together once the previous level is ready. Flux and cert-manager are installed first
+// and are not part of the levels. A default dependency on a component that is not installed is ignored,
+// a declared one fails.
+func installLevels(comps []string, refs map[string]compdesc.ComponentReference) ([][]string, error) {
+	pending := make(map[string]map[string]bool, len(comps))
+	for _, comp := range comps {
+		pending[comp] = make(map[string]bool)
+	}
+
+	for _, comp := range comps {
+		deps, declared, err := componentDependencies(comp, refs[comp])
+		if err != nil {
+			return nil, err
+		}
+
+		for _, dep := range deps {
+			if dep == env.FluxName || dep == env.CertManagerName {
+				continue
+			}
+			if _, ok := pending[dep]; !ok {
+				if _, ok := refs[dep]; declared && !ok {
+					return nil, fmt.Errorf("component %s depends on unknown component %s", comp, dep)
+				}
+				continue
+			}
+			pending[comp][dep] = true
+		}
+	}
+
+	var levels [][]string
+	for len(pending) > 0 {
+		var level []string
+		for comp, deps := range pending {
+			if len(deps) == 0 {
+				level = append(level, comp)
+			}
+		}
+
+		if len(level) == 0 {
+			return nil, fmt.Errorf("components %s have cyclic dependencies", strings.Join(sortedKeys(pending), ", "))
+		}
+
+		sort.Strings(level)
+		for _, comp := range level {
+			delete(pending, comp)
+		}
+		for _, deps := range pending {
+			for _, comp := range level {
+				delete(deps, comp)
+			}
+		}
+
+		levels = append(levels, level)
+	}
+
+	return levels, nil
+}
+
+// installOrder returns the given components in the order of their install levels.
+func installOrder(comps []string, refs map[string]compdesc.ComponentReference) ([]string, error) {
+	levels, err := installLevels(comps, refs)
+	if err != nil {
+		return nil, err
+	}
+
+	ordered := make([]string, 0, len(comps))
+	for _, level := range levels {
+		ordered = append(ordered, level...)
+	}
+
+	return ordered, nil
+}
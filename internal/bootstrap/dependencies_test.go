@@ -0,0 +1,106 @@
+package bootstrap
+
+import (
+	"testing"
+
+	"github.com/open-component-model/mpas/internal/env"
+	"github.com/open-component-model/ocm/pkg/contexts/ocm/compdesc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDependentComponentReference(t *testing.T, name string, deps ...string) compdesc.ComponentReference {
+	ref := newComponentReference(t, name, "v1.0.0", nil)
+	require.NoError(t, ref.Labels.Set(dependsOnLabel, deps))
+
+	return ref
+}
+
+func TestInstallLevels(t *testing.T) {
+	testCases := []struct {
+		name     string
+		refs     map[string]compdesc.ComponentReference
+		expected [][]string
+		err      string
+	}{
+		{
+			name: "default dependencies",
+			refs: map[string]compdesc.ComponentReference{
+				env.ExternalSecretsName:       newComponentReference(t, env.ExternalSecretsName, "v1.0.0", nil),
+				env.GitControllerName:         newComponentReference(t, env.GitControllerName, "v1.0.0", nil),
+				env.MpasProductControllerName: newComponentReference(t, env.MpasProductControllerName, "v1.0.0", nil),
+				env.MpasProjectControllerName: newComponentReference(t, env.MpasProjectControllerName, "v1.0.0", nil),
+				env.OcmControllerName:         newComponentReference(t, env.OcmControllerName, "v1.0.0", nil),
+				env.ReplicationControllerName: newComponentReference(t, env.ReplicationControllerName, "v1.0.0", nil),
+			},
+			expected: [][]string{
+				{env.ExternalSecretsName, env.OcmControllerName},
+				{env.GitControllerName, env.ReplicationControllerName},
+				{env.MpasProductControllerName, env.MpasProjectControllerName},
+			},
+		},
+		{
+			name: "default dependencies on missing components are ignored",
+			refs: map[string]compdesc.ComponentReference{
+				env.GitControllerName:         newComponentReference(t, env.GitControllerName, "v1.0.0", nil),
+				env.MpasProjectControllerName: newComponentReference(t, env.MpasProjectControllerName, "v1.0.0", nil),
+			},
+			expected: [][]string{
+				{env.GitControllerName},
+				{env.MpasProjectControllerName},
+			},
+		},
+		{
+			name: "declared dependencies replace the default ones",
+			refs: map[string]compdesc.ComponentReference{
+				env.GitControllerName: newDependentComponentReference(t, env.GitControllerName),
+				env.OcmControllerName: newDependentComponentReference(t, env.OcmControllerName, env.CertManagerName, "vault"),
+				"vault":               newDependentComponentReference(t, "vault", env.FluxName),
+			},
+			expected: [][]string{
+				{env.GitControllerName, "vault"},
+				{env.OcmControllerName},
+			},
+		},
+		{
+			name: "declared dependency on an unknown component",
+			refs: map[string]compdesc.ComponentReference{
+				env.OcmControllerName: newDependentComponentReference(t, env.OcmControllerName, "vault"),
+			},
+			err: "component ocm-controller depends on unknown component vault",
+		},
+		{
+			name: "cyclic dependencies",
+			refs: map[string]compdesc.ComponentReference{
+				env.ExternalSecretsName: newComponentReference(t, env.ExternalSecretsName, "v1.0.0", nil),
+				env.GitControllerName:   newComponentReference(t, env.GitControllerName, "v1.0.0", nil),
+				env.OcmControllerName:   newDependentComponentReference(t, env.OcmControllerName, env.GitControllerName),
+			},
+			err: "components git-controller, ocm-controller have cyclic dependencies",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			levels, err := installLevels(getOrderedKeys(tc.refs), tc.refs)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, levels)
+		})
+	}
+}
+
+func TestInstallOrder(t *testing.T) {
+	refs := map[string]compdesc.ComponentReference{
+		env.GitControllerName:         newComponentReference(t, env.GitControllerName, "v1.0.0", nil),
+		env.MpasProductControllerName: newComponentReference(t, env.MpasProductControllerName, "v1.0.0", nil),
+		env.OcmControllerName:         newComponentReference(t, env.OcmControllerName, "v1.0.0", nil),
+	}
+
+	order, err := installOrder([]string{env.MpasProductControllerName, env.GitControllerName, env.OcmControllerName}, refs)
+	require.NoError(t, err)
+	assert.Equal(t, []string{env.OcmControllerName, env.GitControllerName, env.MpasProductControllerName}, order)
+}
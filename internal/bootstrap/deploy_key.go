@@ -0,0 +1,164 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+	"crypto/elliptic"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/fluxcd/flux2/v2/pkg/manifestgen/sourcesecret"
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/fluxcd/pkg/ssh"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	"github.com/open-component-model/mpas/internal/env"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// sshOptions configures flux to pull the management repository over SSH with a read-only deploy key
+// instead of over HTTPS with the token.
+type sshOptions struct {
+	// url is the SSH clone URL of the management repository
+	url string
+	// keyAlgorithm is the algorithm of the generated keypair
+	keyAlgorithm sourcesecret.PrivateKeyAlgorithm
+	// keypair is the keypair to use, it is generated if nil
+	keypair *ssh.KeyPair
+	// passphrase is the passphrase of the private key of the keypair, empty if it is not encrypted
+	passphrase string
+	// registerDeployKey registers the public key of the keypair with the git provider, nil if there is none
+	registerDeployKey func(ctx context.Context, publicKey []byte) error
+}
+
+// WithSSHKeyAlgorithm sets the algorithm of the keypair generated for the deploy key.
+// Setting it makes flux pull the management repository over SSH.
+func WithSSHKeyAlgorithm(algorithm string) Option {
+	return func(o *options) {
+		o.sshKeyAlgorithm = algorithm
+	}
+}
+
+// WithPrivateKeyFile sets the private key file of the keypair to use for the deploy key.
+// Setting it makes flux pull the management repository over SSH.
+func WithPrivateKeyFile(privateKeyFile string) Option {
+	return func(o *options) {
+		o.privateKeyFile = privateKeyFile
+	}
+}
+
+// WithPrivateKeyPassphrase sets the passphrase of the private key file, if it is encrypted.
+func WithPrivateKeyPassphrase(passphrase string) Option {
+	return func(o *options) {
+		o.privateKeyPassphrase = passphrase
+	}
+}
+
+// useSSH returns true if flux pulls the management repository over SSH.
+func (b *Bootstrap) useSSH() bool {
+	return b.sshKeyAlgorithm != "" || b.privateKeyFile != "" || b.sshURL != ""
+}
+
+// sshOptions returns the SSH options for flux, nil when flux pulls the management repository over HTTPS.
+func (b *Bootstrap) sshOptions() (*sshOptions, error) {
+	if !b.useSSH() {
+		return nil, nil
+	}
+
+	opts := &sshOptions{
+		url:          b.sshURL,
+		keyAlgorithm: sourcesecret.PrivateKeyAlgorithm(b.sshKeyAlgorithm),
+	}
+
+	// a plain git server has no API to register deploy keys, the key must already be authorized
+	if !b.isPlainGit() {
+		opts.registerDeployKey = b.reconcileDeployKey
+	}
+
+	if b.privateKeyFile != "" {
+		keypair, err := sourcesecret.LoadKeyPairFromPath(b.privateKeyFile, b.privateKeyPassphrase)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load private key: %w", err)
+		}
+		opts.keypair = keypair
+		opts.passphrase = b.privateKeyPassphrase
+	}
+
+	return opts, nil
+}
+
+// sourceSecretOptions returns the options of the source secret used by flux to pull over SSH.
+func (s *sshOptions) sourceSecretOptions(opts sourcesecret.Options) (sourcesecret.Options, error) {
+	u, err := url.Parse(s.url)
+	if err != nil {
+		return opts, fmt.Errorf("failed to parse SSH URL %q: %w", s.url, err)
+	}
+
+	// the password of the source secret decrypts the private key
+	opts.Username = ""
+	opts.Password = s.passphrase
+	opts.CAFile = nil
+	opts.SSHHostname = u.Host
+	opts.Keypair = s.keypair
+	opts.PrivateKeyAlgorithm = s.keyAlgorithm
+	opts.RSAKeyBits = 2048
+	opts.ECDSACurve = elliptic.P384()
+
+	return opts, nil
+}
+
+// deployKeyName returns the name of the deploy key of the target path.
+func (b *Bootstrap) deployKeyName() string {
+	path := strings.Trim(strings.TrimPrefix(filepath.ToSlash(filepath.Clean(b.targetPath)), "./"), "/")
+	if path == "." {
+		path = ""
+	}
+	if path == "" {
+		return "mpas-bootstrap"
+	}
+
+	return fmt.Sprintf("mpas-bootstrap-%s", strings.ReplaceAll(path, "/", "-"))
+}
+
+// postGenerateSecret registers the public key of the generated source secret as deploy key.
+func (s *sshOptions) postGenerateSecret(ctx context.Context, secret corev1.Secret, _ sourcesecret.Options) error {
+	publicKey, ok := secret.StringData[sourcesecret.PublicKeySecretKey]
+	if !ok || publicKey == "" || s.registerDeployKey == nil {
+		return nil
+	}
+
+	return s.registerDeployKey(ctx, []byte(publicKey))
+}
+
+// reconcileDeployKey registers the given public key as read-only deploy key of the management repository.
+func (b *Bootstrap) reconcileDeployKey(ctx context.Context, publicKey []byte) error {
+	if _, _, err := b.repository.DeployKeys().Reconcile(ctx, gitprovider.DeployKeyInfo{
+		Name:     b.deployKeyName(),
+		Key:      publicKey,
+		ReadOnly: ptr.To(true),
+	}); err != nil {
+		return fmt.Errorf("failed to reconcile deploy key %q: %w", b.deployKeyName(), err)
+	}
+
+	return nil
+}
+
+// detectSSH sets the SSH URL if flux already pulls the management repository over SSH.
+func (b *Bootstrap) detectSSH(ctx context.Context) error {
+	var repo sourcev1.GitRepository
+	if err := b.kubeclient.Get(ctx, client.ObjectKey{Name: b.syncName(), Namespace: b.namespace(env.DefaultFluxNamespace)}, &repo); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	if strings.HasPrefix(repo.Spec.URL, "ssh://") {
+		b.sshURL = repo.Spec.URL
+	}
+
+	return nil
+}
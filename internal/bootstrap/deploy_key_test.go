@@ -0,0 +1,107 @@
+package bootstrap
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fluxcd/flux2/v2/pkg/manifestgen/sourcesecret"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	cryptssh "golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestDeployKeyName(t *testing.T) {
+	testCases := []struct {
+		targetPath string
+		expected   string
+	}{
+		{targetPath: ".", expected: "mpas-bootstrap"},
+		{targetPath: "", expected: "mpas-bootstrap"},
+		{targetPath: "./clusters/my-cluster/", expected: "mpas-bootstrap-clusters-my-cluster"},
+		{targetPath: "/clusters/my-cluster", expected: "mpas-bootstrap-clusters-my-cluster"},
+		{targetPath: ".clusters/prod.", expected: "mpas-bootstrap-.clusters-prod."},
+	}
+
+	for _, tc := range testCases {
+		b := &Bootstrap{options: options{targetPath: tc.targetPath}}
+		assert.Equal(t, tc.expected, b.deployKeyName())
+	}
+}
+
+func TestRegisterDeployKey(t *testing.T) {
+	deployKeys := &mockDeployKeyClient{}
+	b := &Bootstrap{
+		repository: &mockGitRepository{deployKeyClient: deployKeys},
+		options: options{
+			targetPath:      "clusters/my-cluster",
+			sshKeyAlgorithm: string(sourcesecret.ECDSAPrivateKeyAlgorithm),
+		},
+		sshURL: "ssh://git@github.com/ocmOrg/mpas",
+	}
+
+	opts, err := b.sshOptions()
+	require.NoError(t, err)
+
+	secretOpts, err := opts.sourceSecretOptions(sourcesecret.Options{
+		Username: "git",
+		Password: "token",
+	})
+	require.NoError(t, err)
+	assert.Empty(t, secretOpts.Username)
+	assert.Empty(t, secretOpts.Password)
+	assert.Equal(t, "github.com", secretOpts.SSHHostname)
+	assert.Equal(t, sourcesecret.ECDSAPrivateKeyAlgorithm, secretOpts.PrivateKeyAlgorithm)
+
+	// no public key, nothing to register
+	require.NoError(t, opts.postGenerateSecret(context.Background(), corev1.Secret{}, secretOpts))
+	assert.Empty(t, deployKeys.reconciled)
+
+	require.NoError(t, opts.postGenerateSecret(context.Background(), corev1.Secret{
+		StringData: map[string]string{
+			sourcesecret.PublicKeySecretKey: "ecdsa-sha2-nistp384 AAAA",
+		},
+	}, secretOpts))
+	require.Len(t, deployKeys.reconciled, 1)
+	assert.Equal(t, "mpas-bootstrap-clusters-my-cluster", deployKeys.reconciled[0].Name)
+	assert.Equal(t, []byte("ecdsa-sha2-nistp384 AAAA"), deployKeys.reconciled[0].Key)
+	require.NotNil(t, deployKeys.reconciled[0].ReadOnly)
+	assert.True(t, *deployKeys.reconciled[0].ReadOnly)
+}
+
+func TestEncryptedPrivateKey(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	block, err := cryptssh.MarshalPrivateKeyWithPassphrase(key, "", []byte("secret"))
+	require.NoError(t, err)
+	file := filepath.Join(t.TempDir(), "id_ed25519")
+	require.NoError(t, os.WriteFile(file, pem.EncodeToMemory(block), 0o600))
+
+	b := &Bootstrap{
+		options: options{
+			targetPath:     "clusters/my-cluster",
+			privateKeyFile: file,
+		},
+		sshURL: "ssh://git@github.com/ocmOrg/mpas",
+	}
+
+	// an encrypted private key cannot be loaded without its passphrase
+	_, err = b.sshOptions()
+	assert.ErrorContains(t, err, "failed to load private key")
+
+	b.privateKeyPassphrase = "secret"
+	opts, err := b.sshOptions()
+	require.NoError(t, err)
+	require.NotNil(t, opts.keypair)
+
+	// the passphrase is stored in the source secret for flux to decrypt the private key
+	secretOpts, err := opts.sourceSecretOptions(sourcesecret.Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "secret", secretOpts.Password)
+	assert.Empty(t, secretOpts.Username)
+}
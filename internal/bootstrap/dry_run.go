@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// dryRunRepository is a management repository that writes the files of every commit
+// to a local directory instead of pushing them to the git provider.
+type dryRunRepository struct {
+	gitprovider.UserRepository
+
+	commitClient *localCommitClient
+}
+
+var _ gitprovider.UserRepository = &dryRunRepository{}
+
+// newDryRunRepository returns a repository writing all committed files below dir.
+func newDryRunRepository(dir string) *dryRunRepository {
+	return &dryRunRepository{
+		commitClient: &localCommitClient{
+			dir: dir,
+		},
+	}
+}
+
+func (r *dryRunRepository) Commits() gitprovider.CommitClient {
+	return r.commitClient
+}
+
+type localCommitClient struct {
+	gitprovider.CommitClient
+
+	dir string
+	// mu is used to synchronize writes to the output directory
+	mu sync.Mutex
+}
+
+var _ gitprovider.CommitClient = &localCommitClient{}
+
+// Create writes the given files to the output directory. The returned commit carries a sha
+// computed from the message and the files so that the output is reproducible.
+func (c *localCommitClient) Create(_ context.Context, branch, message string, files []gitprovider.CommitFile) (gitprovider.Commit, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h := sha1.New()
+	h.Write([]byte(branch + message))
+	for _, file := range files {
+		if file.Path == nil {
+			continue
+		}
+
+		path, err := securejoin.SecureJoin(c.dir, *file.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve path %q: %w", *file.Path, err)
+		}
+
+		// a file without content marks a deletion
+		if file.Content == nil {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("failed to remove file %q: %w", path, err)
+			}
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create directory for %q: %w", path, err)
+		}
+
+		if err := os.WriteFile(path, []byte(*file.Content), 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write file %q: %w", path, err)
+		}
+
+		h.Write([]byte(*file.Path + *file.Content))
+	}
+
+	return &localCommit{
+		info: gitprovider.CommitInfo{
+			Sha:     hex.EncodeToString(h.Sum(nil)),
+			Message: message,
+		},
+	}, nil
+}
+
+// localCommit is a commit made without a git provider API.
+type localCommit struct {
+	gitprovider.Commit
+
+	info gitprovider.CommitInfo
+}
+
+var _ gitprovider.Commit = &localCommit{}
+
+func (c *localCommit) Get() gitprovider.CommitInfo {
+	return c.info
+}
+
+func newCommitFile(path, content string) gitprovider.CommitFile {
+	return gitprovider.CommitFile{
+		Path:    &path,
+		Content: &content,
+	}
+}
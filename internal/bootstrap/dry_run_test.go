@@ -0,0 +1,95 @@
+package bootstrap
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestDryRunRepository(t *testing.T) {
+	dir := t.TempDir()
+	repo := newDryRunRepository(dir)
+
+	commit, err := repo.Commits().Create(context.Background(), "main", "Add manifests", []gitprovider.CommitFile{
+		newCommitFile("target/ocm-system/ocm-controller.yaml", "kind: Deployment\n"),
+		newCommitFile("../../escape.yaml", "kind: Secret\n"),
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, commit.Get().Sha)
+
+	content, err := os.ReadFile(filepath.Join(dir, "target", "ocm-system", "ocm-controller.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "kind: Deployment\n", string(content))
+
+	// paths are confined to the output directory
+	_, err = os.Stat(filepath.Join(dir, "escape.yaml"))
+	require.NoError(t, err)
+
+	again, err := repo.Commits().Create(context.Background(), "main", "Add manifests", []gitprovider.CommitFile{
+		newCommitFile("target/ocm-system/ocm-controller.yaml", "kind: Deployment\n"),
+		newCommitFile("../../escape.yaml", "kind: Secret\n"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, commit.Get().Sha, again.Get().Sha, "expected the sha to be reproducible")
+
+	_, err = repo.Commits().Create(context.Background(), "main", "Remove manifests", []gitprovider.CommitFile{
+		{Path: ptr.To("target/ocm-system/ocm-controller.yaml")},
+	})
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "target", "ocm-system", "ocm-controller.yaml"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestCertificateManifestsDryRun(t *testing.T) {
+	dir := t.TempDir()
+	installer := newCertificateManifestInstaller(&certificateManifestOptions{
+		gitRepository: newDryRunRepository(dir),
+		branch:        "main",
+		targetPath:    "target",
+		dryRun:        true,
+	})
+
+	_, err := installer.Install(context.Background(), []string{"ocm-system", "mpas-system"}, true)
+	require.NoError(t, err)
+
+	for _, path := range []string{
+		"target/mpas-system/mpas_certificate.yaml",
+		"target/ocm-system/ocm_certificate.yaml",
+		"target/cert-manager/cluster_issuer.yaml",
+	} {
+		_, err := os.Stat(filepath.Join(dir, path))
+		assert.NoError(t, err, "expected %s to be rendered", path)
+	}
+}
+
+func TestCertificateManifestsOfNamespaces(t *testing.T) {
+	dir := t.TempDir()
+	installer := newCertificateManifestInstaller(&certificateManifestOptions{
+		gitRepository: newDryRunRepository(dir),
+		branch:        "main",
+		targetPath:    "target",
+		dryRun:        true,
+	})
+
+	_, err := installer.Install(context.Background(), []string{"ocm-system"}, false)
+	require.NoError(t, err)
+
+	_, err = os.Stat(filepath.Join(dir, "target/ocm-system/ocm_certificate.yaml"))
+	assert.NoError(t, err)
+	for _, path := range []string{
+		"target/mpas-system/mpas_certificate.yaml",
+		"target/cert-manager/cluster_issuer.yaml",
+	} {
+		_, err := os.Stat(filepath.Join(dir, path))
+		assert.True(t, os.IsNotExist(err), "expected %s not to be rendered", path)
+	}
+
+	_, err = installer.Install(context.Background(), []string{"kube-system"}, false)
+	assert.ErrorContains(t, err, "no certificate for namespace kube-system")
+}
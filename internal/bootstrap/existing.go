@@ -0,0 +1,185 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	"github.com/open-component-model/mpas/internal/env"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// WithExistingFlux sets whether to adopt the flux installed in the cluster instead of installing it.
+// Flux is configured to sync the management repository, it is neither upgraded nor uninstalled by mpas.
+func WithExistingFlux(existing bool) Option {
+	return func(o *options) {
+		o.existingFlux = existing
+	}
+}
+
+// WithExistingCertManager sets whether to adopt the cert-manager installed in the cluster instead of installing it.
+// It is neither upgraded nor uninstalled by mpas.
+func WithExistingCertManager(existing bool) Option {
+	return func(o *options) {
+		o.existingCertManager = existing
+	}
+}
+
+// adopted returns true if the given component is already installed in the cluster and adopted as is.
+func (o *options) adopted(component string) bool {
+	switch component {
+	case env.FluxName:
+		return o.existingFlux
+	case env.CertManagerName:
+		return o.existingCertManager
+	default:
+		return false
+	}
+}
+
+// syncName returns the name of the GitRepository and Kustomization syncing the management repository.
+// Flux names its sync objects after its namespace, the bootstrap follows it unless it configured an existing flux
+// with its own sync objects.
+func (b *Bootstrap) syncName() string {
+	if b.state != nil && b.state.SyncName != "" {
+		return b.state.SyncName
+	}
+
+	return b.namespace(env.DefaultFluxNamespace)
+}
+
+// adoptFlux configures the existing flux to sync the target path of the management repository.
+// If the sync objects of flux already sync it, they are reused as they are. Otherwise a GitRepository and
+// a Kustomization named after MPAS are committed next to them, following the layout of a flux bootstrap.
+func (b *Bootstrap) adoptFlux(ctx context.Context) error {
+	if !b.dryRun {
+		reuse, err := b.fluxSyncsTarget(ctx)
+		if err != nil {
+			return err
+		}
+
+		if reuse {
+			b.state.SyncName = b.namespace(env.DefaultFluxNamespace)
+			return nil
+		}
+	}
+	b.state.SyncName = env.ExistingFluxSyncName
+
+	dir, err := mkdirTempDir("flux-sync")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	opts, err := b.fluxOptions(dir)
+	if err != nil {
+		return err
+	}
+	opts.existing = true
+
+	inst, err := newFluxInstall("", "", nil, opts)
+	if err != nil {
+		return err
+	}
+
+	if b.dryRun {
+		files, err := inst.Render(env.FluxName)
+		if err != nil {
+			return err
+		}
+
+		if _, err := b.repository.Commits().Create(ctx, b.defaultBranch, "Add Flux sync manifests", files); err != nil {
+			return fmt.Errorf("failed to write flux sync manifests: %w", err)
+		}
+
+		return nil
+	}
+
+	return inst.Install(ctx, env.FluxName)
+}
+
+// fluxSyncsTarget returns true if the sync objects of the existing flux already sync the target path
+// of the management repository.
+func (b *Bootstrap) fluxSyncsTarget(ctx context.Context) (bool, error) {
+	key := client.ObjectKey{Name: b.namespace(env.DefaultFluxNamespace), Namespace: b.namespace(env.DefaultFluxNamespace)}
+
+	var repo sourcev1.GitRepository
+	if err := b.kubeclient.Get(ctx, key, &repo); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+
+	var ks kustomizev1.Kustomization
+	if err := b.kubeclient.Get(ctx, key, &ks); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+
+	if ks.Spec.SourceRef.Kind != sourcev1.GitRepositoryKind || ks.Spec.SourceRef.Name != repo.Name {
+		return false, nil
+	}
+
+	if cleanSyncPath(ks.Spec.Path) != cleanSyncPath(b.targetPath) {
+		return false, nil
+	}
+
+	for _, url := range []string{b.url, b.sshURL, b.testURL} {
+		if url != "" && normalizeRepositoryURL(url) == normalizeRepositoryURL(repo.Spec.URL) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// cleanSyncPath returns the given path of the management repository as it is compared to the path of a Kustomization.
+func cleanSyncPath(path string) string {
+	return strings.TrimPrefix(filepath.ToSlash(filepath.Clean("./"+path)), "./")
+}
+
+// normalizeRepositoryURL strips the parts of a repository URL that do not change the repository it points to.
+func normalizeRepositoryURL(url string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(url, "/"), ".git")
+}
+
+// removeFluxSync removes the sync objects the bootstrap created in an existing flux, and the bootstrap state.
+// The Kustomization stops pruning before it is deleted so that the workloads it applied, like a kept cert-manager,
+// are not garbage collected. Sync objects of flux that were reused are left untouched.
+func (b *Bootstrap) removeFluxSync(ctx context.Context) error {
+	fluxNamespace := b.namespace(env.DefaultFluxNamespace)
+	if b.syncName() != fluxNamespace {
+		key := client.ObjectKey{Name: b.syncName(), Namespace: fluxNamespace}
+
+		var ks kustomizev1.Kustomization
+		if err := b.kubeclient.Get(ctx, key, &ks); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to get kustomization %s: %w", key, err)
+		} else if err == nil {
+			patch := client.MergeFrom(ks.DeepCopy())
+			ks.Spec.Prune = false
+			if err := b.kubeclient.Patch(ctx, &ks, patch); err != nil {
+				return fmt.Errorf("failed to disable pruning of kustomization %s: %w", key, err)
+			}
+		}
+
+		meta := metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace}
+		for _, obj := range []client.Object{
+			&kustomizev1.Kustomization{ObjectMeta: meta},
+			&sourcev1.GitRepository{ObjectMeta: meta},
+			&corev1.Secret{ObjectMeta: meta},
+		} {
+			if err := b.kubeclient.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("failed to delete %s: %w", key, err)
+			}
+		}
+	}
+
+	return deleteState(ctx, b.kubeclient, fluxNamespace)
+}
@@ -0,0 +1,157 @@
+package bootstrap
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	"github.com/open-component-model/mpas/internal/env"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestAdoptedComponents(t *testing.T) {
+	components := []string{env.OcmControllerName, env.FluxName, env.CertManagerName}
+	b := &Bootstrap{
+		options: options{
+			components:   components,
+			existingFlux: true,
+		},
+	}
+
+	setDefaults(b)
+	assert.Equal(t, []string{env.OcmControllerName, env.CertManagerName}, b.components)
+	assert.Equal(t, []string{env.OcmControllerName, env.FluxName, env.CertManagerName}, components,
+		"expected the given components to be left untouched")
+}
+
+func TestFluxSyncsTarget(t *testing.T) {
+	testCases := []struct {
+		name string
+		url  string
+		path string
+		want bool
+	}{
+		{
+			name: "same repository and path",
+			url:  "https://github.com/owner/management.git",
+			path: "./clusters/edge-1",
+			want: true,
+		},
+		{
+			name: "same repository without suffix",
+			url:  "https://github.com/owner/management",
+			path: "clusters/edge-1/",
+			want: true,
+		},
+		{
+			name: "other path",
+			url:  "https://github.com/owner/management.git",
+			path: "./clusters/edge-2",
+		},
+		{
+			name: "other repository",
+			url:  "https://github.com/owner/fleet.git",
+			path: "./clusters/edge-1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			meta := metav1.ObjectMeta{Name: env.DefaultFluxNamespace, Namespace: env.DefaultFluxNamespace}
+			b := &Bootstrap{
+				url: "https://github.com/owner/management.git",
+				options: options{
+					targetPath: "clusters/edge-1",
+					kubeclient: newPreflightClient(t,
+						&sourcev1.GitRepository{ObjectMeta: meta, Spec: sourcev1.GitRepositorySpec{URL: tc.url}},
+						&kustomizev1.Kustomization{ObjectMeta: meta, Spec: kustomizev1.KustomizationSpec{
+							Path: tc.path,
+							SourceRef: kustomizev1.CrossNamespaceSourceReference{
+								Kind: sourcev1.GitRepositoryKind,
+								Name: env.DefaultFluxNamespace,
+							},
+						}},
+					),
+				},
+			}
+
+			reuse, err := b.fluxSyncsTarget(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, reuse)
+		})
+	}
+
+	b := &Bootstrap{options: options{targetPath: ".", kubeclient: newPreflightClient(t)}}
+	reuse, err := b.fluxSyncsTarget(context.Background())
+	require.NoError(t, err)
+	assert.False(t, reuse, "expected no sync objects to be reused if flux was not bootstrapped")
+}
+
+func TestAdoptFluxDryRun(t *testing.T) {
+	dir := t.TempDir()
+	b := &Bootstrap{
+		repository: newDryRunRepository(dir),
+		url:        "https://github.com/owner/management.git",
+		state:      newBootstrapState(),
+		options: options{
+			owner:         "owner",
+			targetPath:    "clusters/edge-1",
+			defaultBranch: "main",
+			dryRun:        true,
+			existingFlux:  true,
+		},
+	}
+
+	require.NoError(t, b.adoptFlux(context.Background()))
+	assert.Equal(t, env.ExistingFluxSyncName, b.syncName())
+
+	fluxDir := filepath.Join(dir, "clusters", "edge-1", env.DefaultFluxNamespace)
+	sync, err := os.ReadFile(filepath.Join(fluxDir, "gotk-sync.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(sync), "name: mpas\n")
+	assert.Contains(t, string(sync), "path: ./clusters/edge-1")
+
+	_, err = os.Stat(filepath.Join(fluxDir, "gotk-components.yaml"))
+	assert.True(t, os.IsNotExist(err), "expected the components of the existing flux not to be rendered")
+}
+
+func TestRemoveFluxSync(t *testing.T) {
+	meta := metav1.ObjectMeta{Name: env.ExistingFluxSyncName, Namespace: env.DefaultFluxNamespace}
+	kubeClient := newPreflightClient(t,
+		&kustomizev1.Kustomization{ObjectMeta: meta, Spec: kustomizev1.KustomizationSpec{Prune: true}},
+		&sourcev1.GitRepository{ObjectMeta: meta},
+		&corev1.Secret{ObjectMeta: meta},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: stateConfigMapName, Namespace: env.DefaultFluxNamespace}},
+	)
+
+	b := &Bootstrap{
+		state:   &bootstrapState{ExistingFlux: true, SyncName: env.ExistingFluxSyncName},
+		options: options{kubeclient: kubeClient},
+	}
+	require.NoError(t, b.removeFluxSync(context.Background()))
+
+	key := client.ObjectKey{Name: env.ExistingFluxSyncName, Namespace: env.DefaultFluxNamespace}
+	for _, obj := range []client.Object{&kustomizev1.Kustomization{}, &sourcev1.GitRepository{}, &corev1.Secret{}} {
+		assert.True(t, apierrors.IsNotFound(kubeClient.Get(context.Background(), key, obj)), "expected %T to be deleted", obj)
+	}
+	err := kubeClient.Get(context.Background(), client.ObjectKey{Name: stateConfigMapName, Namespace: env.DefaultFluxNamespace}, &corev1.ConfigMap{})
+	assert.True(t, apierrors.IsNotFound(err), "expected the bootstrap state to be deleted")
+
+	// the sync objects of flux that were reused are kept
+	meta.Name = env.DefaultFluxNamespace
+	kubeClient = newPreflightClient(t, &sourcev1.GitRepository{ObjectMeta: meta})
+	b = &Bootstrap{
+		state:   &bootstrapState{ExistingFlux: true, SyncName: env.DefaultFluxNamespace},
+		options: options{kubeclient: kubeClient},
+	}
+	require.NoError(t, b.removeFluxSync(context.Background()))
+	assert.NoError(t, kubeClient.Get(context.Background(), client.ObjectKeyFromObject(&sourcev1.GitRepository{ObjectMeta: meta}), &sourcev1.GitRepository{}))
+}
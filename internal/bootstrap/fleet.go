@@ -0,0 +1,254 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/open-component-model/mpas/internal/env"
+	"github.com/open-component-model/mpas/internal/printer"
+	kustypes "sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/yaml"
+)
+
+// WithFleetBase sets the path of the management repository the component manifests are committed to,
+// shared by the clusters of a fleet. The target path of each cluster holds a kustomization per component
+// including its manifest from the base.
+func WithFleetBase(base string) Option {
+	return func(o *options) {
+		o.fleetBase = strings.TrimSuffix(base, "/")
+	}
+}
+
+// Cluster is a cluster of a fleet, it is bootstrapped with the given kube context to the given path
+// of the management repository.
+type Cluster struct {
+	Name    string `json:"name"`
+	Context string `json:"context"`
+	Path    string `json:"path"`
+}
+
+// fleetFile is a file listing the clusters of a fleet.
+type fleetFile struct {
+	// Base is the path of the component manifests shared by the clusters.
+	Base     string    `json:"base,omitempty"`
+	Clusters []Cluster `json:"clusters"`
+}
+
+// ParseCluster parses a cluster of the form name=context:path. The path follows the last colon,
+// as the context may hold colons.
+func ParseCluster(s string) (Cluster, error) {
+	name, rest, found := strings.Cut(s, "=")
+	if !found {
+		return Cluster{}, fmt.Errorf("invalid cluster %q, expected name=context:path", s)
+	}
+
+	i := strings.LastIndex(rest, ":")
+	if i < 0 {
+		return Cluster{}, fmt.Errorf("invalid cluster %q, expected name=context:path", s)
+	}
+
+	return Cluster{Name: name, Context: rest[:i], Path: rest[i+1:]}, nil
+}
+
+// LoadFleetFile reads the clusters of a fleet, and the path of their shared base if set, from the given file.
+func LoadFleetFile(file string) ([]Cluster, string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read fleet file: %w", err)
+	}
+
+	var fleet fleetFile
+	if err := yaml.UnmarshalStrict(data, &fleet); err != nil {
+		return nil, "", fmt.Errorf("failed to decode fleet file: %w", err)
+	}
+
+	return fleet.Clusters, fleet.Base, nil
+}
+
+// Fleet bootstraps several clusters into one management repository. The bootstrap component is resolved once,
+// the clusters share the manifests of the components and each gets its own flux installation.
+type Fleet struct {
+	clusters []Cluster
+	base     string
+	printer  *printer.Printer
+	// newBootstrap returns the bootstrap of the given cluster with the given options added
+	newBootstrap func(cluster Cluster, opts ...Option) (*Bootstrap, error)
+}
+
+// clusterResult is the result of the bootstrap of a cluster of a fleet.
+type clusterResult struct {
+	cluster Cluster
+	err     error
+}
+
+// NewFleet returns a new Fleet bootstrapping the given clusters, with their manifests shared at the given base path.
+// The bootstraps are created with newBootstrap, which targets the kube context of the cluster.
+func NewFleet(clusters []Cluster, base string, p *printer.Printer, newBootstrap func(cluster Cluster, opts ...Option) (*Bootstrap, error)) (*Fleet, error) {
+	if base == "" {
+		base = env.DefaultFleetBase
+	}
+
+	f := &Fleet{
+		clusters:     clusters,
+		base:         filepath.Clean(base),
+		printer:      p,
+		newBootstrap: newBootstrap,
+	}
+
+	if err := f.validate(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// validate fails if the clusters are not uniquely named, or if the paths of the clusters and the base overlap,
+// as flux would apply the manifests of another cluster.
+func (f *Fleet) validate() error {
+	if len(f.clusters) == 0 {
+		return fmt.Errorf("a fleet must have at least one cluster")
+	}
+
+	names := make(map[string]bool, len(f.clusters))
+	paths := []string{f.base}
+	for _, cluster := range f.clusters {
+		if cluster.Name == "" || cluster.Context == "" || cluster.Path == "" {
+			return fmt.Errorf("the name, context and path of a cluster must be set, got %+v", cluster)
+		}
+		if names[cluster.Name] {
+			return fmt.Errorf("cluster %s is listed twice", cluster.Name)
+		}
+		names[cluster.Name] = true
+
+		p := filepath.Clean(cluster.Path)
+		for _, other := range paths {
+			if isWithinPath(p, other) || isWithinPath(other, p) {
+				return fmt.Errorf("the path %s of cluster %s overlaps with %s", cluster.Path, cluster.Name, other)
+			}
+		}
+		paths = append(paths, p)
+	}
+
+	return nil
+}
+
+// isWithinPath returns true if p is the given directory or one of its descendants.
+func isWithinPath(p, dir string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
+// Run bootstraps the clusters one after the other. The version of the bootstrap component resolved for the first
+// cluster is installed on all of them. A failed cluster does not stop the bootstrap of the next ones,
+// the result of each cluster is printed at the end.
+func (f *Fleet) Run(ctx context.Context) error {
+	var (
+		version  string
+		fromFile = true
+		results  []clusterResult
+	)
+	for _, cluster := range f.clusters {
+		f.printer.Printf("\nBootstrapping cluster %s with context %s to %s\n", printer.BoldBlue(cluster.Name),
+			printer.BoldBlue(cluster.Context), printer.BoldBlue(cluster.Path))
+
+		opts := []Option{WithTarget(filepath.Clean(cluster.Path)), WithFleetBase(f.base)}
+		if version != "" {
+			opts = append(opts, WithBootstrapVersion(version))
+		}
+		// once transferred, the bootstrap component is fetched from the registry
+		if !fromFile {
+			opts = append(opts, WithFromFile(""))
+		}
+
+		b, err := f.newBootstrap(cluster, opts...)
+		if err == nil {
+			if b.reportFile != "" {
+				b.reportFile = clusterReportFile(b.reportFile, cluster.Name)
+			}
+
+			err = b.Run(ctx)
+			if b.state != nil && b.state.Version != "" && version == "" {
+				version = b.state.Version
+				fromFile = b.dryRun
+			}
+		}
+
+		results = append(results, clusterResult{cluster: cluster, err: err})
+	}
+
+	return f.summarize(results)
+}
+
+// summarize prints the result of each cluster and returns the errors of the failed ones.
+func (f *Fleet) summarize(results []clusterResult) error {
+	f.printer.Printf("\nFleet bootstrap results:\n")
+
+	var errs []error
+	for _, r := range results {
+		if r.err != nil {
+			f.printer.Printf("  %s (%s): failed: %v\n", r.cluster.Name, r.cluster.Path, r.err)
+			errs = append(errs, fmt.Errorf("cluster %s: %w", r.cluster.Name, r.err))
+			continue
+		}
+		f.printer.Printf("  %s (%s): %s\n", r.cluster.Name, r.cluster.Path, printer.BoldBlue("ready"))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to bootstrap %d of %d clusters: %w", len(errs), len(results), errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// clusterReportFile returns the report file of the given cluster, the name of the cluster is appended
+// to the name of the report file, e.g. report-edge-1.json.
+func clusterReportFile(reportFile, cluster string) string {
+	ext := filepath.Ext(reportFile)
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(reportFile, ext), cluster, ext)
+}
+
+// manifestsPath returns the path the manifests of the components are committed to,
+// the shared base of a fleet or the target path.
+func (b *Bootstrap) manifestsPath() string {
+	if b.fleetBase != "" {
+		return b.fleetBase
+	}
+
+	return b.targetPath
+}
+
+// fleetOverlay returns the kustomization including the manifest of the given component from the fleet base
+// in the target path, at <namespace>/<component>/kustomization.yaml, formatted for the given provider.
+// Flux applies the directory of a kustomization as a whole, the kustomization may patch the base manifest.
+func (b *Bootstrap) fleetOverlay(ns, componentName, provider string) (gitprovider.CommitFile, error) {
+	name := path.Base(componentName)
+	dir := filepath.Join(b.targetPath, ns, name)
+	resource, err := filepath.Rel(dir, filepath.Join(b.fleetBase, ns, fmt.Sprintf("%s.yaml", name)))
+	if err != nil {
+		return gitprovider.CommitFile{}, fmt.Errorf("failed to resolve the base manifest of %s: %w", name, err)
+	}
+
+	kus := kustypes.Kustomization{
+		TypeMeta: kustypes.TypeMeta{
+			APIVersion: kustypes.KustomizationVersion,
+			Kind:       kustypes.KustomizationKind,
+		},
+		Resources: []string{filepath.ToSlash(resource)},
+	}
+	data, err := yaml.Marshal(kus)
+	if err != nil {
+		return gitprovider.CommitFile{}, fmt.Errorf("failed to marshal kustomization of %s: %w", name, err)
+	}
+
+	return newCommitFile(filepath.Join(dir, "kustomization.yaml"), SetProviderDataFormat(provider, data)), nil
+}
@@ -0,0 +1,177 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-component-model/mpas/internal/env"
+	"github.com/open-component-model/mpas/internal/printer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kustypes "sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/yaml"
+)
+
+func TestParseCluster(t *testing.T) {
+	cluster, err := ParseCluster("edge-1=kind-edge-1:clusters/edge-1")
+	require.NoError(t, err)
+	assert.Equal(t, Cluster{Name: "edge-1", Context: "kind-edge-1", Path: "clusters/edge-1"}, cluster)
+
+	cluster, err = ParseCluster("prod=arn:aws:eks:eu-west-1:123456789012:cluster/prod:clusters/prod")
+	require.NoError(t, err)
+	assert.Equal(t, Cluster{Name: "prod", Context: "arn:aws:eks:eu-west-1:123456789012:cluster/prod", Path: "clusters/prod"}, cluster)
+
+	_, err = ParseCluster("edge-1:clusters/edge-1")
+	assert.ErrorContains(t, err, "expected name=context:path")
+	_, err = ParseCluster("edge-1=kind-edge-1")
+	assert.ErrorContains(t, err, "expected name=context:path")
+}
+
+func TestLoadFleetFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "fleet.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`base: shared/base
+clusters:
+- name: edge-1
+  context: kind-edge-1
+  path: clusters/edge-1
+- name: edge-2
+  context: kind-edge-2
+  path: clusters/edge-2
+`), 0o644))
+
+	clusters, base, err := LoadFleetFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "shared/base", base)
+	assert.Equal(t, []Cluster{
+		{Name: "edge-1", Context: "kind-edge-1", Path: "clusters/edge-1"},
+		{Name: "edge-2", Context: "kind-edge-2", Path: "clusters/edge-2"},
+	}, clusters)
+
+	require.NoError(t, os.WriteFile(file, []byte("clusters:\n- name: edge-1\n  kubeContext: kind-edge-1\n"), 0o644))
+	_, _, err = LoadFleetFile(file)
+	assert.ErrorContains(t, err, "failed to decode fleet file")
+}
+
+func TestNewFleet(t *testing.T) {
+	edge1 := Cluster{Name: "edge-1", Context: "kind-edge-1", Path: "clusters/edge-1"}
+	edge2 := Cluster{Name: "edge-2", Context: "kind-edge-2", Path: "clusters/edge-2"}
+
+	testCases := []struct {
+		name     string
+		clusters []Cluster
+		base     string
+		err      string
+	}{
+		{
+			name:     "distinct paths",
+			clusters: []Cluster{edge1, edge2},
+		},
+		{
+			name: "no cluster",
+			err:  "a fleet must have at least one cluster",
+		},
+		{
+			name:     "missing context",
+			clusters: []Cluster{{Name: "edge-1", Path: "clusters/edge-1"}},
+			err:      "the name, context and path of a cluster must be set",
+		},
+		{
+			name:     "duplicate name",
+			clusters: []Cluster{edge1, {Name: "edge-1", Context: "kind-edge-2", Path: "clusters/edge-2"}},
+			err:      "cluster edge-1 is listed twice",
+		},
+		{
+			name:     "nested paths",
+			clusters: []Cluster{edge1, {Name: "edge-2", Context: "kind-edge-2", Path: "clusters/edge-1/edge-2"}},
+			err:      "the path clusters/edge-1/edge-2 of cluster edge-2 overlaps with clusters/edge-1",
+		},
+		{
+			name:     "path of the base",
+			clusters: []Cluster{edge1},
+			base:     "clusters",
+			err:      "the path clusters/edge-1 of cluster edge-1 overlaps with clusters",
+		},
+		{
+			name:     "root path",
+			clusters: []Cluster{{Name: "edge-1", Context: "kind-edge-1", Path: "."}},
+			err:      "the path . of cluster edge-1 overlaps with fleet/base",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := NewFleet(tc.clusters, tc.base, nil, nil)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, env.DefaultFleetBase, f.base)
+		})
+	}
+}
+
+func TestFleetRun(t *testing.T) {
+	p, err := printer.Newprinter(io.Discard)
+	require.NoError(t, err)
+
+	clusters := []Cluster{
+		{Name: "edge-1", Context: "kind-edge-1", Path: "clusters/edge-1"},
+		{Name: "edge-2", Context: "kind-edge-2", Path: "clusters/edge-2"},
+	}
+
+	var bootstrapped []string
+	f, err := NewFleet(clusters, "", p, func(cluster Cluster, opts ...Option) (*Bootstrap, error) {
+		o := &options{}
+		for _, opt := range opts {
+			opt(o)
+		}
+		bootstrapped = append(bootstrapped, o.targetPath)
+		assert.Equal(t, env.DefaultFleetBase, o.fleetBase)
+		return nil, errors.New("unreachable")
+	})
+	require.NoError(t, err)
+
+	err = f.Run(context.Background())
+	assert.ErrorContains(t, err, "failed to bootstrap 2 of 2 clusters")
+	assert.ErrorContains(t, err, "cluster edge-2: unreachable")
+	assert.Equal(t, []string{"clusters/edge-1", "clusters/edge-2"}, bootstrapped)
+}
+
+func TestClusterReportFile(t *testing.T) {
+	assert.Equal(t, "report-edge-1.json", clusterReportFile("report.json", "edge-1"))
+	assert.Equal(t, "out/report-edge-1", clusterReportFile("out/report", "edge-1"))
+}
+
+func TestInstallComponentInFleet(t *testing.T) {
+	dir := t.TempDir()
+	b := &Bootstrap{
+		repository: newDryRunRepository(dir),
+		options: options{
+			targetPath: "clusters/edge-1",
+			fleetBase:  "fleet/base",
+			dryRun:     true,
+		},
+	}
+
+	ref := newComponentReference(t, env.OcmControllerName, "v1.0.0", nil)
+	_, err := b.installComponent(context.Background(), ref, env.DefaultOCMNamespace, map[string][]string{}, []byte("kind: Deployment\n"))
+	require.NoError(t, err)
+
+	manifest, err := os.ReadFile(filepath.Join(dir, "fleet", "base", env.DefaultOCMNamespace, "ocm-controller.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "kind: Deployment\n", string(manifest))
+
+	data, err := os.ReadFile(filepath.Join(dir, "clusters", "edge-1", env.DefaultOCMNamespace, "ocm-controller", "kustomization.yaml"))
+	require.NoError(t, err)
+	var kus kustypes.Kustomization
+	require.NoError(t, yaml.Unmarshal(data, &kus))
+	assert.Equal(t, []string{"../../../../fleet/base/ocm-system/ocm-controller.yaml"}, kus.Resources)
+
+	_, err = os.Stat(filepath.Join(dir, "clusters", "edge-1", env.DefaultOCMNamespace, "ocm-controller.yaml"))
+	assert.True(t, os.IsNotExist(err), "expected the manifest to be shared in the base")
+}
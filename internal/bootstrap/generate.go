@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/open-component-model/mpas/internal/printer"
+	om "github.com/open-component-model/ocm/pkg/contexts/ocm"
+	"github.com/open-component-model/ocm/pkg/contexts/ocm/compdesc"
+	"golang.org/x/sync/errgroup"
+)
+
+// WithConcurrency sets the number of component manifests generated concurrently.
+func WithConcurrency(concurrency int) Option {
+	return func(o *options) {
+		o.concurrency = concurrency
+	}
+}
+
+// generateManifests generates the manifests of the given components concurrently, with at most
+// concurrency manifests generated at once. It returns the manifests by component name.
+func (b *Bootstrap) generateManifests(ctx context.Context, ociRepo om.Repository, refs map[string]compdesc.ComponentReference, comps []string) (map[string][]byte, error) {
+	manifests := make(map[string][]byte, len(comps))
+	if len(comps) == 0 {
+		return manifests, nil
+	}
+
+	msg := fmt.Sprintf("Generating %d component manifests", len(comps))
+	if err := b.inSpinner(msg, func() error {
+		var (
+			mu   sync.Mutex
+			done int
+		)
+
+		g, ctx := errgroup.WithContext(ctx)
+		g.SetLimit(b.concurrency)
+		for _, comp := range comps {
+			comp := comp
+			g.Go(func() error {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+
+				patches, err := b.componentPatches(comp)
+				if err != nil {
+					return err
+				}
+
+				manifest, err := generateManifest(ociRepo, comp, refs[comp], b.namespaces, patches)
+				if err != nil {
+					return fmt.Errorf("failed to generate %s manifest: %w", comp, err)
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				manifests[comp] = manifest
+				done++
+				b.printer.UpdateSpinner(fmt.Sprintf("%s (%d/%d, %s done)", msg, done, len(comps), printer.BoldBlue(comp)))
+
+				return nil
+			})
+		}
+
+		return g.Wait()
+	}); err != nil {
+		return nil, err
+	}
+
+	return manifests, nil
+}
+
+// componentsToGenerate returns the components whose manifests have to be generated. When resuming,
+// the leading components completed by a previous run are skipped, as their phases are skipped.
+func (b *Bootstrap) componentsToGenerate(comps []string, refs map[string]compdesc.ComponentReference) []string {
+	if !b.resuming {
+		return comps
+	}
+
+	for i, comp := range comps {
+		ref := refs[comp]
+		if _, ok := b.state.completed(comp, ref.GetVersion()); !ok {
+			return comps[i:]
+		}
+	}
+
+	return nil
+}
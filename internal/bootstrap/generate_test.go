@@ -0,0 +1,149 @@
+package bootstrap
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/open-component-model/mpas/internal/env"
+	"github.com/open-component-model/mpas/internal/printer"
+	"github.com/open-component-model/ocm-controller/pkg/fakes"
+	"github.com/open-component-model/ocm/pkg/contexts/ocm/compdesc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFakeControllerComponent(name string) *mockComponentAccess {
+	componentName := "ocm.software/mpas/" + name
+	return &mockComponentAccess{
+		versions: []string{"v1.0.0"},
+		name:     componentName,
+		cva: map[string]*fakes.Component{
+			"v1.0.0": {
+				Name:    componentName,
+				Version: "v1.0.0",
+				Resources: []*fakes.Resource{
+					{
+						Name:    "ocm-config",
+						Version: "v1.0.0",
+						Data:    bytes.ReplaceAll(testConfigData, []byte("git-controller"), []byte(name)),
+						Kind:    "localBlob",
+						Type:    "ociBlob",
+					},
+					{
+						Name:    name + "-file",
+						Version: "v1.0.0",
+						Data:    bytes.ReplaceAll(bytes.ReplaceAll(testComponentData, []byte("git-controller"), []byte(name)), []byte("ghcr.io/user"), []byte(env.DefaultOCMHost)),
+						Kind:    "localBlob",
+						Type:    "ociBlob",
+					},
+					{
+						Name:     name,
+						Version:  "v1.0.0",
+						Kind:     "ociArtifact",
+						Type:     "ociImage",
+						Relation: "external",
+						AccessOptions: []fakes.AccessOptionFunc{
+							func(m map[string]any) {
+								m["imageReference"] = "ghcr.io/new-user/" + name + ":v1.0.0"
+							},
+							func(m map[string]any) {
+								m["type"] = "ociArtifact"
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestGenerateManifests(t *testing.T) {
+	p, err := printer.Newprinter(io.Discard)
+	require.NoError(t, err)
+
+	repo := &mockRepository{
+		cv: []*mockComponentAccess{
+			newFakeControllerComponent(env.GitControllerName),
+			newFakeControllerComponent(env.OcmControllerName),
+			newFakeControllerComponent(env.ReplicationControllerName),
+		},
+	}
+	refs := map[string]compdesc.ComponentReference{
+		env.GitControllerName:         newComponentReference(t, env.GitControllerName, "v1.0.0", nil),
+		env.OcmControllerName:         newComponentReference(t, env.OcmControllerName, "v1.0.0", nil),
+		env.ReplicationControllerName: newComponentReference(t, env.ReplicationControllerName, "v1.0.0", nil),
+	}
+
+	b := &Bootstrap{
+		options: options{
+			printer:     p,
+			concurrency: 2,
+		},
+	}
+
+	manifests, err := b.generateManifests(context.Background(), repo, refs, getOrderedKeys(refs))
+	require.NoError(t, err)
+	require.Len(t, manifests, 3)
+	for name, manifest := range manifests {
+		assert.Contains(t, string(manifest), "ghcr.io/new-user/"+name+":v1.0.0")
+	}
+
+	refs[env.MpasProductControllerName] = newComponentReference(t, env.MpasProductControllerName, "v1.0.0", nil)
+	_, err = b.generateManifests(context.Background(), repo, refs, getOrderedKeys(refs))
+	assert.ErrorContains(t, err, "failed to generate "+env.MpasProductControllerName+" manifest")
+}
+
+func TestComponentsToGenerate(t *testing.T) {
+	refs := map[string]compdesc.ComponentReference{
+		env.GitControllerName:         newComponentReference(t, env.GitControllerName, "v1.0.0", nil),
+		env.OcmControllerName:         newComponentReference(t, env.OcmControllerName, "v1.0.0", nil),
+		env.ReplicationControllerName: newComponentReference(t, env.ReplicationControllerName, "v1.0.0", nil),
+	}
+	comps := getOrderedKeys(refs)
+
+	testCases := []struct {
+		name      string
+		resuming  bool
+		completed map[string]string
+		expected  []string
+	}{
+		{
+			name:     "not resuming",
+			expected: comps,
+		},
+		{
+			name:      "resuming skips the leading completed components",
+			resuming:  true,
+			completed: map[string]string{comps[0]: "v1.0.0", comps[2]: "v1.0.0"},
+			expected:  comps[1:],
+		},
+		{
+			name:      "resuming regenerates a component completed with another version",
+			resuming:  true,
+			completed: map[string]string{comps[0]: "v0.9.0"},
+			expected:  comps,
+		},
+		{
+			name:      "resuming with all components completed",
+			resuming:  true,
+			completed: map[string]string{comps[0]: "v1.0.0", comps[1]: "v1.0.0", comps[2]: "v1.0.0"},
+			expected:  nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := &Bootstrap{
+				state:    newBootstrapState(),
+				resuming: tc.resuming,
+			}
+			for name, version := range tc.completed {
+				b.state.complete(name, version, "sha")
+			}
+
+			assert.Equal(t, tc.expected, b.componentsToGenerate(comps, refs))
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/Masterminds/semver/v3"
 	"github.com/containers/image/v5/pkg/compression"
 	"github.com/open-component-model/ocm/pkg/contexts/ocm"
+	"github.com/open-component-model/ocm/pkg/contexts/ocm/accessmethods/localblob"
 	"github.com/open-component-model/ocm/pkg/contexts/ocm/accessmethods/ociartifact"
 )
 
@@ -58,8 +59,9 @@ type resources struct {
 }
 
 type nameTag struct {
-	Name string
-	Tag  string
+	Name   string
+	Tag    string
+	Digest string
 }
 
 func getResources(cv ocm.ComponentVersionAccess, componentName string) (resources, error) {
@@ -85,17 +87,11 @@ func getResources(cv ocm.ComponentVersionAccess, componentName string) (resource
 			}
 		default:
 			if resource.Meta().GetType() == "ociImage" {
-				name, version, err := getResourceRef(resource)
+				image, err := getResourceRef(resource)
 				if err != nil {
 					return resources{}, fmt.Errorf("failed to get resource reference: %w", err)
 				}
-				imagesResources[resource.Meta().GetName()] = struct {
-					Name string
-					Tag  string
-				}{
-					Name: name,
-					Tag:  version,
-				}
+				imagesResources[resource.Meta().GetName()] = image
 				comps = append(comps, resource.Meta().GetName())
 			}
 		}
@@ -129,22 +125,44 @@ func getResourceContent(resource ocm.ResourceAccess) ([]byte, error) {
 	return io.ReadAll(decompressedReader)
 }
 
-func getResourceRef(resource ocm.ResourceAccess) (string, string, error) {
+// getResourceRef returns the image reference of the given image resource. An image copied by value, e.g. to an
+// image registry mirror, is a local blob whose global access refers to the copy of the image.
+func getResourceRef(resource ocm.ResourceAccess) (nameTag, error) {
 	a, err := resource.Access()
 	if err != nil {
-		return "", "", err
+		return nameTag{}, err
+	}
+	if local, ok := a.(*localblob.AccessSpec); ok && local.GlobalAccess != nil {
+		a, err = local.GlobalAccess.Evaluate(resource.ComponentVersion().GetContext())
+		if err != nil {
+			return nameTag{}, fmt.Errorf("failed to evaluate global access of %s: %w", resource.Meta().GetName(), err)
+		}
 	}
 	spec, ok := a.(*ociartifact.AccessSpec)
 	if !ok {
-		return "", "", fmt.Errorf("access spec was of type %+v; expected ociartifact", a)
+		return nameTag{}, fmt.Errorf("access spec was of type %+v; expected ociartifact", a)
+	}
+
+	return parseImageReference(spec.ImageReference)
+}
+
+// parseImageReference splits an image reference of the form name:tag, name@digest or name:tag@digest.
+// The name may hold a registry port, e.g. registry.local:5000/ocm-controller:v0.16.0.
+func parseImageReference(im string) (nameTag, error) {
+	var image nameTag
+	name := im
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, image.Digest = name[:i], name[i+1:]
+	}
+
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, image.Tag = name[:i], name[i+1:]
 	}
 
-	im := spec.ImageReference
-	split := strings.Split(im, ":")
-	if len(split) != 2 {
-		return "", "", fmt.Errorf("expected image format of domain:verion but was: %s", im)
+	if name == "" || (image.Tag == "" && image.Digest == "") {
+		return nameTag{}, fmt.Errorf("expected image format of name:tag or name@digest but was: %s", im)
 	}
+	image.Name = name
 
-	name, version := split[0], split[1]
-	return name, version, nil
+	return image, nil
 }
@@ -0,0 +1,115 @@
+package bootstrap
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/open-component-model/mpas/internal/ocm"
+	"github.com/open-component-model/ocm/pkg/common/accessio"
+	"github.com/open-component-model/ocm/pkg/contexts/datacontext"
+	om "github.com/open-component-model/ocm/pkg/contexts/ocm"
+	"github.com/open-component-model/ocm/pkg/contexts/ocm/accessmethods/ociartifact"
+	metav1 "github.com/open-component-model/ocm/pkg/contexts/ocm/compdesc/meta/v1"
+	"github.com/open-component-model/ocm/pkg/contexts/ocm/resourcetypes"
+	"github.com/open-component-model/ocm/pkg/mime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseImageReference(t *testing.T) {
+	testCases := []struct {
+		ref      string
+		expected nameTag
+		err      string
+	}{
+		{
+			ref:      "ghcr.io/open-component-model/ocm-controller:v0.16.0",
+			expected: nameTag{Name: "ghcr.io/open-component-model/ocm-controller", Tag: "v0.16.0"},
+		},
+		{
+			ref:      "registry.local:5000/mpas/open-component-model/ocm-controller:v0.16.0",
+			expected: nameTag{Name: "registry.local:5000/mpas/open-component-model/ocm-controller", Tag: "v0.16.0"},
+		},
+		{
+			ref:      "registry.local:5000/ocm-controller@sha256:0123456789abcdef",
+			expected: nameTag{Name: "registry.local:5000/ocm-controller", Digest: "sha256:0123456789abcdef"},
+		},
+		{
+			ref:      "ghcr.io/ocm-controller:v0.16.0@sha256:0123456789abcdef",
+			expected: nameTag{Name: "ghcr.io/ocm-controller", Tag: "v0.16.0", Digest: "sha256:0123456789abcdef"},
+		},
+		{
+			ref: "registry.local:5000/ocm-controller",
+			err: "expected image format of name:tag or name@digest but was: registry.local:5000/ocm-controller",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.ref, func(t *testing.T) {
+			image, err := parseImageReference(tc.ref)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, image)
+		})
+	}
+}
+
+func TestGetResourceRef(t *testing.T) {
+	octx := om.New(datacontext.MODE_SHARED)
+	ctf, err := ocm.CreateCTF(octx, filepath.Join(t.TempDir(), "ctf"), accessio.FormatDirectory)
+	require.NoError(t, err)
+	defer ctf.Close()
+
+	c, err := ctf.LookupComponent("ocm.software/mpas/ocm-controller")
+	require.NoError(t, err)
+	defer c.Close()
+	cv, err := c.NewVersion("v1.0.0")
+	require.NoError(t, err)
+	defer cv.Close()
+	cv.GetDescriptor().Provider.Name = "ocm"
+
+	// an image copied by value is a local blob whose global access refers to the copy
+	require.NoError(t, cv.SetResourceBlob(om.NewResourceMeta("local", resourcetypes.OCI_IMAGE, metav1.LocalRelation),
+		accessio.BlobAccessForString(mime.MIME_OCTET, "image"), "ocm-controller:v1.0.0",
+		ociartifact.New("registry.local:5000/mirror/ocm-controller:v1.0.0")))
+	require.NoError(t, cv.SetResource(om.NewResourceMeta("global", resourcetypes.OCI_IMAGE, metav1.ExternalRelation),
+		ociartifact.New("ghcr.io/open-component-model/ocm-controller:v1.0.0"), om.SkipDigest()))
+	require.NoError(t, cv.SetResourceBlob(om.NewResourceMeta("blob", resourcetypes.OCI_IMAGE, metav1.LocalRelation),
+		accessio.BlobAccessForString(mime.MIME_OCTET, "image"), "", nil))
+
+	testCases := []struct {
+		resource string
+		expected nameTag
+		err      string
+	}{
+		{
+			resource: "local",
+			expected: nameTag{Name: "registry.local:5000/mirror/ocm-controller", Tag: "v1.0.0"},
+		},
+		{
+			resource: "global",
+			expected: nameTag{Name: "ghcr.io/open-component-model/ocm-controller", Tag: "v1.0.0"},
+		},
+		{
+			resource: "blob",
+			err:      "expected ociartifact",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.resource, func(t *testing.T) {
+			resource, err := cv.GetResource(metav1.NewIdentity(tc.resource))
+			require.NoError(t, err)
+			ref, err := getResourceRef(resource)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, ref)
+		})
+	}
+}
@@ -8,13 +8,14 @@ import (
 	"context"
 	_ "embed"
 	"fmt"
+	"path"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
 	"github.com/open-component-model/mpas/internal/env"
 	"github.com/open-component-model/ocm/pkg/contexts/ocm"
+	kustypes "sigs.k8s.io/kustomize/api/types"
 )
 
 const (
@@ -29,9 +30,13 @@ type certManagerOptions struct {
 	branch                string
 	targetPath            string
 	namespace             string
+	namespaces            map[string]string
 	provider              string
 	timeout               time.Duration
 	commitMessageAppendix string
+	// patches are merged into the kustomization, patchFiles are committed along with the manifest
+	patches    []kustypes.Patch
+	patchFiles []gitprovider.CommitFile
 }
 
 // certManagerInstall is used to install cert-manager
@@ -56,7 +61,9 @@ func newCertManagerInstall(name, version string, repository ocm.Repository, opts
 			version:       version,
 			repository:    repository,
 			dir:           opts.dir,
+			namespaces:    opts.namespaces,
 			host:          env.DefaultCertManagerHost,
+			patches:       opts.patches,
 		}),
 	}
 
@@ -79,21 +86,19 @@ func (c *certManagerInstall) Install(ctx context.Context, component string) (str
 
 func (c *certManagerInstall) createCommit(ctx context.Context, content []byte) (string, error) {
 	data := SetProviderDataFormat(c.provider, content)
-	path := filepath.Join(c.targetPath, c.namespace, fmt.Sprintf("%s.yaml", strings.Split(c.componentName, "/")[2]))
+	path := filepath.Join(c.targetPath, c.namespace, fmt.Sprintf("%s.yaml", path.Base(c.componentName)))
 	commitMsg := fmt.Sprintf("Add %s %s manifests", c.componentName, c.version)
 	if c.commitMessageAppendix != "" {
 		commitMsg = commitMsg + "\n\n" + c.commitMessageAppendix
 	}
 
-	commit, err := c.gitRepository.Commits().Create(ctx,
-		c.branch,
-		commitMsg,
-		[]gitprovider.CommitFile{
+	commit, err := createCommit(ctx, c.gitRepository, c.provider, c.branch, commitMsg,
+		append([]gitprovider.CommitFile{
 			{
 				Path:    &path,
 				Content: &data,
 			},
-		})
+		}, c.patchFiles...))
 	if err != nil {
 		return "", fmt.Errorf("failed to create component: %w", err)
 	}
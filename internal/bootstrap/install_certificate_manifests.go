@@ -9,6 +9,7 @@ import (
 	_ "embed"
 	"fmt"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
@@ -28,6 +29,18 @@ var (
 	mpasCertificate []byte
 )
 
+// namespaceCertificate is the certificate manifest of a namespace.
+type namespaceCertificate struct {
+	file    string
+	content []byte
+}
+
+// namespaceCertificates are the certificate manifests by default namespace.
+var namespaceCertificates = map[string]namespaceCertificate{
+	env.DefaultOCMNamespace:  {file: "ocm_certificate.yaml", content: ocmCertificate},
+	env.DefaultMPASNamespace: {file: "mpas_certificate.yaml", content: mpasCertificate},
+}
+
 type certificateManifestOptions struct {
 	gitRepository         gitprovider.UserRepository
 	branch                string
@@ -36,6 +49,12 @@ type certificateManifestOptions struct {
 	timeout               time.Duration
 	commitMessageAppendix string
 	kubeClient            client.Client
+	// dryRun skips looking up the cluster issuer, it is always rendered
+	dryRun bool
+	// namespaces maps the default namespaces to the namespaces the components are installed to
+	namespaces map[string]string
+	// issuer is the issuer of the certificates
+	issuer certificateIssuer
 }
 
 // certManifestInstall is used to install cert-manager objects
@@ -50,36 +69,64 @@ func newCertificateManifestInstaller(opts *certificateManifestOptions) *certific
 	}
 }
 
-func (c *certificateManifestsInstall) Install(ctx context.Context) (string, error) {
-	mpasCertificatePath := filepath.Join(c.targetPath, "mpas-system", "mpas_certificate.yaml")
-	ocmCertificatePath := filepath.Join(c.targetPath, env.DefaultOCMNamespace, "ocm_certificate.yaml")
-	mpasCertificateData := SetProviderDataFormat(c.provider, mpasCertificate)
-	ocmCertificateData := SetProviderDataFormat(c.provider, ocmCertificate)
-	commitMsg := "Add cluster issuer and namespace certificates"
+// Install commits the certificates of the given default namespaces. The cluster issuer is committed with them
+// if issuer is set and it does not exist yet, unless the certificates are signed by an existing issuer.
+func (c *certificateManifestsInstall) Install(ctx context.Context, namespaces []string, issuer bool) (string, error) {
+	issuer = issuer && c.issuer.manifest() != nil
+
+	commitMsg := fmt.Sprintf("Add %s namespace certificates", strings.Join(namespaces, ", "))
+	if issuer {
+		commitMsg = "Add cluster issuer and namespace certificates"
+	}
 
 	if c.commitMessageAppendix != "" {
 		commitMsg = commitMsg + "\n\n" + c.commitMessageAppendix
 	}
 
-	files := []gitprovider.CommitFile{
-		{
-			Path:    &mpasCertificatePath,
-			Content: &mpasCertificateData,
-		},
-		{
-			Path:    &ocmCertificatePath,
-			Content: &ocmCertificateData,
-		},
+	var files []gitprovider.CommitFile
+	for _, ns := range namespaces {
+		cert, ok := namespaceCertificates[ns]
+		if !ok {
+			return "", fmt.Errorf("no certificate for namespace %s", ns)
+		}
+
+		path := filepath.Join(c.targetPath, mapNamespace(c.namespaces, ns), cert.file)
+		content, err := c.issuer.certificate(cert.content)
+		if err != nil {
+			return "", fmt.Errorf("failed to configure certificate manifest: %w", err)
+		}
+		data, err := c.manifest(content)
+		if err != nil {
+			return "", err
+		}
+
+		files = append(files, gitprovider.CommitFile{
+			Path:    &path,
+			Content: &data,
+		})
 	}
 
-	ok, err := c.addClusterIssuerIfAbsent(ctx)
-	if err != nil {
-		return "", fmt.Errorf("failed to check if cluster issuer exists: %w", err)
+	ok := issuer
+	if ok && !c.dryRun {
+		if c.issuer.hasCA() {
+			if err := c.reconcileCASecret(ctx, mapNamespace(c.namespaces, env.DefaultCertManagerNamespace)); err != nil {
+				return "", err
+			}
+		}
+
+		var err error
+		ok, err = c.addClusterIssuerIfAbsent(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to check if cluster issuer exists: %w", err)
+		}
 	}
 
 	if ok {
-		clusterIssuerPath := filepath.Join(c.targetPath, "cert-manager", "cluster_issuer.yaml")
-		clusterIssuerData := SetProviderDataFormat(c.provider, clusterIssuer)
+		clusterIssuerPath := filepath.Join(c.targetPath, mapNamespace(c.namespaces, env.DefaultCertManagerNamespace), "cluster_issuer.yaml")
+		clusterIssuerData, err := c.manifest(c.issuer.manifest())
+		if err != nil {
+			return "", err
+		}
 
 		files = append(files, gitprovider.CommitFile{
 			Path:    &clusterIssuerPath,
@@ -87,7 +134,10 @@ func (c *certificateManifestsInstall) Install(ctx context.Context) (string, erro
 		})
 	}
 
-	var commit gitprovider.Commit
+	var (
+		commit gitprovider.Commit
+		err    error
+	)
 	// Note, this fix is necessary right now, because gitea has yet to implement their own API
 	// to allow to submit multiple files at once:
 	// https://github.com/go-gitea/gitea/pull/24887
@@ -109,19 +159,29 @@ func (c *certificateManifestsInstall) Install(ctx context.Context) (string, erro
 	return commit.Get().Sha, nil
 }
 
+// manifest returns the given certificate manifest relocated to the mapped namespaces.
+func (c *certificateManifestsInstall) manifest(content []byte) (string, error) {
+	content, err := relocateNamespaces(content, c.namespaces)
+	if err != nil {
+		return "", fmt.Errorf("failed to relocate certificate manifest: %w", err)
+	}
+
+	return SetProviderDataFormat(c.provider, content), nil
+}
+
 func (c *certificateManifestsInstall) addClusterIssuerIfAbsent(ctx context.Context) (bool, error) {
 	// Avoid having to import certmanager and add it to the client scheme. We just want to check if it exists or not.
 	obj := &unstructured.Unstructured{}
 	obj.SetAPIVersion("cert-manager.io/v1")
 	obj.SetKind("ClusterIssuer")
 	if err := c.kubeClient.Get(ctx, types.NamespacedName{
-		Name: "mpas-bootstrap-issuer", // matches name with what is in internal/boostrap/certmanager/cluster_issuer.yaml
+		Name: c.issuer.name(), // matches the name of the first cluster issuer in internal/boostrap/certmanager
 	}, obj); err != nil {
 		if apierrors.IsNotFound(err) {
 			return true, nil
 		}
 
-		return false, fmt.Errorf("failed to get ClusterIssuer mpas-bootstra-issuer: %w", err)
+		return false, fmt.Errorf("failed to get ClusterIssuer %s: %w", c.issuer.name(), err)
 	}
 
 	return false, nil
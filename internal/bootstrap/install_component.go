@@ -7,14 +7,13 @@ package bootstrap
 import (
 	"context"
 	"fmt"
+	"path"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
 	"github.com/open-component-model/mpas/internal/env"
 	"github.com/open-component-model/mpas/internal/kubeutils"
-	"github.com/open-component-model/ocm/pkg/contexts/ocm"
 )
 
 type componentOptions struct {
@@ -22,53 +21,30 @@ type componentOptions struct {
 	branch        string
 	targetPath    string
 	namespace     string
-	dir           string
 	provider      string
 	// we bookkeep the installed components so we can cleanup unnecessary namespaces
 	installedNS           map[string][]string
 	commitMessageAppendix string
-	timeout               time.Duration
+	// patchFiles are committed along with the manifest
+	patchFiles []gitprovider.CommitFile
+	timeout    time.Duration
 }
 
 // componentInstall is used to install a component
 type componentInstall struct {
 	componentName string
 	version       string
-	kustomizer    Kustomizer
 
 	*componentOptions
 }
 
 // newComponentInstall returns a new component install
-func newComponentInstall(name, version string, repository ocm.Repository, opts *componentOptions) (*componentInstall, error) {
-	c := &componentInstall{
+func newComponentInstall(name, version string, opts *componentOptions) *componentInstall {
+	return &componentInstall{
 		componentName:    name,
 		version:          version,
 		componentOptions: opts,
-		kustomizer: NewKustomizer(&kustomizerOptions{
-			componentName: name,
-			version:       version,
-			repository:    repository,
-			dir:           opts.dir,
-			host:          env.DefaultOCMHost,
-		}),
 	}
-
-	return c, nil
-}
-
-func (c *componentInstall) install(ctx context.Context, component string) (string, error) {
-	res, err := c.kustomizer.GenerateKustomizedResourceData(component)
-	if err != nil {
-		return "", fmt.Errorf("failed to generate component yaml: %w", err)
-	}
-
-	sha, err := c.reconcileComponents(ctx, res)
-	if err != nil {
-		return "", fmt.Errorf("failed to reconcile components: %w", err)
-	}
-
-	return sha, nil
 }
 
 func (c *componentInstall) reconcileComponents(ctx context.Context, content []byte) (string, error) {
@@ -86,23 +62,40 @@ func (c *componentInstall) reconcileComponents(ctx context.Context, content []by
 	}
 
 	data := SetProviderDataFormat(c.provider, content)
-	path := filepath.Join(c.targetPath, c.namespace, fmt.Sprintf("%s.yaml", strings.Split(c.componentName, "/")[2]))
+	path := filepath.Join(c.targetPath, c.namespace, fmt.Sprintf("%s.yaml", path.Base(c.componentName)))
 	commitMsg := fmt.Sprintf("Add %s %s manifests", c.componentName, c.version)
 	if c.commitMessageAppendix != "" {
 		commitMsg = commitMsg + "\n\n" + c.commitMessageAppendix
 	}
-	commit, err := c.gitRepository.Commits().Create(ctx,
-		c.branch,
-		commitMsg,
-		[]gitprovider.CommitFile{
+	commit, err := createCommit(ctx, c.gitRepository, c.provider, c.branch, commitMsg,
+		append([]gitprovider.CommitFile{
 			{
 				Path:    &path,
 				Content: &data,
 			},
-		})
+		}, c.patchFiles...))
 	if err != nil {
 		return "", fmt.Errorf("failed to create component: %w", err)
 	}
 
 	return commit.Get().Sha, nil
 }
+
+// createCommit commits the given files to the management repository. Gitea cannot commit multiple files
+// through its API, each file is committed on its own and the last commit is returned.
+func createCommit(ctx context.Context, repo gitprovider.UserRepository, provider, branch, message string, files []gitprovider.CommitFile) (gitprovider.Commit, error) {
+	if provider != env.ProviderGitea {
+		return repo.Commits().Create(ctx, branch, message, files)
+	}
+
+	var commit gitprovider.Commit
+	for _, file := range files {
+		var err error
+		commit, err = repo.Commits().Create(ctx, branch, message, []gitprovider.CommitFile{file})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return commit, nil
+}
@@ -2,52 +2,60 @@ package bootstrap
 
 import (
 	"context"
+	"io"
 	"testing"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/open-component-model/mpas/internal/env"
+	"github.com/open-component-model/mpas/internal/printer"
+	"github.com/open-component-model/ocm/pkg/contexts/ocm/compdesc"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"k8s.io/utils/ptr"
 )
 
 func TestComponentInstall(t *testing.T) {
-	temp := t.TempDir()
+	p, err := printer.Newprinter(io.Discard)
+	require.NoError(t, err)
+
 	mc := &mockCommitClient{
 		commit: &mockCommit{
 			sha: "sha",
 		},
 	}
-	repo := &mockGitRepository{
-		commitClient: mc,
-	}
-	c := &componentInstall{
-		componentName: "ocm.software/mpas/test-component",
-		version:       "v1.0.1",
-		componentOptions: &componentOptions{
-			gitRepository: repo,
-			dir:           temp,
-			branch:        "main",
+	b := &Bootstrap{
+		repository: &mockGitRepository{
+			commitClient: mc,
+		},
+		options: options{
+			printer:       p,
+			concurrency:   1,
+			repositoryURL: "https://git.example.com/mpas.git",
+			defaultBranch: "main",
 			targetPath:    "target",
-			namespace:     "ocm-system",
-			provider:      "gitea",
 		},
-		kustomizer: &mockKustomizer{
-			out: kustomizedDeployment,
+	}
+
+	ociRepo := &mockRepository{
+		cv: []*mockComponentAccess{
+			newFakeControllerComponent(env.GitControllerName),
 		},
 	}
+	refs := map[string]compdesc.ComponentReference{
+		env.GitControllerName: newComponentReference(t, env.GitControllerName, "v1.0.0", nil),
+	}
+	manifests, err := b.generateManifests(context.Background(), ociRepo, refs, []string{env.GitControllerName})
+	require.NoError(t, err)
 
-	sha, err := c.install(context.Background(), "ocm.software/mpas/test-component")
+	sha, err := b.installComponent(context.Background(), refs[env.GitControllerName], env.DefaultOCMNamespace, map[string][]string{}, manifests[env.GitControllerName])
 	require.NoError(t, err)
 	assert.Equal(t, "sha", sha)
 
 	require.Lenf(t, mc.calledWidth, 1, "exactly one call expected from mock client, but was %d", len(mc.calledWidth))
 	args := mc.calledWidth[0]
 	assert.Equal(t, "main", args[0])
-	assert.Equal(t, "Add ocm.software/mpas/test-component v1.0.1 manifests", args[1])
-	assert.Equal(t, []gitprovider.CommitFile{
-		{
-			Path:    ptr.To("target/ocm-system/test-component.yaml"),
-			Content: ptr.To("YXBpVmVyc2lvbjogYXBwcy92MQpraW5kOiBEZXBsb3ltZW50Cm1ldGFkYXRhOgogIG5hbWU6IGdpdC1jb250cm9sbGVyCiAgbmFtZXNwYWNlOiBvY20tc3lzdGVtCnNwZWM6CiAgc2VsZWN0b3I6CiAgICBtYXRjaExhYmVsczoKICAgICAgYXBwOiBnaXQtY29udHJvbGxlcgogIHJlcGxpY2FzOiAxCiAgdGVtcGxhdGU6CiAgICBtZXRhZGF0YToKICAgICAgbGFiZWxzOgogICAgICAgIGFwcDogZ2l0LWNvbnRyb2xsZXIKICAgIHNwZWM6CiAgICAgIGNvbnRhaW5lcnM6CiAgICAgIC0gbmFtZTogbWFuYWdlcgogICAgICAgIGltYWdlOiBnaGNyLmlvL3VzZXIvZ2l0LWNvbnRyb2xsZXI6djEuMC4wCg=="),
-		},
-	}, args[2])
+	assert.Equal(t, "Add ocm.software/mpas/git-controller v1.0.0 manifests", args[1])
+	files := args[2].([]gitprovider.CommitFile)
+	require.Len(t, files, 1)
+	assert.Equal(t, "target/ocm-system/git-controller.yaml", *files[0].Path)
+	assert.Contains(t, *files[0].Content, "image: ghcr.io/new-user/git-controller:v1.0.0")
 }
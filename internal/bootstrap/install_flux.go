@@ -13,17 +13,19 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-	"sync"
 	"time"
 
 	flux "github.com/fluxcd/flux2/v2/pkg/bootstrap"
 	"github.com/fluxcd/flux2/v2/pkg/log"
 	"github.com/fluxcd/flux2/v2/pkg/manifestgen/install"
+	"github.com/fluxcd/flux2/v2/pkg/manifestgen/kustomization"
 	"github.com/fluxcd/flux2/v2/pkg/manifestgen/sourcesecret"
 	syncOpts "github.com/fluxcd/flux2/v2/pkg/manifestgen/sync"
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
 	"github.com/fluxcd/pkg/git"
-	"github.com/fluxcd/pkg/git/gogit"
 	"github.com/fluxcd/pkg/git/repository"
+	"github.com/fluxcd/pkg/kustomize/filesys"
 	rateoption "github.com/fluxcd/pkg/runtime/client"
 	"github.com/open-component-model/mpas/internal/env"
 	"github.com/open-component-model/mpas/internal/kubeutils"
@@ -39,6 +41,8 @@ import (
 
 type fluxOptions struct {
 	gitClient             repository.Client
+	auth                  *git.AuthOptions
+	username              string
 	kubeClient            client.Client
 	restClientGetter      genericclioptions.RESTClientGetter
 	url                   string
@@ -47,12 +51,26 @@ type fluxOptions struct {
 	branch                string
 	targetPath            string
 	namespace             string
+	namespaces            map[string]string
 	token                 string
 	dir                   string
 	commitMessageAppendix string
 	interval              time.Duration
 	timeout               time.Duration
 	caFile                []byte
+	// syncName is the name of the source secret, GitRepository and Kustomization syncing the management repository
+	syncName string
+	// pushBranch is the branch the manifests are pushed to, flux syncs the branch once they are merged to it
+	pushBranch string
+	// committer sets the author of the commits and signs them
+	committer *committer
+	// existing is set when flux is already installed, only its sync configuration is reconciled
+	existing bool
+	// ssh is set when flux pulls the management repository over SSH with a deploy key
+	ssh *sshOptions
+	// patches are merged into the kustomization, patchFiles are committed along with the manifests
+	patches    []kustypes.Patch
+	patchFiles []gitprovider.CommitFile
 }
 
 type fluxInstall struct {
@@ -62,11 +80,9 @@ type fluxInstall struct {
 	components       []string
 	fluxBootstrapper *flux.PlainGitBootstrapper
 	*fluxOptions
-	// mu is used to synchronize access to the kustomization file
-	mu sync.Mutex
 }
 
-func newFluxInstall(name, version, owner string, repository ocm.Repository, opts *fluxOptions) (*fluxInstall, error) {
+func newFluxInstall(name, version string, repository ocm.Repository, opts *fluxOptions) (*fluxInstall, error) {
 	f := &fluxInstall{
 		componentName: name,
 		version:       version,
@@ -74,23 +90,22 @@ func newFluxInstall(name, version, owner string, repository ocm.Repository, opts
 		fluxOptions:   opts,
 	}
 
-	clientOpts := []gogit.ClientOption{gogit.WithDiskStorage(), gogit.WithFallbackToDefaultKnownHosts()}
-	gitOptions := &git.AuthOptions{Transport: git.HTTPS, Username: owner, Password: f.token}
-	if f.transport == "http" {
-		clientOpts = append(clientOpts, gogit.WithInsecureCredentialsOverHTTP())
-		gitOptions.Transport = git.HTTP
-	}
-	gitClient, err := gogit.NewClient(f.dir, gitOptions, clientOpts...)
+	gitClient, err := newGitClient(f.dir, f.auth)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create a Git client: %w", err)
+		return nil, err
 	}
 
-	p, err := flux.NewPlainGitProvider(gitClient, f.kubeClient,
+	bootstrapOpts := []flux.GitOption{
 		flux.WithBranch(f.branch),
 		flux.WithRepositoryURL(f.url),
 		flux.WithLogger(log.NopLogger{}),
 		flux.WithKubeconfig(f.restClientGetter, &rateoption.Options{QPS: env.DefaultKubeAPIQPS, Burst: env.DefaultKubeAPIBurst}),
-	)
+	}
+	if f.ssh != nil {
+		bootstrapOpts = append(bootstrapOpts, flux.WithPostGenerateSecretFunc(f.ssh.postGenerateSecret))
+	}
+
+	p, err := flux.NewPlainGitProvider(gitClient, f.kubeClient, bootstrapOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -101,93 +116,166 @@ func newFluxInstall(name, version, owner string, repository ocm.Repository, opts
 }
 
 func (f *fluxInstall) Install(ctx context.Context, component string) error {
-	cv, err := getComponentVersion(f.repository, f.componentName, f.version)
-	if err != nil {
-		return fmt.Errorf("failed to get component version: %w", err)
+	if !f.existing {
+		res, err := f.generate(component)
+		if err != nil {
+			return err
+		}
+
+		err = f.reconcileComponents(ctx, fmt.Sprintf("%s/%s/%s", f.targetPath, f.namespace, "gotk-components.yaml"), string(res))
+		if err != nil {
+			return fmt.Errorf("failed to reconcile components: %w", err)
+		}
 	}
 
-	resources, err := getResources(cv, component)
-	if err != nil {
-		return fmt.Errorf("failed to get resources: %w", err)
+	var err error
+	secretOpts := sourcesecret.Options{
+		Name:         f.syncName,
+		Namespace:    f.namespace,
+		TargetPath:   f.targetPath,
+		ManifestFile: sourcesecret.MakeDefaultOptions().ManifestFile,
+		Username:     f.username,
+		Password:     f.token,
+		CAFile:       f.caFile,
 	}
 
-	f.components = resources.componentList
+	if f.ssh != nil {
+		secretOpts, err = f.ssh.sourceSecretOptions(secretOpts)
+		if err != nil {
+			return err
+		}
+	}
 
-	if resources.componentResource == nil || resources.ocmConfig == nil {
-		return fmt.Errorf("flux or ocm-config resource not found")
+	if err := f.fluxBootstrapper.ReconcileSourceSecret(ctx, secretOpts); err != nil {
+		return err
 	}
 
-	kfile, kus, err := f.generateKustomization(resources.componentResource)
+	syncOpts := f.syncOptions()
+
+	if err := f.reconcileSyncConfig(ctx, syncOpts); err != nil {
+		return fmt.Errorf("failed to reconcile sync config: %w", err)
+	}
+
+	// the pushed manifests are only reconciled once they are merged to the synced branch
+	var healthErr error
+	if !f.proposed() {
+		if err := f.fluxBootstrapper.ReportKustomizationHealth(ctx, syncOpts, env.DefaultPollInterval, f.timeout); err != nil {
+			healthErr = errors.Join(healthErr, err)
+		}
+	}
+
+	if !f.existing {
+		installOpts := install.Options{
+			Namespace:  f.namespace,
+			Components: f.components,
+		}
+		if err := f.fluxBootstrapper.ReportComponentsHealth(ctx, installOpts, f.timeout); err != nil {
+			healthErr = errors.Join(healthErr, err)
+		}
+	}
+	if healthErr != nil {
+		return fmt.Errorf("failed to report health, please try again later: %w", healthErr)
+	}
+
+	return nil
+}
+
+// Render returns the flux component, sync and kustomization manifests without pushing them
+// to the management repository or applying them to the cluster. The source secret is left out
+// as it holds the git credentials. The components are left out of an existing flux.
+func (f *fluxInstall) Render(component string) ([]gitprovider.CommitFile, error) {
+	sync, err := syncOpts.Generate(f.syncOptions())
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to generate sync manifests: %w", err)
 	}
 
-	kconfig, err := unMarshallConfig(resources.ocmConfig)
+	var (
+		files     []gitprovider.CommitFile
+		resources []string
+		dir       = filepath.Join(f.targetPath, f.namespace)
+	)
+	if !f.existing {
+		res, err := f.generate(component)
+		if err != nil {
+			return nil, err
+		}
+
+		componentsFile := "gotk-components.yaml"
+		files = append(files, newCommitFile(filepath.Join(dir, componentsFile), string(res)))
+		resources = append(resources, componentsFile)
+	}
+
+	syncFile := syncOpts.MakeDefaultOptions().ManifestFile
+	kus, err := yaml.Marshal(kustypes.Kustomization{
+		TypeMeta: kustypes.TypeMeta{
+			APIVersion: kustypes.KustomizationVersion,
+			Kind:       kustypes.KustomizationKind,
+		},
+		Resources: append(resources, syncFile),
+	})
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to marshal kustomization: %w", err)
 	}
 
-	res, err := f.generateGOTKComponent(kconfig, resources.imagesResources, kus, kfile)
+	return append(append(files,
+		newCommitFile(filepath.Join(dir, syncFile), sync.Content),
+		newCommitFile(filepath.Join(dir, konfig.DefaultKustomizationFileName()), string(kus)),
+	), f.patchFiles...), nil
+}
+
+// generate returns the flux component manifests with the images localized to the ones of the component.
+func (f *fluxInstall) generate(component string) ([]byte, error) {
+	cv, err := getComponentVersion(f.repository, f.componentName, f.version)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to get component version: %w", err)
 	}
 
-	err = f.reconcileComponents(ctx, fmt.Sprintf("%s/%s/%s", f.targetPath, f.namespace, "gotk-components.yaml"), string(res))
+	resources, err := getResources(cv, component)
 	if err != nil {
-		return fmt.Errorf("failed to reconcile components: %w", err)
+		return nil, fmt.Errorf("failed to get resources: %w", err)
 	}
 
-	secretOpts := sourcesecret.Options{
-		Name:         f.namespace,
-		Namespace:    f.namespace,
-		TargetPath:   f.targetPath,
-		ManifestFile: sourcesecret.MakeDefaultOptions().ManifestFile,
-		Username:     "git",
-		Password:     f.token,
-		CAFile:       f.caFile,
+	f.components = resources.componentList
+
+	if resources.componentResource == nil || resources.ocmConfig == nil {
+		return nil, fmt.Errorf("flux or ocm-config resource not found")
 	}
 
-	if err := f.fluxBootstrapper.ReconcileSourceSecret(ctx, secretOpts); err != nil {
-		return err
+	kfile, kus, err := f.generateKustomization(resources.componentResource)
+	if err != nil {
+		return nil, err
+	}
+
+	kconfig, err := unMarshallConfig(resources.ocmConfig)
+	if err != nil {
+		return nil, err
 	}
 
-	syncOpts := syncOpts.Options{
+	return f.generateGOTKComponent(kconfig, resources.imagesResources, kus, kfile)
+}
+
+func (f *fluxInstall) syncOptions() syncOpts.Options {
+	opts := syncOpts.Options{
 		Interval:          f.interval,
-		Name:              f.namespace,
+		Name:              f.syncName,
 		Namespace:         f.namespace,
 		URL:               f.url,
 		Branch:            f.branch,
-		Secret:            secretOpts.Name,
+		Secret:            f.syncName,
 		TargetPath:        f.targetPath,
 		ManifestFile:      syncOpts.MakeDefaultOptions().ManifestFile,
 		RecurseSubmodules: false,
 	}
 
-	if f.testURL != "" {
-		syncOpts.URL = f.testURL
-	}
-
-	if err := f.fluxBootstrapper.ReconcileSyncConfig(ctx, syncOpts); err != nil {
-		return fmt.Errorf("failed to reconcile sync config: %w", err)
-	}
-
-	var healthErr error
-	if err := f.fluxBootstrapper.ReportKustomizationHealth(ctx, syncOpts, env.DefaultPollInterval, f.timeout); err != nil {
-		healthErr = errors.Join(healthErr, err)
+	if f.ssh != nil {
+		opts.URL = f.ssh.url
 	}
 
-	installOpts := install.Options{
-		Namespace:  f.namespace,
-		Components: f.components,
-	}
-	if err := f.fluxBootstrapper.ReportComponentsHealth(ctx, installOpts, f.timeout); err != nil {
-		healthErr = errors.Join(healthErr, err)
-	}
-	if healthErr != nil {
-		return fmt.Errorf("failed to report health, please try again later: %w", healthErr)
+	if f.testURL != "" {
+		opts.URL = f.testURL
 	}
 
-	return nil
+	return opts
 }
 
 func (f *fluxInstall) generateGOTKComponent(kconfig *cfd.ConfigData, imagesResources map[string]nameTag, kus kustypes.Kustomization, kfile string) ([]byte, error) {
@@ -197,10 +285,17 @@ func (f *fluxInstall) generateGOTKComponent(kconfig *cfd.ConfigData, imagesResou
 			Name:    fmt.Sprintf("%s/%s", env.DefaultFluxHost, loc.Resource.Name),
 			NewName: image.Name,
 			NewTag:  image.Tag,
+			Digest:  image.Digest,
 		})
 	}
+	kus.Patches = append(kus.Patches, f.patches...)
+
+	manifest, err := buildKustomization(kus, kfile, f.dir)
+	if err != nil {
+		return nil, err
+	}
 
-	return buildKustomization(kus, kfile, f.dir, &f.mu)
+	return relocateNamespaces(manifest, f.namespaces)
 }
 
 func (f *fluxInstall) generateKustomization(fluxResource []byte) (string, kustypes.Kustomization, error) {
@@ -255,12 +350,10 @@ func (f *fluxInstall) commitAndPushComponents(ctx context.Context, path string,
 		commitMsg = commitMsg + "\n\n" + f.commitMessageAppendix
 	}
 
-	_, err = f.gitClient.Commit(git.Commit{
-		Author:  git.Signature{Name: "Flux"},
-		Message: commitMsg,
-	}, repository.WithFiles(map[string]io.Reader{
-		path: strings.NewReader(content),
-	}))
+	files := patchFilesReaders(f.patchFiles)
+	files[path] = strings.NewReader(content)
+
+	_, err = f.committer.commit(f.gitClient, "Flux", commitMsg, repository.WithFiles(files))
 	if err != nil && !errors.Is(err, git.ErrNoStagedFiles) {
 		return fmt.Errorf("failed to commit sync manifests: %w", err)
 	}
@@ -273,6 +366,87 @@ func (f *fluxInstall) commitAndPushComponents(ctx context.Context, path string,
 	return nil
 }
 
+// cloneBranch returns the branch the manifests are pushed to.
+func (f *fluxOptions) cloneBranch() string {
+	if f.pushBranch != "" {
+		return f.pushBranch
+	}
+
+	return f.branch
+}
+
+// proposed returns true if the manifests are pushed to another branch than the one flux syncs.
+func (f *fluxOptions) proposed() bool {
+	return f.pushBranch != "" && f.pushBranch != f.branch
+}
+
+// reconcileSyncConfig commits the sync manifests to the management repository and applies them.
+// Flux commits them itself unless the commits have a configured author or are signed, as it signs with OpenPGP only,
+// or are pushed to another branch than the one it syncs.
+func (f *fluxInstall) reconcileSyncConfig(ctx context.Context, opts syncOpts.Options) error {
+	if !f.committer.local() && !f.proposed() {
+		return f.fluxBootstrapper.ReconcileSyncConfig(ctx, opts)
+	}
+
+	key := client.ObjectKey{Name: opts.Name, Namespace: opts.Namespace}
+	var ks kustomizev1.Kustomization
+	if err := f.kubeClient.Get(ctx, key, &ks); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to get kustomization %s: %w", key, err)
+	} else if err == nil && cleanSyncPath(ks.Spec.Path) != cleanSyncPath(opts.TargetPath) {
+		return fmt.Errorf("sync path configuration (%q) would overwrite path (%q) of existing Kustomization", opts.TargetPath, ks.Spec.Path)
+	}
+
+	if err := f.cloneRepository(ctx); err != nil {
+		return fmt.Errorf("failed to clone repository: %w", err)
+	}
+
+	manifests, err := syncOpts.Generate(opts)
+	if err != nil {
+		return fmt.Errorf("failed to generate sync manifests: %w", err)
+	}
+
+	fs, err := filesys.MakeFsOnDiskSecureBuild(f.gitClient.Path())
+	if err != nil {
+		return fmt.Errorf("failed to initialize kustomize file system: %w", err)
+	}
+	if err := fs.WriteFile(filepath.Join(f.gitClient.Path(), manifests.Path), []byte(manifests.Content)); err != nil {
+		return fmt.Errorf("failed to write sync manifests: %w", err)
+	}
+
+	kus, err := kustomization.Generate(kustomization.Options{
+		FileSystem: fs,
+		BaseDir:    f.gitClient.Path(),
+		TargetPath: filepath.Dir(manifests.Path),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to generate %s: %w", konfig.DefaultKustomizationFileName(), err)
+	}
+
+	commitMsg := "Add Flux sync manifests"
+	if f.commitMessageAppendix != "" {
+		commitMsg = commitMsg + "\n\n" + f.commitMessageAppendix
+	}
+
+	_, err = f.committer.commit(f.gitClient, "Flux", commitMsg, repository.WithFiles(map[string]io.Reader{
+		kus.Path: strings.NewReader(kus.Content),
+	}))
+	if err != nil && !errors.Is(err, git.ErrNoStagedFiles) {
+		return fmt.Errorf("failed to commit sync manifests: %w", err)
+	}
+
+	if err == nil {
+		if err := f.gitClient.Push(ctx); err != nil {
+			return fmt.Errorf("failed to push sync manifests: %w", err)
+		}
+	}
+
+	if _, err := kubeutils.Apply(ctx, f.restClientGetter, f.gitClient.Path(), filepath.Join(f.gitClient.Path(), kus.Path)); err != nil {
+		return fmt.Errorf("failed to apply sync manifests: %w", err)
+	}
+
+	return nil
+}
+
 func (f *fluxInstall) cloneRepository(ctx context.Context) error {
 	if _, err := f.gitClient.Head(); err != nil {
 		if !errors.Is(err, git.ErrNoGitRepository) {
@@ -284,7 +458,7 @@ func (f *fluxInstall) cloneRepository(ctx context.Context) error {
 			}
 			_, err = f.gitClient.Clone(ctx, f.url, repository.CloneOptions{
 				CheckoutStrategy: repository.CheckoutStrategy{
-					Branch: f.branch,
+					Branch: f.cloneBranch(),
 				},
 			})
 			if err != nil {
@@ -0,0 +1,154 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/open-component-model/mpas/internal/env"
+	om "github.com/open-component-model/ocm/pkg/contexts/ocm"
+	"github.com/open-component-model/ocm/pkg/contexts/ocm/compdesc"
+	kustypes "sigs.k8s.io/kustomize/api/types"
+)
+
+const (
+	externalSecret               = "external-secrets"
+	externalSecretWebhook        = "external-secrets-webhook"
+	externalSecretCertController = "external-secrets-cert-controller"
+)
+
+// deploymentsLabel is the label of a component reference of the bootstrap component holding the deployments
+// checked for the health of a component installed by the generic installer, e.g. ["foo-controller"].
+const deploymentsLabel = "mpas.ocm.software/deployments"
+
+// installerLabel is the label of a component reference of the bootstrap component naming the registered
+// installer the component is installed with, e.g. "external-secrets".
+const installerLabel = "mpas.ocm.software/installer"
+
+// componentInstaller describes how the manifest of a component of the bootstrap component is generated
+// and where the component is installed.
+type componentInstaller struct {
+	// resource is the resource of the component holding its manifest
+	resource string
+	// host is the image host the localizations of the ocm-config of the component refer to
+	host string
+	// namespace is the default namespace the component is installed to
+	namespace string
+	// deployments are checked for the health of the component
+	deployments []string
+}
+
+// installers are the registered installers by name. The other components are installed by the generic installer.
+// It is populated by calls to registerInstaller.
+var installers = map[string]componentInstaller{}
+
+func init() {
+	// Register the installers of the known components
+	registerInstaller(env.OcmControllerName, controllerInstaller(env.OcmControllerName, env.DefaultOCMNamespace))
+	registerInstaller(env.GitControllerName, controllerInstaller(env.GitControllerName, env.DefaultOCMNamespace))
+	registerInstaller(env.ReplicationControllerName, controllerInstaller(env.ReplicationControllerName, env.DefaultOCMNamespace))
+	registerInstaller(env.MpasProductControllerName, controllerInstaller(env.MpasProductControllerName, env.DefaultMPASNamespace))
+	registerInstaller(env.MpasProjectControllerName, controllerInstaller(env.MpasProjectControllerName, env.DefaultMPASNamespace))
+	registerInstaller(env.ExternalSecretsName, componentInstaller{
+		resource:    env.ExternalSecretsName,
+		host:        env.DefaultExternalSecretsHost,
+		namespace:   env.DefaultExternalSecretsNamespace,
+		deployments: []string{externalSecret, externalSecretCertController, externalSecretWebhook},
+	})
+}
+
+// registerInstaller registers the installer of the component with the given name. The installer also
+// installs the components whose reference names it with the installer label.
+func registerInstaller(name string, inst componentInstaller) {
+	installers[name] = inst
+}
+
+// controllerInstaller returns the installer of a controller packaged like the MPAS controllers: its manifest
+// is the <name>-file resource and its images are localized from the OCM host.
+func controllerInstaller(name, namespace string) componentInstaller {
+	return componentInstaller{
+		resource:    fmt.Sprintf("%s-file", name),
+		host:        env.DefaultOCMHost,
+		namespace:   namespace,
+		deployments: []string{name},
+	}
+}
+
+// installerFor returns the installer of the given component: the installer named by the installer label of its
+// reference, or else the installer registered under its name. A component without an installer is installed
+// like the MPAS controllers to the mpas-system namespace. The health of a component installed by a labeled or
+// the generic installer is checked with the deployments of the deployments label of its reference, by default
+// the deployments of the installer.
+func installerFor(name string, ref compdesc.ComponentReference) (componentInstaller, error) {
+	var selected string
+	labeled, err := ref.GetLabels().GetValue(installerLabel, &selected)
+	if err != nil {
+		return componentInstaller{}, fmt.Errorf("failed to read label %s of %s: %w", installerLabel, name, err)
+	}
+
+	inst, ok := installers[name]
+	if labeled {
+		if inst, ok = installers[selected]; !ok {
+			return componentInstaller{}, fmt.Errorf("label %s of %s names unknown installer %q", installerLabel, name, selected)
+		}
+	} else if ok {
+		return inst, nil
+	} else {
+		inst = controllerInstaller(path.Base(name), env.DefaultMPASNamespace)
+	}
+
+	var deployments []string
+	ok, err = ref.GetLabels().GetValue(deploymentsLabel, &deployments)
+	if err != nil {
+		return componentInstaller{}, fmt.Errorf("failed to read label %s of %s: %w", deploymentsLabel, name, err)
+	}
+	if ok {
+		if len(deployments) == 0 {
+			return componentInstaller{}, fmt.Errorf("label %s of %s lists no deployment", deploymentsLabel, name)
+		}
+		inst.deployments = deployments
+	}
+
+	return inst, nil
+}
+
+// generateManifest generates the manifest of the given component with its installer.
+func generateManifest(ociRepo om.Repository, name string, ref compdesc.ComponentReference, namespaces map[string]string, patches []kustypes.Patch) ([]byte, error) {
+	inst, err := installerFor(name, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return inst.generate(ociRepo, ref, namespaces, patches)
+}
+
+// generate downloads the resources of the given component and kustomizes its manifest with the given patches
+// in a temporary directory of its own, relocated to the given namespaces.
+func (i componentInstaller) generate(ociRepo om.Repository, ref compdesc.ComponentReference, namespaces map[string]string, patches []kustypes.Patch) ([]byte, error) {
+	dir, err := mkdirTempDir(fmt.Sprintf("%s-install", path.Base(ref.GetComponentName())))
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	kustomizer := NewKustomizer(&kustomizerOptions{
+		componentName: ref.GetComponentName(),
+		version:       ref.GetVersion(),
+		repository:    ociRepo,
+		dir:           dir,
+		host:          i.host,
+		namespaces:    namespaces,
+		patches:       patches,
+	})
+
+	manifest, err := kustomizer.GenerateKustomizedResourceData(i.resource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate component yaml: %w", err)
+	}
+
+	return manifest, nil
+}
@@ -0,0 +1,122 @@
+package bootstrap
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/open-component-model/mpas/internal/env"
+	"github.com/open-component-model/mpas/internal/printer"
+	"github.com/open-component-model/ocm/pkg/contexts/ocm/compdesc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstallerFor(t *testing.T) {
+	inst, err := installerFor(env.ExternalSecretsName, newComponentReference(t, env.ExternalSecretsName, "v1.0.0", nil))
+	require.NoError(t, err)
+	assert.Equal(t, env.DefaultExternalSecretsNamespace, inst.namespace)
+	assert.Equal(t, env.ExternalSecretsName, inst.resource)
+
+	inst, err = installerFor(env.GitControllerName, newComponentReference(t, env.GitControllerName, "v1.0.0", nil))
+	require.NoError(t, err)
+	assert.Equal(t, componentInstaller{
+		resource:    "git-controller-file",
+		host:        env.DefaultOCMHost,
+		namespace:   env.DefaultOCMNamespace,
+		deployments: []string{env.GitControllerName},
+	}, inst)
+
+	inst, err = installerFor("my-org/foo-controller", newComponentReference(t, "my-org/foo-controller", "v1.0.0", nil))
+	require.NoError(t, err)
+	assert.Equal(t, componentInstaller{
+		resource:    "foo-controller-file",
+		host:        env.DefaultOCMHost,
+		namespace:   env.DefaultMPASNamespace,
+		deployments: []string{"foo-controller"},
+	}, inst)
+
+	ref := newComponentReference(t, "my-org/foo-controller", "v1.0.0", nil)
+	require.NoError(t, ref.Labels.Set(deploymentsLabel, []string{"foo-manager", "foo-webhook"}))
+	inst, err = installerFor("my-org/foo-controller", ref)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo-manager", "foo-webhook"}, inst.deployments)
+
+	ref = newComponentReference(t, "my-org/foo-controller", "v1.0.0", nil)
+	require.NoError(t, ref.Labels.Set(deploymentsLabel, []string{}))
+	_, err = installerFor("my-org/foo-controller", ref)
+	assert.ErrorContains(t, err, "label mpas.ocm.software/deployments of my-org/foo-controller lists no deployment")
+}
+
+func TestInstallerForLabel(t *testing.T) {
+	ref := newComponentReference(t, "my-org/secrets", "v1.0.0", nil)
+	require.NoError(t, ref.Labels.Set(installerLabel, env.ExternalSecretsName))
+	inst, err := installerFor("my-org/secrets", ref)
+	require.NoError(t, err)
+	assert.Equal(t, installers[env.ExternalSecretsName], inst)
+
+	// the deployments label overrides the deployments of the labeled installer
+	require.NoError(t, ref.Labels.Set(deploymentsLabel, []string{"secrets-manager"}))
+	inst, err = installerFor("my-org/secrets", ref)
+	require.NoError(t, err)
+	assert.Equal(t, env.DefaultExternalSecretsNamespace, inst.namespace)
+	assert.Equal(t, []string{"secrets-manager"}, inst.deployments)
+	assert.Equal(t, []string{externalSecret, externalSecretCertController, externalSecretWebhook}, installers[env.ExternalSecretsName].deployments)
+
+	ref = newComponentReference(t, "my-org/secrets", "v1.0.0", nil)
+	require.NoError(t, ref.Labels.Set(installerLabel, "unknown"))
+	_, err = installerFor("my-org/secrets", ref)
+	assert.ErrorContains(t, err, `label mpas.ocm.software/installer of my-org/secrets names unknown installer "unknown"`)
+}
+
+func TestRegisterInstaller(t *testing.T) {
+	registerInstaller("my-installer", componentInstaller{
+		resource:    "manifests",
+		host:        "registry.example.com",
+		namespace:   "my-system",
+		deployments: []string{"my-manager"},
+	})
+	t.Cleanup(func() {
+		delete(installers, "my-installer")
+	})
+
+	ref := newComponentReference(t, "my-org/foo-controller", "v1.0.0", nil)
+	require.NoError(t, ref.Labels.Set(installerLabel, "my-installer"))
+	inst, err := installerFor("my-org/foo-controller", ref)
+	require.NoError(t, err)
+	assert.Equal(t, componentInstaller{
+		resource:    "manifests",
+		host:        "registry.example.com",
+		namespace:   "my-system",
+		deployments: []string{"my-manager"},
+	}, inst)
+
+	inst, err = installerFor("my-installer", newComponentReference(t, "my-installer", "v1.0.0", nil))
+	require.NoError(t, err)
+	assert.Equal(t, "my-system", inst.namespace)
+}
+
+func TestGenerateCustomComponentManifest(t *testing.T) {
+	p, err := printer.Newprinter(io.Discard)
+	require.NoError(t, err)
+
+	repo := &mockRepository{
+		cv: []*mockComponentAccess{
+			newFakeControllerComponent("foo-controller"),
+		},
+	}
+	refs := map[string]compdesc.ComponentReference{
+		"foo-controller": newComponentReference(t, "foo-controller", "v1.0.0", nil),
+	}
+
+	b := &Bootstrap{
+		options: options{
+			printer:     p,
+			concurrency: 1,
+		},
+	}
+
+	manifests, err := b.generateManifests(context.Background(), repo, refs, getOrderedKeys(refs))
+	require.NoError(t, err)
+	assert.Contains(t, string(manifests["foo-controller"]), "ghcr.io/new-user/foo-controller:v1.0.0")
+}
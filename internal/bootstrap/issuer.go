@@ -0,0 +1,276 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/open-component-model/mpas/internal/kubeutils"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// bootstrapIssuerName is the self-signed cluster issuer of the CA of the certificate issuer
+	bootstrapIssuerName = "mpas-bootstrap-issuer"
+	// certificateIssuerName is the cluster issuer of the namespace certificates
+	certificateIssuerName = "mpas-certificate-issuer"
+	// caSecretName is the secret holding the CA keypair of the certificate issuer given with WithCAKeyPair
+	caSecretName = "mpas-ca-keypair"
+)
+
+var (
+	//go:embed certmanager/ca_cluster_issuer.yaml
+	caClusterIssuer []byte
+)
+
+// WithIssuerRef sets the existing cert-manager issuer signing the namespace certificates, as Issuer/<name>
+// or ClusterIssuer/<name>, a plain name refers to a ClusterIssuer. The bootstrap issuers are not installed.
+// An Issuer must exist in each namespace holding certificates.
+func WithIssuerRef(ref string) Option {
+	return func(o *options) {
+		o.issuerRef = ref
+	}
+}
+
+// WithCAKeyPair sets the PEM encoded CA certificate and private key files the namespace certificates are
+// signed with. The keypair is stored as secret in the cert-manager namespace instead of a self-signed CA.
+func WithCAKeyPair(certFile, keyFile string) Option {
+	return func(o *options) {
+		o.caCertFile = certFile
+		o.caKeyFile = keyFile
+	}
+}
+
+// WithCertificateDuration sets the duration of the namespace certificates and how long before they expire they
+// are renewed, as go durations. The defaults of cert-manager apply to the empty ones.
+func WithCertificateDuration(duration, renewBefore string) Option {
+	return func(o *options) {
+		o.certificateDuration = duration
+		o.certificateRenewBefore = renewBefore
+	}
+}
+
+// issuerRef is a reference to a cert-manager issuer.
+type issuerRef struct {
+	kind string
+	name string
+}
+
+// parseIssuerRef parses an issuer reference of the form [Issuer|ClusterIssuer/]<name>.
+func parseIssuerRef(ref string) (issuerRef, error) {
+	kind, name, found := strings.Cut(ref, "/")
+	if !found {
+		kind, name = "ClusterIssuer", ref
+	}
+
+	if kind != "Issuer" && kind != "ClusterIssuer" {
+		return issuerRef{}, fmt.Errorf("invalid issuer %q, the kind must be Issuer or ClusterIssuer", ref)
+	}
+	if name == "" || strings.Contains(name, "/") {
+		return issuerRef{}, fmt.Errorf("invalid issuer %q, expected [Issuer|ClusterIssuer/]<name>", ref)
+	}
+
+	return issuerRef{kind: kind, name: name}, nil
+}
+
+// certificateIssuer configures the issuer of the namespace certificates and their lifetime.
+type certificateIssuer struct {
+	// ref is the existing issuer of the certificates, the bootstrap issuers are not installed if set
+	ref *issuerRef
+	// caCert and caKey are the CA keypair of the certificate issuer, it is self-signed if they are not set
+	caCert []byte
+	caKey  []byte
+	// duration and renewBefore are set on the certificates if not empty
+	duration    string
+	renewBefore string
+}
+
+// certificateIssuer returns the issuer of the namespace certificates configured by the options.
+func (b *Bootstrap) certificateIssuer() (certificateIssuer, error) {
+	issuer := certificateIssuer{
+		duration:    b.certificateDuration,
+		renewBefore: b.certificateRenewBefore,
+	}
+
+	if b.issuerRef != "" {
+		ref, err := parseIssuerRef(b.issuerRef)
+		if err != nil {
+			return certificateIssuer{}, err
+		}
+		issuer.ref = &ref
+	}
+
+	if b.caCertFile != "" {
+		var err error
+		issuer.caCert, err = os.ReadFile(b.caCertFile)
+		if err != nil {
+			return certificateIssuer{}, fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		issuer.caKey, err = os.ReadFile(b.caKeyFile)
+		if err != nil {
+			return certificateIssuer{}, fmt.Errorf("failed to read CA private key: %w", err)
+		}
+	}
+
+	return issuer, nil
+}
+
+// hasCA returns true if the certificate issuer signs with a given CA keypair.
+func (i certificateIssuer) hasCA() bool {
+	return len(i.caCert) > 0
+}
+
+// name returns the name of the cluster issuer that is only committed if it does not exist yet.
+func (i certificateIssuer) name() string {
+	if i.hasCA() {
+		return certificateIssuerName
+	}
+
+	return bootstrapIssuerName
+}
+
+// manifest returns the manifest of the cluster issuers, it is empty if an existing issuer is used.
+func (i certificateIssuer) manifest() []byte {
+	switch {
+	case i.ref != nil:
+		return nil
+	case i.hasCA():
+		return caClusterIssuer
+	default:
+		return clusterIssuer
+	}
+}
+
+// certificate returns the given certificate manifest referring to the issuer, with the configured lifetime.
+func (i certificateIssuer) certificate(content []byte) ([]byte, error) {
+	if i.ref == nil && i.duration == "" && i.renewBefore == "" {
+		return content, nil
+	}
+
+	objects, err := kubeutils.YamlToUnstructructured(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert yaml to unstructured: %w", err)
+	}
+
+	for _, obj := range objects {
+		if obj.GetKind() != "Certificate" {
+			continue
+		}
+
+		if i.ref != nil {
+			if err := unstructured.SetNestedStringMap(obj.Object, map[string]string{
+				"name":  i.ref.name,
+				"kind":  i.ref.kind,
+				"group": "cert-manager.io",
+			}, "spec", "issuerRef"); err != nil {
+				return nil, fmt.Errorf("failed to set issuer of certificate %s: %w", obj.GetName(), err)
+			}
+		}
+
+		for field, value := range map[string]string{"duration": i.duration, "renewBefore": i.renewBefore} {
+			if value == "" {
+				continue
+			}
+			if err := unstructured.SetNestedField(obj.Object, value, "spec", field); err != nil {
+				return nil, fmt.Errorf("failed to set %s of certificate %s: %w", field, obj.GetName(), err)
+			}
+		}
+	}
+
+	return kubeutils.UnstructuredToYaml(objects)
+}
+
+// reconcileCASecret creates or updates the secret holding the CA keypair in the given namespace,
+// the namespace is created if cert-manager is not installed yet.
+func (c *certificateManifestsInstall) reconcileCASecret(ctx context.Context, namespace string) error {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
+	if err := c.kubeClient.Create(ctx, ns); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create namespace %s: %w", namespace, err)
+	}
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: caSecretName, Namespace: namespace}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, c.kubeClient, secret, func() error {
+		secret.Type = corev1.SecretTypeTLS
+		secret.Data = map[string][]byte{
+			corev1.TLSCertKey:       c.issuer.caCert,
+			corev1.TLSPrivateKeyKey: c.issuer.caKey,
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to reconcile secret %s/%s: %w", namespace, caSecretName, err)
+	}
+
+	return nil
+}
+
+// validateIssuerOptions validates the issuer and the lifetime of the namespace certificates.
+func validateIssuerOptions(opts *options) error {
+	if opts.issuerRef != "" {
+		if opts.caCertFile != "" {
+			return fmt.Errorf("an existing issuer and a CA keypair cannot be used together")
+		}
+		if _, err := parseIssuerRef(opts.issuerRef); err != nil {
+			return err
+		}
+	}
+
+	if (opts.caCertFile == "") != (opts.caKeyFile == "") {
+		return fmt.Errorf("both the CA certificate and the CA private key must be set")
+	}
+	if opts.caCertFile != "" {
+		if err := validateCAKeyPair(opts.caCertFile, opts.caKeyFile); err != nil {
+			return err
+		}
+	}
+
+	var durations [2]time.Duration
+	for i, d := range []struct{ name, value string }{
+		{"certificate duration", opts.certificateDuration},
+		{"certificate renewal", opts.certificateRenewBefore},
+	} {
+		if d.value == "" {
+			continue
+		}
+		var err error
+		durations[i], err = time.ParseDuration(d.value)
+		if err != nil || durations[i] <= 0 {
+			return fmt.Errorf("invalid %s %q, must be a positive duration, e.g. 2160h", d.name, d.value)
+		}
+	}
+	if durations[0] > 0 && durations[1] >= durations[0] {
+		return fmt.Errorf("the certificates must be renewed before their duration of %s elapses", opts.certificateDuration)
+	}
+
+	return nil
+}
+
+// validateCAKeyPair fails if the given files do not hold a matching CA certificate and private key.
+func validateCAKeyPair(certFile, keyFile string) error {
+	keyPair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return fmt.Errorf("invalid CA keypair: %w", err)
+	}
+
+	cert, err := x509.ParseCertificate(keyPair.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("invalid CA certificate: %w", err)
+	}
+	if !cert.IsCA {
+		return fmt.Errorf("the certificate %s is not a CA certificate", certFile)
+	}
+
+	return nil
+}
@@ -0,0 +1,192 @@
+package bootstrap
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/open-component-model/mpas/internal/kubeutils"
+	"github.com/open-component-model/mpas/internal/printer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// writeCAKeyPair writes a self-signed keypair to the given directory and returns the paths of its files.
+func writeCAKeyPair(t *testing.T, dir string, isCA bool) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "corporate-ca"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyData, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile, keyFile := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}), 0o644))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyData}), 0o600))
+
+	return certFile, keyFile
+}
+
+// readCertificate reads the first object of the given rendered manifest.
+func readCertificate(t *testing.T, path string) *unstructured.Unstructured {
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	objects, err := kubeutils.YamlToUnstructructured(content)
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+
+	return objects[0]
+}
+
+func TestParseIssuerRef(t *testing.T) {
+	ref, err := parseIssuerRef("corporate-ca")
+	require.NoError(t, err)
+	assert.Equal(t, issuerRef{kind: "ClusterIssuer", name: "corporate-ca"}, ref)
+
+	ref, err = parseIssuerRef("Issuer/vault")
+	require.NoError(t, err)
+	assert.Equal(t, issuerRef{kind: "Issuer", name: "vault"}, ref)
+
+	_, err = parseIssuerRef("Certificate/vault")
+	assert.ErrorContains(t, err, `invalid issuer "Certificate/vault", the kind must be Issuer or ClusterIssuer`)
+	_, err = parseIssuerRef("ClusterIssuer/")
+	assert.ErrorContains(t, err, `invalid issuer "ClusterIssuer/", expected [Issuer|ClusterIssuer/]<name>`)
+}
+
+func TestCertificateManifestsWithIssuerRef(t *testing.T) {
+	dir := t.TempDir()
+	installer := newCertificateManifestInstaller(&certificateManifestOptions{
+		gitRepository: newDryRunRepository(dir),
+		branch:        "main",
+		targetPath:    "target",
+		dryRun:        true,
+		issuer: certificateIssuer{
+			ref:         &issuerRef{kind: "Issuer", name: "vault"},
+			duration:    "2160h",
+			renewBefore: "360h",
+		},
+	})
+
+	_, err := installer.Install(context.Background(), []string{"ocm-system"}, true)
+	require.NoError(t, err)
+
+	_, err = os.Stat(filepath.Join(dir, "target/cert-manager/cluster_issuer.yaml"))
+	assert.True(t, os.IsNotExist(err), "expected no cluster issuer to be rendered")
+
+	cert := readCertificate(t, filepath.Join(dir, "target/ocm-system/ocm_certificate.yaml"))
+	ref, _, err := unstructured.NestedStringMap(cert.Object, "spec", "issuerRef")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"name": "vault", "kind": "Issuer", "group": "cert-manager.io"}, ref)
+	duration, _, _ := unstructured.NestedString(cert.Object, "spec", "duration")
+	assert.Equal(t, "2160h", duration)
+	renewBefore, _, _ := unstructured.NestedString(cert.Object, "spec", "renewBefore")
+	assert.Equal(t, "360h", renewBefore)
+}
+
+func TestCertificateManifestsWithCAKeyPair(t *testing.T) {
+	certFile, keyFile := writeCAKeyPair(t, t.TempDir(), true)
+	b := &Bootstrap{
+		options: options{
+			caCertFile: certFile,
+			caKeyFile:  keyFile,
+		},
+	}
+	issuer, err := b.certificateIssuer()
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	kubeClient := fake.NewClientBuilder().Build()
+	installer := newCertificateManifestInstaller(&certificateManifestOptions{
+		gitRepository: newDryRunRepository(dir),
+		branch:        "main",
+		targetPath:    "target",
+		kubeClient:    kubeClient,
+		namespaces:    map[string]string{"cert-manager": "tenant-cert-manager"},
+		issuer:        issuer,
+	})
+
+	_, err = installer.Install(context.Background(), []string{"ocm-system"}, true)
+	require.NoError(t, err)
+
+	clusterIssuer := readCertificate(t, filepath.Join(dir, "target/tenant-cert-manager/cluster_issuer.yaml"))
+	assert.Equal(t, certificateIssuerName, clusterIssuer.GetName())
+	secretName, _, _ := unstructured.NestedString(clusterIssuer.Object, "spec", "ca", "secretName")
+	assert.Equal(t, caSecretName, secretName)
+
+	cert := readCertificate(t, filepath.Join(dir, "target/ocm-system/ocm_certificate.yaml"))
+	issuerName, _, _ := unstructured.NestedString(cert.Object, "spec", "issuerRef", "name")
+	assert.Equal(t, certificateIssuerName, issuerName)
+
+	var secret corev1.Secret
+	require.NoError(t, kubeClient.Get(context.Background(), client.ObjectKey{Name: caSecretName, Namespace: "tenant-cert-manager"}, &secret))
+	assert.Equal(t, corev1.SecretTypeTLS, secret.Type)
+	caCert, err := os.ReadFile(certFile)
+	require.NoError(t, err)
+	assert.Equal(t, caCert, secret.Data[corev1.TLSCertKey])
+}
+
+func TestValidateIssuerOptions(t *testing.T) {
+	p, err := printer.Newprinter(io.Discard)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile, keyFile := writeCAKeyPair(t, dir, true)
+	newOptions := func() *options {
+		return &options{
+			repositoryName: "mpas",
+			printer:        p,
+			dryRun:         true,
+			outputDir:      dir,
+		}
+	}
+
+	opts := newOptions()
+	opts.issuerRef = "ClusterIssuer/corporate-ca"
+	opts.caCertFile, opts.caKeyFile = certFile, keyFile
+	assert.ErrorContains(t, validateOptions(opts), "an existing issuer and a CA keypair cannot be used together")
+
+	opts.issuerRef = ""
+	assert.NoError(t, validateOptions(opts))
+
+	opts.caKeyFile = ""
+	assert.ErrorContains(t, validateOptions(opts), "both the CA certificate and the CA private key must be set")
+
+	opts = newOptions()
+	opts.caCertFile, opts.caKeyFile = writeCAKeyPair(t, t.TempDir(), false)
+	assert.ErrorContains(t, validateOptions(opts), "is not a CA certificate")
+
+	opts = newOptions()
+	opts.certificateDuration = "90d"
+	assert.ErrorContains(t, validateOptions(opts), `invalid certificate duration "90d", must be a positive duration`)
+
+	opts.certificateDuration = "2160h"
+	opts.certificateRenewBefore = "2160h"
+	assert.ErrorContains(t, validateOptions(opts), "the certificates must be renewed before their duration of 2160h elapses")
+
+	opts.certificateRenewBefore = "360h"
+	assert.NoError(t, validateOptions(opts))
+}
@@ -7,8 +7,8 @@ package bootstrap
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
-	"strings"
 	"sync"
 
 	"github.com/fluxcd/pkg/kustomize"
@@ -19,12 +19,19 @@ import (
 	"sigs.k8s.io/yaml"
 )
 
+// buildMu serializes kustomize builds, kustomize is not safe to run concurrently.
+var buildMu sync.Mutex
+
 type kustomizerOptions struct {
 	dir           string
 	repository    ocm.Repository
 	componentName string
 	version       string
 	host          string
+	// namespaces maps the default namespaces to the namespaces the manifests are relocated to
+	namespaces map[string]string
+	// patches are the user supplied patches merged into the kustomization
+	patches []kustypes.Patch
 }
 
 // Kustomizer can kustomize a given component and change image information.
@@ -34,9 +41,6 @@ type Kustomizer interface {
 
 type Kustomize struct {
 	*kustomizerOptions
-
-	// mu is used to synchronize access to the kustomization file
-	mu sync.Mutex
 }
 
 // NewKustomizer creates a new kustomizer based on mutation options.
@@ -75,11 +79,11 @@ func (k *Kustomize) GenerateKustomizedResourceData(component string) ([]byte, er
 }
 
 func (k *Kustomize) generateKustomization(componentResource []byte) (string, kustypes.Kustomization, error) {
-	if err := os.WriteFile(filepath.Join(k.dir, fmt.Sprintf("%s.yaml", strings.Split(k.componentName, "/")[2])), componentResource, os.ModePerm); err != nil {
+	if err := os.WriteFile(filepath.Join(k.dir, fmt.Sprintf("%s.yaml", path.Base(k.componentName))), componentResource, os.ModePerm); err != nil {
 		return "", kustypes.Kustomization{}, err
 	}
 
-	return genKus(k.dir, fmt.Sprintf("./%s.yaml", strings.Split(k.componentName, "/")[2]))
+	return genKus(k.dir, fmt.Sprintf("./%s.yaml", path.Base(k.componentName)))
 }
 
 func (k *Kustomize) generateComponentYaml(kconfig *cfd.ConfigData, imagesResources map[string]nameTag, kus kustypes.Kustomization, kfile string) ([]byte, error) {
@@ -89,13 +93,20 @@ func (k *Kustomize) generateComponentYaml(kconfig *cfd.ConfigData, imagesResourc
 			Name:    fmt.Sprintf("%s/%s", k.host, loc.Resource.Name),
 			NewName: image.Name,
 			NewTag:  image.Tag,
+			Digest:  image.Digest,
 		})
 	}
+	kus.Patches = append(kus.Patches, k.patches...)
+
+	manifest, err := buildKustomization(kus, kfile, k.dir)
+	if err != nil {
+		return nil, err
+	}
 
-	return buildKustomization(kus, kfile, k.dir, &k.mu)
+	return relocateNamespaces(manifest, k.namespaces)
 }
 
-func buildKustomization(kus kustypes.Kustomization, kfile, dir string, mu sync.Locker) ([]byte, error) {
+func buildKustomization(kus kustypes.Kustomization, kfile, dir string) ([]byte, error) {
 	manifest, err := yaml.Marshal(kus)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal kustomization: %w", err)
@@ -108,8 +119,8 @@ func buildKustomization(kus kustypes.Kustomization, kfile, dir string, mu sync.L
 
 	fs := filesys.MakeFsOnDisk()
 
-	mu.Lock()
-	defer mu.Unlock()
+	buildMu.Lock()
+	defer buildMu.Unlock()
 
 	m, err := kustomize.Build(fs, dir)
 	if err != nil {
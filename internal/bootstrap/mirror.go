@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/open-component-model/mpas/internal/ocm"
+	"github.com/open-component-model/mpas/internal/printer"
+	om "github.com/open-component-model/ocm/pkg/contexts/ocm"
+	"github.com/open-component-model/ocm/pkg/contexts/ocm/compdesc"
+)
+
+// WithImageRegistryMirror sets the registry the components are copied to with their images. The manifests
+// of the components refer to the copies of the images, so that the cluster does not pull from the upstream registries.
+func WithImageRegistryMirror(mirror string) Option {
+	return func(o *options) {
+		o.imageRegistryMirror = mirror
+	}
+}
+
+// adoptImageRegistryMirror uses the image registry mirror recorded in the bootstrap state unless another one is set,
+// so that an upgrade or a resumed bootstrap keeps copying the images to the mirror.
+func (b *Bootstrap) adoptImageRegistryMirror(state *bootstrapState) {
+	if b.imageRegistryMirror == "" {
+		b.imageRegistryMirror = state.ImageRegistryMirror
+	}
+	state.ImageRegistryMirror = b.imageRegistryMirror
+}
+
+// mirrorComponents copies the components of the given references, with their images by value, from the given
+// repository to the image registry mirror in the bootstrap phase with the given name. It returns the mirror
+// repository, the image resources of the copied components refer to the images in the mirror.
+func (b *Bootstrap) mirrorComponents(ctx context.Context, octx om.Context, ociRepo om.Repository, refs map[string]compdesc.ComponentReference, phase, version string) (om.Repository, error) {
+	mirror, err := ocm.MakeRepositoryWithDockerConfig(octx, b.imageRegistryMirror, b.dockerConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create image registry mirror repository: %w", err)
+	}
+
+	mirrored := make([]compdesc.ComponentReference, 0, len(refs))
+	for _, name := range getOrderedKeys(refs) {
+		mirrored = append(mirrored, refs[name])
+	}
+
+	if _, err := b.inPhase(ctx, phase, version, fmt.Sprintf("Copying %d components with their images to %s",
+		len(mirrored), printer.BoldBlue(b.imageRegistryMirror)), func() (string, error) {
+		return "", ocm.TransferReferences(octx, ociRepo, mirror, mirrored, io.Discard)
+	}); err != nil {
+		mirror.Close()
+		return nil, fmt.Errorf("failed to copy components to the image registry mirror: %w", err)
+	}
+
+	return mirror, nil
+}
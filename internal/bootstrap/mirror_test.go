@@ -0,0 +1,196 @@
+package bootstrap
+
+import (
+	"bytes"
+	"context"
+	"encoding/pem"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/open-component-model/mpas/internal/env"
+	"github.com/open-component-model/mpas/internal/ocm"
+	"github.com/open-component-model/mpas/internal/printer"
+	"github.com/open-component-model/ocm/pkg/common/accessio"
+	"github.com/open-component-model/ocm/pkg/contexts/credentials"
+	"github.com/open-component-model/ocm/pkg/contexts/datacontext"
+	"github.com/open-component-model/ocm/pkg/contexts/oci/identity"
+	om "github.com/open-component-model/ocm/pkg/contexts/ocm"
+	"github.com/open-component-model/ocm/pkg/contexts/ocm/compdesc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestAdoptImageRegistryMirror(t *testing.T) {
+	state := newBootstrapState()
+	state.ImageRegistryMirror = "registry.local:5000/mpas"
+
+	b := &Bootstrap{}
+	b.adoptImageRegistryMirror(state)
+	assert.Equal(t, "registry.local:5000/mpas", b.imageRegistryMirror)
+
+	// a mirror set for the upgrade replaces the recorded one
+	b.imageRegistryMirror = "registry.other:5000/mpas"
+	b.adoptImageRegistryMirror(state)
+	assert.Equal(t, "registry.other:5000/mpas", b.imageRegistryMirror)
+	assert.Equal(t, "registry.other:5000/mpas", state.ImageRegistryMirror)
+}
+
+func TestValidateImageRegistryMirror(t *testing.T) {
+	p, err := printer.Newprinter(io.Discard)
+	require.NoError(t, err)
+
+	opts := &options{
+		repositoryName:      "mpas",
+		printer:             p,
+		dryRun:              true,
+		outputDir:           t.TempDir(),
+		imageRegistryMirror: "registry.local:5000/mpas",
+	}
+	assert.ErrorContains(t, validateOptions(opts), "the images cannot be copied to an image registry mirror during a dry-run")
+
+	opts.imageRegistryMirror = ""
+	assert.NoError(t, validateOptions(opts))
+}
+
+var testFluxConfigData = []byte(`apiVersion: config.ocm.software/v1alpha1
+kind: ConfigData
+metadata:
+  name: ocm-config
+localization:
+- name: source-controller
+  file: gotk-components.yaml
+  image: spec.template.spec.containers[0].image
+  resource:
+    name: source-controller
+`)
+
+var testFluxComponentData = []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: source-controller
+  namespace: flux-system
+spec:
+  selector:
+    matchLabels:
+      app: source-controller
+  template:
+    metadata:
+      labels:
+        app: source-controller
+    spec:
+      containers:
+      - name: manager
+        image: ghcr.io/fluxcd/source-controller:v1.0.0
+`)
+
+func TestMirrorComponents(t *testing.T) {
+	server := newTestRegistry(t)
+	host := strings.TrimPrefix(server.URL, "https://")
+	octx := om.New(datacontext.MODE_SHARED)
+	octx.CredentialsContext().SetCredentialsForConsumer(identity.GetConsumerId(host, ""), credentials.DirectCredentials{
+		credentials.ATTR_CERTIFICATE_AUTHORITY: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})),
+	})
+
+	tmp := t.TempDir()
+	ctf, err := ocm.CreateCTF(octx, filepath.Join(tmp, "ctf"), accessio.FormatDirectory)
+	require.NoError(t, err)
+	defer ctf.Close()
+
+	addTestComponent(t, octx, ctf, "ocm.software/mpas/"+env.OcmControllerName, map[string][]byte{
+		"ocm-config": bytes.ReplaceAll(testConfigData, []byte("git-controller"), []byte(env.OcmControllerName)),
+		env.OcmControllerName + "-file": bytes.ReplaceAll(bytes.ReplaceAll(testComponentData, []byte("git-controller"), []byte(env.OcmControllerName)),
+			[]byte("ghcr.io/user"), []byte(env.DefaultOCMHost)),
+	}, env.OcmControllerName, pushTestImage(t, server, "upstream/"+env.OcmControllerName+":v1.0.0"))
+	addTestComponent(t, octx, ctf, "ocm.software/mpas/"+env.FluxName, map[string][]byte{
+		"ocm-config": testFluxConfigData,
+		env.FluxName: testFluxComponentData,
+	}, "source-controller", pushTestImage(t, server, "upstream/source-controller:v1.0.0"))
+
+	refs := map[string]compdesc.ComponentReference{
+		env.OcmControllerName: newComponentReference(t, env.OcmControllerName, "v1.0.0", nil),
+		env.FluxName:          newComponentReference(t, env.FluxName, "v1.0.0", nil),
+	}
+
+	p, err := printer.Newprinter(io.Discard)
+	require.NoError(t, err)
+	dockerConfig := filepath.Join(tmp, "config.json")
+	require.NoError(t, os.WriteFile(dockerConfig, []byte(`{"auths":{}}`), 0o600))
+	b := &Bootstrap{
+		state:  newBootstrapState(),
+		report: newReport(),
+		options: options{
+			kubeclient:          fake.NewClientBuilder().Build(),
+			printer:             p,
+			concurrency:         1,
+			imageRegistryMirror: host + "/mirror",
+			dockerConfigPath:    dockerConfig,
+		},
+	}
+
+	mirror, err := b.mirrorComponents(context.Background(), octx, ctf, refs, "mirror", "v1.0.0")
+	require.NoError(t, err)
+	defer mirror.Close()
+
+	// the manifests generated from the mirror refer to the copies of the images
+	manifests, err := b.generateManifests(context.Background(), mirror, refs, []string{env.OcmControllerName})
+	require.NoError(t, err)
+	assert.Contains(t, string(manifests[env.OcmControllerName]), "image: "+host+"/mirror/")
+	assert.NotContains(t, string(manifests[env.OcmControllerName]), "image: "+host+"/upstream/")
+
+	f := &fluxInstall{
+		componentName: "ocm.software/mpas/" + env.FluxName,
+		version:       "v1.0.0",
+		repository:    mirror,
+		fluxOptions: &fluxOptions{
+			dir: t.TempDir(),
+		},
+	}
+	manifest, err := f.generate(env.FluxName)
+	require.NoError(t, err)
+	assert.Contains(t, string(manifest), "image: "+host+"/mirror/")
+	assert.NotContains(t, string(manifest), "image: "+host+"/upstream/")
+}
+
+// newTestRegistry starts an OCI registry serving TLS.
+func newTestRegistry(t *testing.T) *httptest.Server {
+	server := httptest.NewTLSServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// pushTestImage pushes a random image to the given repository of the registry and returns its reference.
+func pushTestImage(t *testing.T, server *httptest.Server, repository string) string {
+	image, err := random.Image(64, 1)
+	require.NoError(t, err)
+	ref, err := name.ParseReference(strings.TrimPrefix(server.URL, "https://") + "/" + repository)
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(ref, image, remote.WithTransport(server.Client().Transport)))
+
+	return ref.String()
+}
+
+// addTestComponent adds a component version with the given file resources and image resource to the repository.
+func addTestComponent(t *testing.T, octx om.Context, repo om.Repository, name string, files map[string][]byte, imageName, image string) {
+	comp, err := ocm.NewComponent(octx, name, "v1.0.0", ocm.WithProvider("ocm"))
+	require.NoError(t, err)
+	require.NoError(t, comp.AddToCTF(repo))
+	for resource, data := range files {
+		path := filepath.Join(t.TempDir(), resource)
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+		require.NoError(t, comp.AddResource(ocm.WithResourceName(resource), ocm.WithResourceType("file"),
+			ocm.WithResourcePath(path), ocm.WithResourceVersion("v1.0.0")))
+	}
+	require.NoError(t, comp.AddResource(ocm.WithResourceName(imageName), ocm.WithResourceType("ociImage"),
+		ocm.WithResourceImage(image), ocm.WithResourceVersion("v1.0.0")))
+	require.NoError(t, comp.Close())
+}
@@ -0,0 +1,252 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/open-component-model/mpas/internal/env"
+	"github.com/open-component-model/mpas/internal/kubeutils"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// apiServerServiceName is the DNS name of the api server service, it is never relocated.
+const apiServerServiceName = "kubernetes.default.svc"
+
+// certManagerCAInjectionAnnotations are the cert-manager annotations referencing
+// a certificate or a secret as <namespace>/<name>.
+var certManagerCAInjectionAnnotations = []string{
+	"cert-manager.io/inject-ca-from",
+	"cert-manager.io/inject-ca-from-secret",
+}
+
+// WithNamespaces sets the namespaces the components are installed to instead of their default namespace,
+// keyed by the default namespace. The default namespaces that are not mapped are kept.
+func WithNamespaces(namespaces map[string]string) Option {
+	return func(o *options) {
+		o.namespaces = namespaces
+	}
+}
+
+// namespace returns the namespace the components of the given default namespace are installed to.
+func (b *Bootstrap) namespace(ns string) string {
+	return mapNamespace(b.namespaces, ns)
+}
+
+// mapNamespace returns the namespace the given default namespace is mapped to.
+func mapNamespace(namespaces map[string]string, ns string) string {
+	if mapped, ok := namespaces[ns]; ok {
+		return mapped
+	}
+
+	return ns
+}
+
+// adoptNamespaces completes the namespace mapping with the one recorded in the bootstrap state,
+// so that an upgrade or a resumed bootstrap installs the components to the same namespaces.
+// It fails if a namespace is mapped differently than it was recorded.
+func (b *Bootstrap) adoptNamespaces(state *bootstrapState) error {
+	for ns, mapped := range b.namespaces {
+		recorded, ok := state.Namespaces[ns]
+		if !ok {
+			recorded = ns
+		}
+		if recorded != mapped {
+			return fmt.Errorf("namespace %s was mapped to %s when bootstrapping, not to %s", ns, recorded, mapped)
+		}
+	}
+
+	if len(state.Namespaces) > 0 {
+		namespaces := maps.Clone(state.Namespaces)
+		maps.Copy(namespaces, b.namespaces)
+		b.namespaces = namespaces
+	}
+
+	return nil
+}
+
+// validateNamespaces checks that only the default namespaces are mapped, to distinct valid namespace names.
+func validateNamespaces(namespaces map[string]string) error {
+	targets := make(map[string]string, len(namespaces))
+	for _, ns := range sortedKeys(namespaces) {
+		mapped := namespaces[ns]
+		if !slices.Contains(generatedNamespaces, ns) {
+			return fmt.Errorf("namespace %s cannot be mapped, must be one of %s", ns, strings.Join(generatedNamespaces, ", "))
+		}
+		if errs := validation.IsDNS1123Label(mapped); len(errs) > 0 {
+			return fmt.Errorf("invalid namespace %q for %s: %s", mapped, ns, strings.Join(errs, ", "))
+		}
+		if other, ok := targets[mapped]; ok {
+			return fmt.Errorf("namespaces %s and %s cannot both be mapped to %s", other, ns, mapped)
+		}
+		targets[mapped] = ns
+	}
+
+	for _, ns := range generatedNamespaces {
+		if _, ok := namespaces[ns]; ok {
+			continue
+		}
+		if other, ok := targets[ns]; ok {
+			return fmt.Errorf("namespace %s cannot be mapped to %s, it is used by other components", other, ns)
+		}
+	}
+
+	return nil
+}
+
+// relocateNamespaces moves the objects of the given manifests from their default namespace to the mapped one.
+// Besides the namespace of the objects, the namespace objects, the service account subjects, the webhook
+// and api services, the cert-manager CA injection annotations and the service DNS names are relocated.
+// The default namespace exists in every cluster and is not created by the manifests, a namespace object
+// is added for the namespace it is mapped to.
+func relocateNamespaces(content []byte, namespaces map[string]string) ([]byte, error) {
+	if len(namespaces) == 0 {
+		return content, nil
+	}
+
+	objects, err := kubeutils.YamlToUnstructructured(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert yaml to unstructured: %w", err)
+	}
+
+	var fromDefault bool
+	for _, obj := range objects {
+		if obj.GetKind() == "Namespace" {
+			if mapped, ok := namespaces[obj.GetName()]; ok {
+				obj.SetName(mapped)
+			}
+			continue
+		}
+
+		if mapped, ok := namespaces[obj.GetNamespace()]; ok {
+			fromDefault = fromDefault || obj.GetNamespace() == env.DefaultExternalSecretsNamespace
+			obj.SetNamespace(mapped)
+		}
+
+		if err := relocateReferences(obj, namespaces); err != nil {
+			return nil, fmt.Errorf("failed to relocate %s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+	}
+
+	if fromDefault {
+		objects = append([]*unstructured.Unstructured{newNamespaceObject(namespaces[env.DefaultExternalSecretsNamespace])}, objects...)
+	}
+
+	return kubeutils.UnstructuredToYaml(objects)
+}
+
+// relocateReferences relocates the namespaces the given object refers to.
+func relocateReferences(obj *unstructured.Unstructured, namespaces map[string]string) error {
+	var paths [][]string
+	switch obj.GetKind() {
+	case "RoleBinding", "ClusterRoleBinding":
+		paths = append(paths, []string{"subjects"})
+	case "ValidatingWebhookConfiguration", "MutatingWebhookConfiguration":
+		paths = append(paths, []string{"webhooks"})
+	}
+
+	for _, path := range paths {
+		items, ok, err := unstructured.NestedSlice(obj.Object, path...)
+		if err != nil || !ok {
+			return err
+		}
+		for _, item := range items {
+			if m, ok := item.(map[string]any); ok {
+				relocateField(m, namespaces, "namespace")
+				relocateField(m, namespaces, "clientConfig", "service", "namespace")
+			}
+		}
+		if err := unstructured.SetNestedSlice(obj.Object, items, path...); err != nil {
+			return err
+		}
+	}
+
+	switch obj.GetKind() {
+	case "APIService":
+		relocateField(obj.Object, namespaces, "spec", "service", "namespace")
+	case "CustomResourceDefinition":
+		relocateField(obj.Object, namespaces, "spec", "conversion", "webhook", "clientConfig", "service", "namespace")
+	}
+
+	if annotations := obj.GetAnnotations(); annotations != nil {
+		for _, key := range certManagerCAInjectionAnnotations {
+			ns, name, ok := strings.Cut(annotations[key], "/")
+			if mapped, found := namespaces[ns]; ok && found {
+				annotations[key] = mapped + "/" + name
+			}
+		}
+		obj.SetAnnotations(annotations)
+	}
+
+	obj.Object = relocateServiceNames(obj.Object, namespaces).(map[string]any)
+
+	return nil
+}
+
+// relocateField sets the namespace at the given path of the object to the mapped one.
+func relocateField(obj map[string]any, namespaces map[string]string, path ...string) {
+	ns, ok, err := unstructured.NestedString(obj, path...)
+	if err != nil || !ok {
+		return
+	}
+	if mapped, ok := namespaces[ns]; ok {
+		_ = unstructured.SetNestedField(obj, mapped, path...)
+	}
+}
+
+// serviceNameRegexp matches the namespace of a service DNS name.
+var serviceNameRegexp = regexp.MustCompile(`([a-z0-9-]+)\.([a-z0-9-]+)\.svc\b`)
+
+// relocateServiceNames relocates the service DNS names found in the string values of the given value.
+func relocateServiceNames(value any, namespaces map[string]string) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = relocateServiceNames(item, namespaces)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = relocateServiceNames(item, namespaces)
+		}
+		return v
+	case string:
+		return serviceNameRegexp.ReplaceAllStringFunc(v, func(name string) string {
+			m := serviceNameRegexp.FindStringSubmatch(name)
+			mapped, ok := namespaces[m[2]]
+			if !ok || name == apiServerServiceName {
+				return name
+			}
+			return m[1] + "." + mapped + ".svc"
+		})
+	default:
+		return v
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func newNamespaceObject(name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("Namespace")
+	obj.SetName(name)
+
+	return obj
+}
@@ -0,0 +1,195 @@
+package bootstrap
+
+import (
+	"testing"
+
+	"github.com/open-component-model/mpas/internal/kubeutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestRelocateNamespaces(t *testing.T) {
+	content := []byte(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: external-secrets-webhook
+  namespace: default
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: external-secrets-controller
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: external-secrets-controller
+subjects:
+- kind: ServiceAccount
+  name: external-secrets
+  namespace: default
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: externalsecret-validate
+  annotations:
+    cert-manager.io/inject-ca-from: default/external-secrets-webhook
+webhooks:
+- name: validate.externalsecret.external-secrets.io
+  clientConfig:
+    service:
+      name: external-secrets-webhook
+      namespace: default
+      path: /validate-external-secrets-io-v1beta1-externalsecret
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: external-secrets-webhook
+  namespace: default
+spec:
+  template:
+    spec:
+      containers:
+      - name: webhook
+        args:
+        - --dns-name=external-secrets-webhook.default.svc
+        - --api-server=https://kubernetes.default.svc
+        - --registry=registry.ocm-system.svc.cluster.local:5000
+`)
+
+	namespaces := map[string]string{
+		"default":    "tenant-external-secrets",
+		"ocm-system": "tenant-ocm-system",
+	}
+	relocated, err := relocateNamespaces(content, namespaces)
+	require.NoError(t, err)
+
+	objects, err := kubeutils.YamlToUnstructructured(relocated)
+	require.NoError(t, err)
+	require.Len(t, objects, 5)
+
+	// the default namespace is not created by the manifests
+	assert.Equal(t, "Namespace", objects[0].GetKind())
+	assert.Equal(t, "tenant-external-secrets", objects[0].GetName())
+
+	assert.Equal(t, "tenant-external-secrets", objects[1].GetNamespace())
+
+	subjects, _, err := unstructured.NestedSlice(objects[2].Object, "subjects")
+	require.NoError(t, err)
+	assert.Equal(t, "tenant-external-secrets", subjects[0].(map[string]any)["namespace"])
+
+	assert.Equal(t, "tenant-external-secrets/external-secrets-webhook", objects[3].GetAnnotations()["cert-manager.io/inject-ca-from"])
+	webhooks, _, err := unstructured.NestedSlice(objects[3].Object, "webhooks")
+	require.NoError(t, err)
+	ns, _, err := unstructured.NestedString(webhooks[0].(map[string]any), "clientConfig", "service", "namespace")
+	require.NoError(t, err)
+	assert.Equal(t, "tenant-external-secrets", ns)
+
+	containers, _, err := unstructured.NestedSlice(objects[4].Object, "spec", "template", "spec", "containers")
+	require.NoError(t, err)
+	assert.Equal(t, []any{
+		"--dns-name=external-secrets-webhook.tenant-external-secrets.svc",
+		"--api-server=https://kubernetes.default.svc",
+		"--registry=registry.tenant-ocm-system.svc.cluster.local:5000",
+	}, containers[0].(map[string]any)["args"])
+}
+
+func TestRelocateNamespacesCreatedNamespace(t *testing.T) {
+	relocated, err := relocateNamespaces(ocmCertificate, map[string]string{"ocm-system": "tenant-ocm-system"})
+	require.NoError(t, err)
+
+	objects, err := kubeutils.YamlToUnstructructured(relocated)
+	require.NoError(t, err)
+	// the namespace is created by the ocm-controller manifests, none is added
+	require.Len(t, objects, 1)
+	assert.Equal(t, "tenant-ocm-system", objects[0].GetNamespace())
+	dnsNames, _, err := unstructured.NestedStringSlice(objects[0].Object, "spec", "dnsNames")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"registry.tenant-ocm-system.svc.cluster.local"}, dnsNames)
+
+	unchanged, err := relocateNamespaces(ocmCertificate, nil)
+	require.NoError(t, err)
+	assert.Equal(t, ocmCertificate, unchanged)
+}
+
+func TestValidateNamespaces(t *testing.T) {
+	testCases := []struct {
+		name       string
+		namespaces map[string]string
+		err        string
+	}{
+		{
+			name: "valid mapping",
+			namespaces: map[string]string{
+				"default":    "tenant-external-secrets",
+				"ocm-system": "tenant-ocm-system",
+			},
+		},
+		{
+			name:       "unknown namespace",
+			namespaces: map[string]string{"kube-system": "tenant-kube-system"},
+			err:        "namespace kube-system cannot be mapped",
+		},
+		{
+			name:       "invalid namespace name",
+			namespaces: map[string]string{"default": "Tenant_Default"},
+			err:        `invalid namespace "Tenant_Default" for default`,
+		},
+		{
+			name: "namespaces mapped to the same namespace",
+			namespaces: map[string]string{
+				"mpas-system": "tenant-system",
+				"ocm-system":  "tenant-system",
+			},
+			err: "namespaces mpas-system and ocm-system cannot both be mapped to tenant-system",
+		},
+		{
+			name:       "namespace mapped to a default namespace in use",
+			namespaces: map[string]string{"default": "ocm-system"},
+			err:        "namespace default cannot be mapped to ocm-system, it is used by other components",
+		},
+		{
+			name: "namespaces swapped",
+			namespaces: map[string]string{
+				"mpas-system": "ocm-system",
+				"ocm-system":  "mpas-system",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateNamespaces(tc.namespaces)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAdoptNamespaces(t *testing.T) {
+	state := newBootstrapState()
+	state.Namespaces = map[string]string{
+		"flux-system": "tenant-flux-system",
+		"default":     "tenant-external-secrets",
+	}
+
+	b := &Bootstrap{
+		options: options{
+			namespaces: map[string]string{"flux-system": "tenant-flux-system"},
+		},
+	}
+	require.NoError(t, b.adoptNamespaces(state))
+	assert.Equal(t, "tenant-external-secrets", b.namespace("default"))
+	assert.Equal(t, "ocm-system", b.namespace("ocm-system"))
+
+	b.namespaces = map[string]string{"default": "other-external-secrets"}
+	assert.ErrorContains(t, b.adoptNamespaces(state), "namespace default was mapped to tenant-external-secrets when bootstrapping, not to other-external-secrets")
+
+	b.namespaces = map[string]string{"ocm-system": "tenant-ocm-system"}
+	assert.ErrorContains(t, b.adoptNamespaces(state), "namespace ocm-system was mapped to ocm-system when bootstrapping, not to tenant-ocm-system")
+}
@@ -0,0 +1,256 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	kustypes "sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// patchesRepositoryDir is the directory of the target path the patches of the components are committed to.
+	patchesRepositoryDir = "patches"
+	// patchesSourceIgnore keeps flux from applying the committed patches as manifests.
+	patchesSourceIgnore = "# patches applied by mpas when generating the component manifests\n*\n"
+)
+
+// WithPatchesDir sets the directory holding the kustomize patches of the components. Each subdirectory
+// is named after a component and holds its strategic merge and JSON6902 patches.
+func WithPatchesDir(patchesDir string) Option {
+	return func(o *options) {
+		o.patchesDir = patchesDir
+	}
+}
+
+// patchFile is a file holding kustomize patches of a component.
+type patchFile struct {
+	name    string
+	content string
+}
+
+// jsonPatch is a JSON6902 patch document, its operations are applied to the objects matching the target.
+type jsonPatch struct {
+	Target *kustypes.Selector `json:"target"`
+	Patch  []map[string]any   `json:"patch"`
+}
+
+// loadPatchesDir reads the patch files of the components from the given directory, keyed by component.
+// The .yaml, .yml and .json files of each subdirectory are read in alphabetical order, the other files are ignored.
+func loadPatchesDir(dir string) (map[string][]patchFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read patches directory: %w", err)
+	}
+
+	patches := make(map[string][]patchFile)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		files, err := os.ReadDir(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read patches of %s: %w", entry.Name(), err)
+		}
+
+		for _, file := range files {
+			if file.IsDir() || !isPatchFile(file.Name()) {
+				continue
+			}
+
+			content, err := os.ReadFile(filepath.Join(dir, entry.Name(), file.Name()))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read patch %s of %s: %w", file.Name(), entry.Name(), err)
+			}
+
+			patches[entry.Name()] = append(patches[entry.Name()], patchFile{
+				name:    file.Name(),
+				content: string(content),
+			})
+		}
+
+		if _, err := kustomizationPatches(patches[entry.Name()]); err != nil {
+			return nil, fmt.Errorf("invalid patches of %s: %w", entry.Name(), err)
+		}
+	}
+
+	return patches, nil
+}
+
+func isPatchFile(name string) bool {
+	switch filepath.Ext(name) {
+	case ".yaml", ".yml", ".json":
+		return true
+	default:
+		return false
+	}
+}
+
+// kustomizationPatches converts the documents of the given patch files to kustomize patches. A document
+// with a target is a JSON6902 patch, any other document is a strategic merge patch of the object it names.
+func kustomizationPatches(files []patchFile) ([]kustypes.Patch, error) {
+	var patches []kustypes.Patch
+	for _, file := range files {
+		decoder := k8syaml.NewYAMLOrJSONDecoder(strings.NewReader(file.content), len(file.content))
+		for {
+			var doc map[string]any
+			if err := decoder.Decode(&doc); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return nil, fmt.Errorf("failed to decode patch %s: %w", file.name, err)
+			}
+			if len(doc) == 0 {
+				continue
+			}
+
+			patch, err := kustomizationPatch(doc)
+			if err != nil {
+				return nil, fmt.Errorf("invalid patch %s: %w", file.name, err)
+			}
+			patches = append(patches, patch)
+		}
+	}
+
+	return patches, nil
+}
+
+func kustomizationPatch(doc map[string]any) (kustypes.Patch, error) {
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		return kustypes.Patch{}, fmt.Errorf("failed to marshal patch: %w", err)
+	}
+
+	if _, ok := doc["target"]; !ok {
+		if _, ok := doc["kind"]; !ok {
+			return kustypes.Patch{}, fmt.Errorf("a strategic merge patch must have a kind, a JSON6902 patch a target")
+		}
+		return kustypes.Patch{Patch: string(data)}, nil
+	}
+
+	var p jsonPatch
+	if err := yaml.UnmarshalStrict(data, &p); err != nil {
+		return kustypes.Patch{}, fmt.Errorf("failed to decode JSON6902 patch: %w", err)
+	}
+	if len(p.Patch) == 0 {
+		return kustypes.Patch{}, fmt.Errorf("JSON6902 patch has no operations")
+	}
+
+	ops, err := yaml.Marshal(p.Patch)
+	if err != nil {
+		return kustypes.Patch{}, fmt.Errorf("failed to marshal JSON6902 patch: %w", err)
+	}
+
+	return kustypes.Patch{Patch: string(ops), Target: p.Target}, nil
+}
+
+// validatePatches fails if patches are given for a component that is not installed.
+func validatePatches(patches map[string][]patchFile, comps []string) error {
+	installed := make(map[string]bool, len(comps))
+	for _, comp := range comps {
+		installed[path.Base(comp)] = true
+	}
+
+	for _, comp := range sortedKeys(patches) {
+		if !installed[comp] {
+			return fmt.Errorf("patches given for %s, which is not a component of the bootstrap component", comp)
+		}
+	}
+
+	return nil
+}
+
+// componentPatches returns the kustomize patches of the given component.
+func (b *Bootstrap) componentPatches(name string) ([]kustypes.Patch, error) {
+	return kustomizationPatches(b.patches[path.Base(name)])
+}
+
+// patchCommitFiles returns the files committing the patches of the given component to the management repository,
+// along with the .sourceignore file of the patches directory, formatted for the given provider. Flux commits
+// through a clone of the management repository, its files are not formatted for the provider.
+func (b *Bootstrap) patchCommitFiles(name, provider string) []gitprovider.CommitFile {
+	files := b.patches[path.Base(name)]
+	if len(files) == 0 {
+		return nil
+	}
+
+	commitFiles := []gitprovider.CommitFile{
+		newCommitFile(filepath.Join(b.manifestsPath(), patchesRepositoryDir, ".sourceignore"), SetProviderDataFormat(provider, []byte(patchesSourceIgnore))),
+	}
+	for _, file := range files {
+		commitFiles = append(commitFiles, newCommitFile(filepath.Join(b.manifestsPath(), patchesRepositoryDir, path.Base(name), file.name),
+			SetProviderDataFormat(provider, []byte(file.content))))
+	}
+
+	return commitFiles
+}
+
+// patchedComponents returns the components with patches, in alphabetical order.
+func (b *Bootstrap) patchedComponents() []string {
+	var comps []string
+	for comp, files := range b.patches {
+		if len(files) > 0 {
+			comps = append(comps, comp)
+		}
+	}
+	sort.Strings(comps)
+
+	return comps
+}
+
+// fetchPatches reads the patches of the given components from the management repository,
+// where they were committed when bootstrapping. They are read from a clone, as not all
+// git providers can read files through their API.
+func (b *Bootstrap) fetchPatches(ctx context.Context, comps []string) (map[string][]patchFile, error) {
+	fileClient := newPlainGitRepository(b.gitClient, b.committer).Files()
+	patches := make(map[string][]patchFile, len(comps))
+	for _, comp := range comps {
+		files, err := fileClient.Get(ctx, filepath.Join(b.manifestsPath(), patchesRepositoryDir, comp), b.defaultBranch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch patches of %s: %w", comp, err)
+		}
+
+		for _, file := range files {
+			if file.Path == nil || file.Content == nil || !isPatchFile(*file.Path) {
+				continue
+			}
+			patches[comp] = append(patches[comp], patchFile{
+				name:    path.Base(*file.Path),
+				content: *file.Content,
+			})
+		}
+		sort.Slice(patches[comp], func(i, j int) bool {
+			return patches[comp][i].name < patches[comp][j].name
+		})
+
+		if _, err := kustomizationPatches(patches[comp]); err != nil {
+			return nil, fmt.Errorf("invalid patches of %s: %w", comp, err)
+		}
+	}
+
+	return patches, nil
+}
+
+// patchFilesReaders returns the readers of the given commit files keyed by path, as committed by the flux installer.
+func patchFilesReaders(files []gitprovider.CommitFile) map[string]io.Reader {
+	readers := make(map[string]io.Reader, len(files))
+	for _, file := range files {
+		readers[*file.Path] = strings.NewReader(*file.Content)
+	}
+
+	return readers
+}
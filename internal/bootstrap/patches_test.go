@@ -0,0 +1,201 @@
+package bootstrap
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/open-component-model/mpas/internal/env"
+	"github.com/open-component-model/mpas/internal/kubeutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var testResourcesPatch = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo-controller
+  namespace: ocm-system
+spec:
+  template:
+    spec:
+      nodeSelector:
+        kubernetes.io/os: linux
+      containers:
+      - name: manager
+        resources:
+          limits:
+            memory: 512Mi
+`
+
+var testArgsPatch = `target:
+  kind: Deployment
+  name: foo-controller
+patch:
+- op: add
+  path: /spec/template/spec/containers/0/args
+  value:
+  - --concurrent=10
+`
+
+func writePatches(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	return dir
+}
+
+func TestLoadPatchesDir(t *testing.T) {
+	dir := writePatches(t, map[string]string{
+		"foo-controller/resources.yaml": testResourcesPatch,
+		"foo-controller/args.yaml":      testArgsPatch,
+		"foo-controller/README.md":      "# patches",
+		"flux/.keep":                    "",
+		"README.md":                     "# patches",
+	})
+
+	patches, err := loadPatchesDir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]patchFile{
+		"foo-controller": {
+			{name: "args.yaml", content: testArgsPatch},
+			{name: "resources.yaml", content: testResourcesPatch},
+		},
+	}, patches)
+
+	dir = writePatches(t, map[string]string{
+		"foo-controller/invalid.yaml": "spec:\n  replicas: 2\n",
+	})
+	_, err = loadPatchesDir(dir)
+	assert.ErrorContains(t, err, "invalid patches of foo-controller: invalid patch invalid.yaml")
+}
+
+func TestKustomizationPatches(t *testing.T) {
+	patches, err := kustomizationPatches([]patchFile{
+		{name: "args.yaml", content: testArgsPatch},
+		{name: "resources.yaml", content: testResourcesPatch + "---\n" + testResourcesPatch},
+	})
+	require.NoError(t, err)
+	require.Len(t, patches, 3)
+
+	assert.Equal(t, "Deployment", patches[0].Target.Kind)
+	assert.Equal(t, "foo-controller", patches[0].Target.Name)
+	assert.YAMLEq(t, `- op: add
+  path: /spec/template/spec/containers/0/args
+  value:
+  - --concurrent=10
+`, patches[0].Patch)
+
+	assert.Nil(t, patches[1].Target)
+	assert.YAMLEq(t, testResourcesPatch, patches[1].Patch)
+
+	_, err = kustomizationPatches([]patchFile{{name: "ops.yaml", content: "target:\n  kind: Deployment\npatch: []\n"}})
+	assert.ErrorContains(t, err, "JSON6902 patch has no operations")
+
+	_, err = kustomizationPatches([]patchFile{{name: "ops.yaml", content: "target:\n  kind: Deployment\npatches: []\n"}})
+	assert.ErrorContains(t, err, "failed to decode JSON6902 patch")
+}
+
+func TestValidatePatches(t *testing.T) {
+	patches := map[string][]patchFile{
+		"foo-controller": {{name: "args.yaml", content: testArgsPatch}},
+	}
+
+	assert.NoError(t, validatePatches(patches, []string{env.FluxName, "my-org/foo-controller"}))
+	assert.ErrorContains(t, validatePatches(patches, []string{env.FluxName}),
+		"patches given for foo-controller, which is not a component of the bootstrap component")
+}
+
+func TestGenerateManifestWithPatches(t *testing.T) {
+	repo := &mockRepository{
+		cv: []*mockComponentAccess{
+			newFakeControllerComponent("foo-controller"),
+		},
+	}
+
+	b := &Bootstrap{
+		patches: map[string][]patchFile{
+			"foo-controller": {
+				{name: "args.yaml", content: testArgsPatch},
+				{name: "resources.yaml", content: testResourcesPatch},
+			},
+		},
+	}
+	patches, err := b.componentPatches("my-org/foo-controller")
+	require.NoError(t, err)
+
+	manifest, err := generateManifest(repo, "foo-controller", newComponentReference(t, "foo-controller", "v1.0.0", nil), nil, patches)
+	require.NoError(t, err)
+
+	objects, err := kubeutils.YamlToUnstructructured(manifest)
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+
+	nodeSelector, _, err := unstructured.NestedStringMap(objects[0].Object, "spec", "template", "spec", "nodeSelector")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"kubernetes.io/os": "linux"}, nodeSelector)
+
+	containers, _, err := unstructured.NestedSlice(objects[0].Object, "spec", "template", "spec", "containers")
+	require.NoError(t, err)
+	container := containers[0].(map[string]any)
+	assert.Equal(t, "ghcr.io/new-user/foo-controller:v1.0.0", container["image"])
+	assert.Equal(t, []any{"--concurrent=10"}, container["args"])
+	assert.Equal(t, map[string]any{"limits": map[string]any{"memory": "512Mi"}}, container["resources"])
+}
+
+func TestPatchCommitFiles(t *testing.T) {
+	b := &Bootstrap{
+		patches: map[string][]patchFile{
+			"foo-controller": {{name: "args.yaml", content: testArgsPatch}},
+		},
+		options: options{
+			targetPath: "clusters/my-cluster",
+		},
+	}
+
+	files := b.patchCommitFiles("my-org/foo-controller", "")
+	require.Len(t, files, 2)
+	assert.Equal(t, "clusters/my-cluster/patches/.sourceignore", *files[0].Path)
+	assert.Equal(t, patchesSourceIgnore, *files[0].Content)
+	assert.Equal(t, "clusters/my-cluster/patches/foo-controller/args.yaml", *files[1].Path)
+	assert.Equal(t, testArgsPatch, *files[1].Content)
+
+	assert.Empty(t, b.patchCommitFiles(env.OcmControllerName, ""))
+	assert.Equal(t, []string{"foo-controller"}, b.patchedComponents())
+}
+
+func TestFetchPatches(t *testing.T) {
+	url := newBareRepository(t)
+	b := &Bootstrap{
+		url: url,
+		options: options{
+			targetPath:    "clusters/my-cluster",
+			defaultBranch: "main",
+		},
+	}
+	ctx := context.Background()
+
+	_, err := newPlainGitRepository(cloneFunc(url), nil).Commits().Create(ctx, "main", "Add patches", []gitprovider.CommitFile{
+		newCommitFile("clusters/my-cluster/patches/foo-controller/resources.yaml", testResourcesPatch),
+		newCommitFile("clusters/my-cluster/patches/foo-controller/args.yaml", testArgsPatch),
+		newCommitFile("clusters/my-cluster/patches/foo-controller/README.md", "not a patch"),
+	})
+	require.NoError(t, err)
+
+	// the patches are read from a clone, sorted by name
+	patches, err := b.fetchPatches(ctx, []string{"foo-controller"})
+	require.NoError(t, err)
+	assert.Equal(t, []patchFile{
+		{name: "args.yaml", content: testArgsPatch},
+		{name: "resources.yaml", content: testResourcesPatch},
+	}, patches["foo-controller"])
+
+	_, err = b.fetchPatches(ctx, []string{"bar-controller"})
+	assert.ErrorContains(t, err, "failed to fetch patches of bar-controller")
+}
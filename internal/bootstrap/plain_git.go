@@ -0,0 +1,343 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/fluxcd/pkg/git"
+	"github.com/fluxcd/pkg/git/gogit"
+)
+
+// WithRepositoryURL sets the URL of an existing management repository hosted on a plain git server.
+// The repository is neither looked up nor created through a git provider API, the manifests
+// are committed through a local clone and pushed to it.
+func WithRepositoryURL(repositoryURL string) Option {
+	return func(o *options) {
+		o.repositoryURL = repositoryURL
+	}
+}
+
+// WithUsername sets the username used to authenticate against the git server over HTTP(S).
+// It defaults to the owner for git providers.
+func WithUsername(username string) Option {
+	return func(o *options) {
+		o.username = username
+	}
+}
+
+// isPlainGit returns true if the management repository is hosted on a plain git server.
+func (b *Bootstrap) isPlainGit() bool {
+	return b.repositoryURL != ""
+}
+
+// preparePlainGitRepository sets up a repository that commits through a local clone of the
+// existing management repository. The clone URL is the repository URL.
+func (b *Bootstrap) preparePlainGitRepository(ctx context.Context) error {
+	b.setPlainGitURLs()
+
+	// fail early if the repository cannot be cloned
+	dir, err := mkdirTempDir("mpas-git")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := b.gitClient(ctx, dir); err != nil {
+		return err
+	}
+
+	b.repository = newPlainGitRepository(b.gitClient, b.committer)
+
+	return nil
+}
+
+// setPlainGitURLs sets the clone URLs to the repository URL.
+func (b *Bootstrap) setPlainGitURLs() {
+	b.url = b.repositoryURL
+	if isSSHURL(b.repositoryURL) {
+		b.sshURL = b.repositoryURL
+	}
+}
+
+// gitAuthOptions returns the options used to authenticate against the management repository.
+// For a plain git server they are derived from the repository URL, over SSH the private key file is used.
+func (b *Bootstrap) gitAuthOptions() (*git.AuthOptions, error) {
+	if !b.isPlainGit() {
+		username := b.owner
+		if b.username != "" {
+			username = b.username
+		}
+
+		opts := &git.AuthOptions{Transport: git.HTTPS, Username: username, Password: b.token}
+		if b.transportType == "http" {
+			opts.Transport = git.HTTP
+		}
+
+		return opts, nil
+	}
+
+	u, err := url.Parse(b.repositoryURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse repository URL %q: %w", b.repositoryURL, err)
+	}
+
+	data := map[string][]byte{
+		"username": []byte(b.username),
+		"password": []byte(b.token),
+	}
+	if isSSHURL(b.repositoryURL) {
+		identity, err := os.ReadFile(b.privateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read private key file: %w", err)
+		}
+		data = map[string][]byte{
+			"identity": identity,
+			"password": []byte(b.privateKeyPassphrase),
+		}
+	}
+
+	return git.NewAuthOptions(*u, data)
+}
+
+func validatePlainGitOptions(opts *options) error {
+	u, err := url.Parse(opts.repositoryURL)
+	if err != nil {
+		return fmt.Errorf("invalid repository URL %q: %w", opts.repositoryURL, err)
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		if opts.privateKeyFile != "" {
+			return fmt.Errorf("a private key file can only be used with an SSH repository URL")
+		}
+	case "ssh":
+		if opts.privateKeyFile == "" {
+			return fmt.Errorf("a private key file must be set to use an SSH repository URL")
+		}
+	default:
+		return fmt.Errorf("unsupported scheme %q of repository URL %q, must be one of http, https or ssh", u.Scheme, opts.repositoryURL)
+	}
+
+	if opts.sshKeyAlgorithm != "" {
+		return fmt.Errorf("a deploy key cannot be generated for a plain git server, use a private key file instead")
+	}
+
+	if opts.deleteRepository {
+		return fmt.Errorf("a repository on a plain git server cannot be deleted")
+	}
+
+	return nil
+}
+
+// newGitClient returns a git client storing the repository in dir.
+func newGitClient(dir string, auth *git.AuthOptions) (*gogit.Client, error) {
+	clientOpts := []gogit.ClientOption{gogit.WithDiskStorage(), gogit.WithFallbackToDefaultKnownHosts()}
+	if auth.Transport == git.HTTP {
+		clientOpts = append(clientOpts, gogit.WithInsecureCredentialsOverHTTP())
+	}
+
+	gitClient, err := gogit.NewClient(dir, auth, clientOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a Git client: %w", err)
+	}
+
+	return gitClient, nil
+}
+
+// repositoryNameFromURL returns the name of the repository of the given URL.
+func repositoryNameFromURL(repositoryURL string) string {
+	name := repositoryURL
+	if u, err := url.Parse(repositoryURL); err == nil {
+		name = u.Path
+	}
+
+	return strings.TrimSuffix(path.Base(strings.TrimSuffix(name, "/")), ".git")
+}
+
+func isSSHURL(repositoryURL string) bool {
+	return strings.HasPrefix(repositoryURL, "ssh://")
+}
+
+// plainGitRepository is a management repository hosted on a plain git server. Every commit
+// is made in a fresh clone and pushed, as flux pushes its own manifests in between.
+type plainGitRepository struct {
+	gitprovider.UserRepository
+
+	commitClient *plainGitCommitClient
+}
+
+var _ gitprovider.UserRepository = &plainGitRepository{}
+
+// newPlainGitRepository returns a repository committing through clones made by the given function,
+// with the given committer.
+func newPlainGitRepository(clone func(ctx context.Context, dir string) (*gogit.Client, error), committer *committer) *plainGitRepository {
+	return &plainGitRepository{
+		commitClient: &plainGitCommitClient{
+			clone:     clone,
+			committer: committer,
+		},
+	}
+}
+
+// commitLocally returns the given repository of a git provider committing through local clones if the commits
+// have a configured author or are signed, as the git provider APIs commit as the owner of the token without a signature.
+func (b *Bootstrap) commitLocally(repo gitprovider.UserRepository) gitprovider.UserRepository {
+	if !b.committer.local() {
+		return repo
+	}
+
+	r := newPlainGitRepository(b.gitClient, b.committer)
+	r.UserRepository = repo
+
+	return r
+}
+
+func (r *plainGitRepository) Commits() gitprovider.CommitClient {
+	return r.commitClient
+}
+
+// Files returns a client reading the files of a fresh clone of the repository.
+func (r *plainGitRepository) Files() gitprovider.FileClient {
+	return &plainGitFileClient{clone: r.commitClient.clone}
+}
+
+type plainGitFileClient struct {
+	clone func(ctx context.Context, dir string) (*gogit.Client, error)
+}
+
+var _ gitprovider.FileClient = &plainGitFileClient{}
+
+// Get returns the files directly below the given directory of a fresh clone of the repository,
+// the branch is the one it is cloned at.
+func (c *plainGitFileClient) Get(ctx context.Context, dirPath, _ string, _ ...gitprovider.FilesGetOption) ([]*gitprovider.CommitFile, error) {
+	dir, err := mkdirTempDir("mpas-git")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	gitClient, err := c.clone(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	defer gitClient.Close()
+
+	root, err := securejoin.SecureJoin(gitClient.Path(), dirPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path %q: %w", dirPath, err)
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory %q: %w", dirPath, err)
+	}
+
+	var files []*gitprovider.CommitFile
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(root, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %q: %w", entry.Name(), err)
+		}
+
+		files = append(files, &gitprovider.CommitFile{
+			Path:    gitprovider.StringVar(path.Join(filepath.ToSlash(dirPath), entry.Name())),
+			Content: gitprovider.StringVar(string(content)),
+		})
+	}
+
+	return files, nil
+}
+
+type plainGitCommitClient struct {
+	gitprovider.CommitClient
+
+	clone func(ctx context.Context, dir string) (*gogit.Client, error)
+	// committer sets the author of the commits and signs them
+	committer *committer
+	// mu is used to serialize the commits
+	mu sync.Mutex
+}
+
+var _ gitprovider.CommitClient = &plainGitCommitClient{}
+
+// Create writes the given files to a fresh clone of the repository, commits and pushes them.
+// If the files are already up to date, the current head is returned.
+func (c *plainGitCommitClient) Create(ctx context.Context, _, message string, files []gitprovider.CommitFile) (gitprovider.Commit, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	dir, err := mkdirTempDir("mpas-git")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	gitClient, err := c.clone(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		if file.Path == nil {
+			continue
+		}
+
+		path, err := securejoin.SecureJoin(gitClient.Path(), *file.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve path %q: %w", *file.Path, err)
+		}
+
+		// a file without content marks a deletion
+		if file.Content == nil {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("failed to remove file %q: %w", path, err)
+			}
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create directory for %q: %w", path, err)
+		}
+
+		if err := os.WriteFile(path, []byte(*file.Content), 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write file %q: %w", path, err)
+		}
+	}
+
+	sha, err := c.committer.commit(gitClient, "MPAS", message)
+	if err != nil {
+		if !errors.Is(err, git.ErrNoStagedFiles) {
+			return nil, fmt.Errorf("failed to commit manifests: %w", err)
+		}
+
+		if sha, err = gitClient.Head(); err != nil {
+			return nil, fmt.Errorf("failed to get head: %w", err)
+		}
+	} else if err := gitClient.Push(ctx); err != nil {
+		return nil, fmt.Errorf("failed to push manifests: %w", err)
+	}
+
+	return &localCommit{
+		info: gitprovider.CommitInfo{
+			Sha:     sha,
+			Message: message,
+		},
+	}, nil
+}
@@ -0,0 +1,173 @@
+package bootstrap
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/fluxcd/pkg/git"
+	"github.com/fluxcd/pkg/git/gogit"
+	"github.com/fluxcd/pkg/git/repository"
+	gogitv5 "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+// newBareRepository returns the URL of a local bare repository with an initial commit on main.
+func newBareRepository(t *testing.T) string {
+	t.Helper()
+
+	bare := t.TempDir()
+	_, err := gogitv5.PlainInit(bare, true)
+	require.NoError(t, err)
+
+	work := t.TempDir()
+	repo, err := gogitv5.PlainInit(work, false)
+	require.NoError(t, err)
+	require.NoError(t, repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName("main"))))
+	_, err = repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{bare}})
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(work, "README.md"), []byte("mpas\n"), 0o644))
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+	_, err = wt.Add("README.md")
+	require.NoError(t, err)
+	_, err = wt.Commit("initial", &gogitv5.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	require.NoError(t, err)
+	require.NoError(t, repo.Push(&gogitv5.PushOptions{
+		RefSpecs: []config.RefSpec{"refs/heads/main:refs/heads/main"},
+	}))
+
+	return "file://" + bare
+}
+
+// cloneFunc returns a function cloning the main branch of the repository at the given URL.
+func cloneFunc(url string) func(ctx context.Context, dir string) (*gogit.Client, error) {
+	return func(ctx context.Context, dir string) (*gogit.Client, error) {
+		c, err := gogit.NewClient(dir, &git.AuthOptions{Transport: git.HTTPS}, gogit.WithDiskStorage())
+		if err != nil {
+			return nil, err
+		}
+		_, err = c.Clone(ctx, url, repository.CloneOptions{
+			CheckoutStrategy: repository.CheckoutStrategy{Branch: "main"},
+		})
+		return c, err
+	}
+}
+
+func TestPlainGitRepository(t *testing.T) {
+	clone := cloneFunc(newBareRepository(t))
+
+	repo := newPlainGitRepository(clone, nil)
+	commit, err := repo.Commits().Create(context.Background(), "main", "Add manifests", []gitprovider.CommitFile{
+		newCommitFile("clusters/ocm-system/ocm-controller.yaml", "kind: Deployment\n"),
+		newCommitFile("../../escape.yaml", "kind: Secret\n"),
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, commit.Get().Sha)
+
+	// the files are pushed to the repository
+	dir := t.TempDir()
+	c, err := clone(context.Background(), dir)
+	require.NoError(t, err)
+	head, err := c.Head()
+	require.NoError(t, err)
+	assert.Equal(t, commit.Get().Sha, head)
+	content, err := os.ReadFile(filepath.Join(dir, "clusters", "ocm-system", "ocm-controller.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "kind: Deployment\n", string(content))
+	_, err = os.Stat(filepath.Join(dir, "escape.yaml"))
+	require.NoError(t, err, "expected the path to be confined to the repository")
+
+	// committing the same files again does not create a new commit
+	again, err := repo.Commits().Create(context.Background(), "main", "Add manifests", []gitprovider.CommitFile{
+		newCommitFile("clusters/ocm-system/ocm-controller.yaml", "kind: Deployment\n"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, commit.Get().Sha, again.Get().Sha)
+
+	// the files of a directory are read from a clone
+	files, err := repo.Files().Get(context.Background(), "clusters/ocm-system", "main")
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "clusters/ocm-system/ocm-controller.yaml", *files[0].Path)
+	assert.Equal(t, "kind: Deployment\n", *files[0].Content)
+
+	_, err = repo.Files().Get(context.Background(), "clusters/missing", "main")
+	assert.ErrorContains(t, err, "failed to read directory")
+
+	// a file without content is removed
+	removed, err := repo.Commits().Create(context.Background(), "main", "Remove manifests", []gitprovider.CommitFile{
+		{Path: ptr.To("clusters/ocm-system/ocm-controller.yaml")},
+	})
+	require.NoError(t, err)
+	assert.NotEqual(t, commit.Get().Sha, removed.Get().Sha)
+}
+
+func TestValidatePlainGitOptions(t *testing.T) {
+	testCases := []struct {
+		name string
+		opts options
+		err  string
+	}{
+		{
+			name: "https",
+			opts: options{repositoryURL: "https://git.example.com/ocm/mpas.git"},
+		},
+		{
+			name: "ssh with a private key file",
+			opts: options{repositoryURL: "ssh://git@git.example.com/ocm/mpas.git", privateKeyFile: "id_ed25519"},
+		},
+		{
+			name: "ssh without a private key file",
+			opts: options{repositoryURL: "ssh://git@git.example.com/ocm/mpas.git"},
+			err:  "a private key file must be set",
+		},
+		{
+			name: "https with a private key file",
+			opts: options{repositoryURL: "https://git.example.com/ocm/mpas.git", privateKeyFile: "id_ed25519"},
+			err:  "a private key file can only be used with an SSH repository URL",
+		},
+		{
+			name: "generated deploy key",
+			opts: options{repositoryURL: "ssh://git@git.example.com/ocm/mpas.git", privateKeyFile: "id_ed25519", sshKeyAlgorithm: "ecdsa"},
+			err:  "a deploy key cannot be generated",
+		},
+		{
+			name: "delete repository",
+			opts: options{repositoryURL: "https://git.example.com/ocm/mpas.git", deleteRepository: true},
+			err:  "cannot be deleted",
+		},
+		{
+			name: "unsupported scheme",
+			opts: options{repositoryURL: "file:///srv/git/mpas.git"},
+			err:  "unsupported scheme",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validatePlainGitOptions(&tc.opts)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestRepositoryNameFromURL(t *testing.T) {
+	assert.Equal(t, "mpas", repositoryNameFromURL("https://git.example.com/ocm/mpas.git"))
+	assert.Equal(t, "mpas", repositoryNameFromURL("ssh://git@git.example.com/ocm/mpas/"))
+}
@@ -0,0 +1,444 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/open-component-model/mpas/internal/env"
+	"github.com/open-component-model/mpas/internal/oci"
+	"github.com/open-component-model/mpas/internal/ocm"
+	"github.com/open-component-model/mpas/internal/printer"
+	om "github.com/open-component-model/ocm/pkg/contexts/ocm"
+	"github.com/open-component-model/ocm/pkg/contexts/ocm/utils"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	preflightPhase = "preflight"
+	// fluxManagedLabel is set by flux on the objects it applies
+	fluxManagedLabel = "kustomize.toolkit.fluxcd.io/name"
+)
+
+// fluxCRDs are the flux CRDs the bootstrap relies on, they must serve v1.
+var fluxCRDs = []string{
+	"gitrepositories.source.toolkit.fluxcd.io",
+	"kustomizations.kustomize.toolkit.fluxcd.io",
+}
+
+// certManagerCRD is the cert-manager CRD the certificates of the components rely on.
+const certManagerCRD = "certificates.cert-manager.io"
+
+// requiredPermissions are the cluster scoped resources the user must be allowed to create to install the components.
+var requiredPermissions = []authorizationv1.ResourceAttributes{
+	{Group: "apiextensions.k8s.io", Resource: "customresourcedefinitions", Verb: "create"},
+	{Group: "rbac.authorization.k8s.io", Resource: "clusterroles", Verb: "create"},
+	{Group: "", Resource: "namespaces", Verb: "create"},
+}
+
+// preflightCheck is a check run before the bootstrap commits anything.
+type preflightCheck struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// Preflight runs the preflight checks of a bootstrap on their own.
+type Preflight struct {
+	b *Bootstrap
+}
+
+// NewPreflight returns the preflight checks of a bootstrap with the given options. The git token is only checked
+// if a provider client is given, the cluster is not checked during a dry-run.
+func NewPreflight(providerClient gitprovider.Client, opts ...Option) (*Preflight, error) {
+	b := &Bootstrap{
+		providerClient: providerClient,
+	}
+
+	for _, opt := range opts {
+		opt(&b.options)
+	}
+
+	setDefaults(b)
+
+	if b.printer == nil {
+		return nil, fmt.Errorf("printer must be set")
+	}
+
+	if !b.dryRun && (b.kubeclient == nil || b.restClientGetter == nil) {
+		return nil, fmt.Errorf("kubeclient and rest client getter must be set")
+	}
+
+	return &Preflight{b: b}, nil
+}
+
+// Run runs the preflight checks and returns an error listing the failed ones.
+func (p *Preflight) Run(ctx context.Context) error {
+	octx := om.DefaultContext()
+	if _, err := utils.Configure(octx, ""); err != nil {
+		return fmt.Errorf("failed to configure ocm context: %w", err)
+	}
+	octx.LoggingContext().SetDefaultLevel(1)
+	if err := p.b.configureRegistryCredentials(ctx, octx); err != nil {
+		return err
+	}
+
+	if p.b.fromFile != "" {
+		bundlePath, cleanup, err := ocm.ResolveBundle(p.b.fromFile)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		p.b.bundlePath = bundlePath
+	}
+
+	return p.b.preflight(ctx, octx)
+}
+
+// preflight runs all preflight checks, so that every problem is reported at once, and fails if any of them failed.
+func (b *Bootstrap) preflight(ctx context.Context, octx om.Context) error {
+	started := time.Now()
+
+	var errs []error
+	for _, check := range b.preflightChecks(octx) {
+		check := check
+		if err := b.inSpinner(check.name, func() error {
+			return check.run(ctx)
+		}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		b.report.recordPhase(preflightPhase, "", phaseFailed, "", err, started)
+		return fmt.Errorf("preflight checks failed:\n%w", err)
+	}
+	b.report.recordPhase(preflightPhase, "", phaseCompleted, "", nil, started)
+
+	return nil
+}
+
+// preflightChecks returns the checks to run. The cluster is not checked during a dry-run, nor is the git token
+// as nothing is pushed.
+func (b *Bootstrap) preflightChecks(octx om.Context) []preflightCheck {
+	var checks []preflightCheck
+	if !b.dryRun {
+		checks = append(checks,
+			preflightCheck{
+				name: "Checking the Kubernetes version",
+				run:  b.checkKubernetesVersion,
+			},
+			preflightCheck{
+				name: "Checking the permissions to install the components",
+				run: func(ctx context.Context) error {
+					return checkPermissions(ctx, b.kubeclient)
+				},
+			},
+			b.fluxCheck(),
+			preflightCheck{
+				name: "Checking for conflicting installations",
+				run: func(ctx context.Context) error {
+					return checkConflicts(ctx, b.kubeclient, b.components, b.existingCertManager)
+				},
+			},
+		)
+
+		if b.existingCertManager {
+			checks = append(checks, preflightCheck{
+				name: fmt.Sprintf("Checking the existing %s", printer.BoldBlue(env.CertManagerName)),
+				run: func(ctx context.Context) error {
+					return checkExisting(ctx, b.kubeclient, env.CertManagerName, []string{certManagerCRD}, env.MinCertManagerVersion)
+				},
+			})
+		}
+
+		if b.fromFile != "" {
+			checks = append(checks, preflightCheck{
+				name: fmt.Sprintf("Checking the registry %s", printer.BoldBlue(b.registry)),
+				run:  b.checkRegistry,
+			})
+		}
+	}
+
+	checks = append(checks, preflightCheck{
+		name: fmt.Sprintf("Checking the bootstrap component in %s", printer.BoldBlue(b.bundleOrComponentLocation())),
+		run: func(ctx context.Context) error {
+			return b.checkBootstrapComponent(octx)
+		},
+	})
+
+	if !b.dryRun && b.providerClient != nil && !b.isPlainGit() {
+		checks = append(checks, preflightCheck{
+			name: "Checking the permissions of the git token",
+			run: func(ctx context.Context) error {
+				return checkTokenPermission(ctx, b.providerClient)
+			},
+		})
+	}
+
+	return checks
+}
+
+// fluxCheck returns the check of the installed flux, which must be adoptable if an existing flux is adopted
+// and must not be too old to be upgraded otherwise.
+func (b *Bootstrap) fluxCheck() preflightCheck {
+	if b.existingFlux {
+		return preflightCheck{
+			name: fmt.Sprintf("Checking the existing %s", printer.BoldBlue(env.FluxName)),
+			run: func(ctx context.Context) error {
+				return checkExisting(ctx, b.kubeclient, env.FluxName, fluxCRDs, env.MinFluxVersion)
+			},
+		}
+	}
+
+	return preflightCheck{
+		name: "Checking the installed flux",
+		run: func(ctx context.Context) error {
+			return checkFluxCRDs(ctx, b.kubeclient)
+		},
+	}
+}
+
+// checkKubernetesVersion fails if the Kubernetes server is older than the supported version.
+func (b *Bootstrap) checkKubernetesVersion(_ context.Context) error {
+	discovery, err := b.restClientGetter.ToDiscoveryClient()
+	if err != nil {
+		return fmt.Errorf("failed to create discovery client: %w", err)
+	}
+
+	info, err := discovery.ServerVersion()
+	if err != nil {
+		return fmt.Errorf("failed to get the Kubernetes version, is the cluster reachable? %w", err)
+	}
+
+	return checkKubernetesVersion(info.GitVersion)
+}
+
+// checkKubernetesVersion fails if the given Kubernetes version is older than the supported version.
+func checkKubernetesVersion(gitVersion string) error {
+	version, err := semver.NewVersion(gitVersion)
+	if err != nil {
+		return fmt.Errorf("failed to parse the Kubernetes version %s: %w", gitVersion, err)
+	}
+
+	// the prerelease allows versions like v1.28.3-eks-4f4795d
+	constraint, err := semver.NewConstraint(fmt.Sprintf(">=%s-0", env.MinKubernetesVersion))
+	if err != nil {
+		return err
+	}
+	if !constraint.Check(version) {
+		return fmt.Errorf("the Kubernetes version %s is not supported, upgrade the cluster to %s or later", gitVersion, env.MinKubernetesVersion)
+	}
+
+	return nil
+}
+
+// checkPermissions fails if the user is not allowed to create the cluster scoped resources of the components.
+func checkPermissions(ctx context.Context, c client.Client) error {
+	var denied []string
+	for _, attributes := range requiredPermissions {
+		attributes := attributes
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &attributes,
+			},
+		}
+		if err := c.Create(ctx, review); err != nil {
+			return fmt.Errorf("failed to review the permissions of the user: %w", err)
+		}
+
+		if !review.Status.Allowed {
+			denied = append(denied, attributes.Resource)
+		}
+	}
+
+	if len(denied) > 0 {
+		return fmt.Errorf("the user is not allowed to create %s, bootstrapping requires cluster-admin permissions",
+			strings.Join(denied, ", "))
+	}
+
+	return nil
+}
+
+// checkFluxCRDs fails if flux is installed at a version whose CRDs do not serve v1.
+func checkFluxCRDs(ctx context.Context, c client.Client) error {
+	for _, name := range fluxCRDs {
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		if err := c.Get(ctx, client.ObjectKey{Name: name}, crd); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("failed to get CRD %s: %w", name, err)
+		}
+
+		if !servesV1(crd) {
+			return fmt.Errorf("flux %s is installed and its CRD %s does not serve v1, upgrade flux to %s or uninstall it",
+				crdVersion(crd), name, env.DefaultFluxVer)
+		}
+	}
+
+	return nil
+}
+
+// checkExisting fails if the given component, adopted as it is installed, is not installed or if it is older than
+// the given minimum version. The version is read from the labels of its CRDs, which must all exist and serve v1.
+func checkExisting(ctx context.Context, c client.Client, component string, crds []string, minVersion string) error {
+	constraint, err := semver.NewConstraint(fmt.Sprintf(">=%s-0", minVersion))
+	if err != nil {
+		return err
+	}
+
+	for _, name := range crds {
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		if err := c.Get(ctx, client.ObjectKey{Name: name}, crd); err != nil {
+			if apierrors.IsNotFound(err) {
+				return fmt.Errorf("%s is not installed, its CRD %s does not exist, install it or bootstrap without adopting it", component, name)
+			}
+			return fmt.Errorf("failed to get CRD %s: %w", name, err)
+		}
+
+		if !servesV1(crd) {
+			return fmt.Errorf("%s %s is installed and its CRD %s does not serve v1, upgrade it to %s or later",
+				component, crdVersion(crd), name, minVersion)
+		}
+
+		// an unlabelled installation is only checked for the versions its CRDs serve
+		label, ok := crd.Labels["app.kubernetes.io/version"]
+		if !ok {
+			continue
+		}
+		version, err := semver.NewVersion(label)
+		if err != nil {
+			return fmt.Errorf("failed to parse the version %s of %s: %w", label, component, err)
+		}
+		if !constraint.Check(version) {
+			return fmt.Errorf("%s %s is not supported, upgrade it to %s or later", component, label, minVersion)
+		}
+	}
+
+	return nil
+}
+
+// servesV1 returns true if the given CRD serves v1.
+func servesV1(crd *apiextensionsv1.CustomResourceDefinition) bool {
+	return slices.ContainsFunc(crd.Spec.Versions, func(v apiextensionsv1.CustomResourceDefinitionVersion) bool {
+		return v.Name == "v1" && v.Served
+	})
+}
+
+// checkConflicts fails if cert-manager, unless the existing one is adopted, or external-secrets if it is to be
+// installed, is installed but not managed by flux, as it would conflict with the installed component.
+func checkConflicts(ctx context.Context, c client.Client, components []string, existingCertManager bool) error {
+	crds := make(map[string]string)
+	if !existingCertManager {
+		crds[env.CertManagerName] = certManagerCRD
+	}
+	if slices.Contains(components, env.ExternalSecretsName) {
+		crds[env.ExternalSecretsName] = "externalsecrets.external-secrets.io"
+	}
+
+	var errs []error
+	for _, name := range sortedKeys(crds) {
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		if err := c.Get(ctx, client.ObjectKey{Name: crds[name]}, crd); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("failed to get CRD %s: %w", crds[name], err)
+		}
+
+		if _, ok := crd.Labels[fluxManagedLabel]; !ok {
+			errs = append(errs, fmt.Errorf("%s %s is already installed and not managed by flux, its CRD %s exists, uninstall it before bootstrapping",
+				name, crdVersion(crd), crds[name]))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// crdVersion returns the version of the application of the given CRD, if it is labelled with it.
+func crdVersion(crd *apiextensionsv1.CustomResourceDefinition) string {
+	if version, ok := crd.Labels["app.kubernetes.io/version"]; ok {
+		return version
+	}
+
+	return "(unknown version)"
+}
+
+// checkRegistry fails if the registry the bootstrap bundle is transferred to is not reachable.
+func (b *Bootstrap) checkRegistry(ctx context.Context) error {
+	repo := oci.Repository{
+		RepositoryURL: b.registry,
+		PlainHTTP:     b.transportType == "http",
+	}
+	if err := repo.Ping(ctx); err != nil {
+		return fmt.Errorf("the registry %s is not reachable, check --registry and its credentials: %w", b.registry, err)
+	}
+
+	return nil
+}
+
+// checkBootstrapComponent fails if no version of the bootstrap component matching the requested version resolves.
+func (b *Bootstrap) checkBootstrapComponent(octx om.Context) error {
+	repo, err := b.bundleOrComponentRepository(octx)
+	if err != nil {
+		return fmt.Errorf("failed to access %s: %w", b.bundleOrComponentLocation(), err)
+	}
+	defer repo.Close()
+
+	cv, err := ocm.FetchComponentVersion(repo, env.DefaultBootstrapComponent, b.bootstrapVersion)
+	if err != nil {
+		return fmt.Errorf("the bootstrap component does not resolve in %s, check --registry, --from-file and --bootstrap-version: %w",
+			b.bundleOrComponentLocation(), err)
+	}
+
+	return cv.Close()
+}
+
+// bundleOrComponentRepository returns the bundle given with --from-file, which is not transferred yet,
+// or the repository of the bootstrap component.
+func (b *Bootstrap) bundleOrComponentRepository(octx om.Context) (om.Repository, error) {
+	if b.fromFile != "" {
+		return ocm.RepositoryFromCTF(b.bundlePath)
+	}
+
+	return b.componentRepository(octx)
+}
+
+// bundleOrComponentLocation returns the location of the repository returned by bundleOrComponentRepository.
+func (b *Bootstrap) bundleOrComponentLocation() string {
+	if b.fromFile != "" {
+		return b.fromFile
+	}
+
+	return b.registry
+}
+
+// checkTokenPermission fails if the git token is not allowed to create and push to repositories.
+// Tokens whose permissions the provider cannot tell, like fine-grained GitHub tokens, are not checked.
+func checkTokenPermission(ctx context.Context, providerClient gitprovider.Client) error {
+	ok, err := providerClient.HasTokenPermission(ctx, gitprovider.TokenPermissionRWRepository)
+	if errors.Is(err, gitprovider.ErrNoProviderSupport) || errors.Is(err, gitprovider.ErrMissingHeader) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check the permissions of the git token: %w", err)
+	}
+
+	if !ok {
+		return fmt.Errorf("the git token is not allowed to create and push to repositories, grant it the repo scope")
+	}
+
+	return nil
+}
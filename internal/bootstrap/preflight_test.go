@@ -0,0 +1,172 @@
+package bootstrap
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-component-model/mpas/internal/env"
+	"github.com/open-component-model/mpas/internal/kubeutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCheckKubernetesVersion(t *testing.T) {
+	for _, version := range []string{"v1.28.0", "v1.28.3-eks-4f4795d", "v1.29.1+k3s1", "v1.30.0"} {
+		assert.NoError(t, checkKubernetesVersion(version), version)
+	}
+
+	assert.ErrorContains(t, checkKubernetesVersion("v1.27.9"),
+		"the Kubernetes version v1.27.9 is not supported, upgrade the cluster to 1.28.0 or later")
+	assert.ErrorContains(t, checkKubernetesVersion("latest"), "failed to parse the Kubernetes version latest")
+}
+
+func TestCheckFluxCRDs(t *testing.T) {
+	testCases := []struct {
+		name     string
+		versions []string
+		err      string
+	}{
+		{
+			name: "flux not installed",
+		},
+		{
+			name:     "flux v2",
+			versions: []string{"v1beta2", "v1"},
+		},
+		{
+			name:     "flux v0",
+			versions: []string{"v1beta1", "v1beta2"},
+			err:      "flux v0.41.2 is installed and its CRD kustomizations.kustomize.toolkit.fluxcd.io does not serve v1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var objects []client.Object
+			if tc.versions != nil {
+				crd := newCRD("kustomizations.kustomize.toolkit.fluxcd.io", map[string]string{
+					"app.kubernetes.io/version": "v0.41.2",
+				})
+				for _, version := range tc.versions {
+					crd.Spec.Versions = append(crd.Spec.Versions, apiextensionsv1.CustomResourceDefinitionVersion{
+						Name:   version,
+						Served: true,
+					})
+				}
+				objects = append(objects, crd)
+			}
+
+			err := checkFluxCRDs(context.Background(), newPreflightClient(t, objects...))
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestCheckExisting(t *testing.T) {
+	newFluxCRDs := func(version string, served ...string) []client.Object {
+		var objects []client.Object
+		for _, name := range fluxCRDs {
+			crd := newCRD(name, map[string]string{"app.kubernetes.io/version": version})
+			for _, v := range served {
+				crd.Spec.Versions = append(crd.Spec.Versions, apiextensionsv1.CustomResourceDefinitionVersion{Name: v, Served: true})
+			}
+			objects = append(objects, crd)
+		}
+		return objects
+	}
+
+	testCases := []struct {
+		name    string
+		objects []client.Object
+		err     string
+	}{
+		{
+			name:    "supported flux",
+			objects: newFluxCRDs("v2.2.3", "v1beta2", "v1"),
+		},
+		{
+			name: "flux not installed",
+			err:  "flux is not installed, its CRD gitrepositories.source.toolkit.fluxcd.io does not exist",
+		},
+		{
+			name:    "flux v0",
+			objects: newFluxCRDs("v0.41.2", "v1beta2"),
+			err:     "flux v0.41.2 is installed and its CRD gitrepositories.source.toolkit.fluxcd.io does not serve v1, upgrade it to 2.1.0 or later",
+		},
+		{
+			name:    "flux too old",
+			objects: newFluxCRDs("v2.0.1", "v1"),
+			err:     "flux v2.0.1 is not supported, upgrade it to 2.1.0 or later",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkExisting(context.Background(), newPreflightClient(t, tc.objects...), env.FluxName, fluxCRDs, env.MinFluxVersion)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestCheckConflicts(t *testing.T) {
+	certManager := newCRD("certificates.cert-manager.io", map[string]string{"app.kubernetes.io/version": "v1.12.0"})
+	externalSecrets := newCRD("externalsecrets.external-secrets.io", nil)
+
+	err := checkConflicts(context.Background(), newPreflightClient(t, certManager, externalSecrets), []string{env.ExternalSecretsName}, false)
+	assert.ErrorContains(t, err, "cert-manager v1.12.0 is already installed and not managed by flux")
+	assert.ErrorContains(t, err, "external-secrets-operator (unknown version) is already installed and not managed by flux")
+
+	// external-secrets is not installed by the bootstrap
+	err = checkConflicts(context.Background(), newPreflightClient(t, externalSecrets), nil, false)
+	assert.NoError(t, err)
+
+	// installed by a previous bootstrap
+	managed := newCRD("certificates.cert-manager.io", map[string]string{fluxManagedLabel: "flux-system"})
+	err = checkConflicts(context.Background(), newPreflightClient(t, managed), nil, false)
+	assert.NoError(t, err)
+
+	// the existing cert-manager is adopted
+	err = checkConflicts(context.Background(), newPreflightClient(t, certManager), nil, true)
+	assert.NoError(t, err)
+}
+
+func TestPreflightChecksOfDryRun(t *testing.T) {
+	b := &Bootstrap{
+		options: options{
+			dryRun:   true,
+			registry: "ghcr.io/open-component-model/mpas-bootstrap-component",
+		},
+	}
+
+	checks := b.preflightChecks(nil)
+	require.Len(t, checks, 1, "expected the cluster and the git token not to be checked during a dry-run")
+	assert.Contains(t, checks[0].name, "Checking the bootstrap component")
+}
+
+func newPreflightClient(t *testing.T, objects ...client.Object) client.Client {
+	scheme, err := kubeutils.NewScheme()
+	require.NoError(t, err)
+
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+}
+
+func newCRD(name string, labels map[string]string) *apiextensionsv1.CustomResourceDefinition {
+	return &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+	}
+}
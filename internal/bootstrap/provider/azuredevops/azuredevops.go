@@ -0,0 +1,291 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package azuredevops implements the parts of a gitprovider.Client required to bootstrap
+// MPAS against the Azure DevOps Services or Server REST API.
+package azuredevops
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+const (
+	// DefaultDomain is the domain of Azure DevOps Services.
+	DefaultDomain = "dev.azure.com"
+	// ProviderID is the provider ID of Azure DevOps.
+	ProviderID = gitprovider.ProviderID("azure-devops")
+
+	apiVersion = "7.0"
+)
+
+// Client is a gitprovider.Client for Azure DevOps. An organization reference maps to an Azure DevOps
+// organization, its only sub-organization to a project. Azure DevOps has no personal repositories.
+type Client struct {
+	httpClient         *http.Client
+	baseURL            *url.URL
+	token              string
+	destructiveActions bool
+}
+
+var _ gitprovider.Client = &Client{}
+
+// NewClient returns a new Client authenticating with the given personal access token.
+// The domain defaults to dev.azure.com, it may carry a scheme to reach a server over plain http.
+func NewClient(token string, optFns ...gitprovider.ClientOption) (*Client, error) {
+	opts, err := gitprovider.MakeClientOptions(optFns...)
+	if err != nil {
+		return nil, fmt.Errorf("failed making client options: %w", err)
+	}
+
+	httpClient, err := gitprovider.BuildClientFromTransportChain(opts.GetTransportChain())
+	if err != nil {
+		return nil, fmt.Errorf("failed building client: %w", err)
+	}
+
+	domain := DefaultDomain
+	if opts.Domain != nil {
+		domain = *opts.Domain
+	}
+	if !strings.Contains(domain, "://") {
+		domain = "https://" + domain
+	}
+
+	baseURL, err := url.Parse(domain)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing domain %q: %w", domain, err)
+	}
+
+	c := &Client{
+		httpClient: httpClient,
+		baseURL:    baseURL,
+		token:      token,
+	}
+	if opts.EnableDestructiveAPICalls != nil {
+		c.destructiveActions = *opts.EnableDestructiveAPICalls
+	}
+
+	return c, nil
+}
+
+// SupportedDomain returns the host of the Azure DevOps server.
+func (c *Client) SupportedDomain() string {
+	return c.baseURL.Host
+}
+
+// ProviderID returns the provider ID "azure-devops".
+func (c *Client) ProviderID() gitprovider.ProviderID {
+	return ProviderID
+}
+
+// HasTokenPermission is not supported.
+func (c *Client) HasTokenPermission(_ context.Context, _ gitprovider.TokenPermission) (bool, error) {
+	return false, gitprovider.ErrNoProviderSupport
+}
+
+// Raw returns the http client used to access the REST API.
+func (c *Client) Raw() interface{} {
+	return c.httpClient
+}
+
+// Organizations is not supported, organizations and projects are not listed.
+func (c *Client) Organizations() gitprovider.OrganizationsClient {
+	return &organizationsClient{}
+}
+
+// OrgRepositories returns a client for the repositories of a project.
+func (c *Client) OrgRepositories() gitprovider.OrgRepositoriesClient {
+	return &orgRepositoriesClient{client: c}
+}
+
+// UserRepositories is not supported, Azure DevOps has no personal repositories.
+func (c *Client) UserRepositories() gitprovider.UserRepositoriesClient {
+	return &userRepositoriesClient{}
+}
+
+// do sends a request to the REST API and decodes the response into out if it is not nil.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	u := c.baseURL.JoinPath(path)
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("api-version", apiVersion)
+	u.RawQuery = query.Encode()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.SetBasicAuth("", c.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to %s %s: %w", method, u.Path, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return gitprovider.ErrNotFound
+	case resp.StatusCode == http.StatusConflict:
+		return gitprovider.ErrAlreadyExists
+	case resp.StatusCode >= 300:
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		return fmt.Errorf("failed to %s %s: %s: %s", method, u.Path, resp.Status, apiErr.Message)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response of %s %s: %w", method, u.Path, err)
+	}
+
+	return nil
+}
+
+// projectPath returns the organization and project of the given organization reference.
+func projectPath(o gitprovider.OrganizationRef) (string, error) {
+	if len(o.SubOrganizations) != 1 {
+		return "", fmt.Errorf("an Azure DevOps repository must be referenced as <organization>/<project>/<repository>, got %q", o.String())
+	}
+
+	return fmt.Sprintf("%s/%s", o.Organization, o.SubOrganizations[0]), nil
+}
+
+type organizationsClient struct{}
+
+func (c *organizationsClient) Get(_ context.Context, _ gitprovider.OrganizationRef) (gitprovider.Organization, error) {
+	return nil, gitprovider.ErrNoProviderSupport
+}
+
+func (c *organizationsClient) List(_ context.Context) ([]gitprovider.Organization, error) {
+	return nil, gitprovider.ErrNoProviderSupport
+}
+
+func (c *organizationsClient) Children(_ context.Context, _ gitprovider.OrganizationRef) ([]gitprovider.Organization, error) {
+	return nil, gitprovider.ErrNoProviderSupport
+}
+
+type userRepositoriesClient struct{}
+
+func (c *userRepositoriesClient) Get(_ context.Context, _ gitprovider.UserRepositoryRef) (gitprovider.UserRepository, error) {
+	return nil, gitprovider.ErrNoProviderSupport
+}
+
+func (c *userRepositoriesClient) List(_ context.Context, _ gitprovider.UserRef) ([]gitprovider.UserRepository, error) {
+	return nil, gitprovider.ErrNoProviderSupport
+}
+
+func (c *userRepositoriesClient) Create(_ context.Context, _ gitprovider.UserRepositoryRef, _ gitprovider.RepositoryInfo, _ ...gitprovider.RepositoryCreateOption) (gitprovider.UserRepository, error) {
+	return nil, gitprovider.ErrNoProviderSupport
+}
+
+func (c *userRepositoriesClient) Reconcile(_ context.Context, _ gitprovider.UserRepositoryRef, _ gitprovider.RepositoryInfo, _ ...gitprovider.RepositoryReconcileOption) (gitprovider.UserRepository, bool, error) {
+	return nil, false, gitprovider.ErrNoProviderSupport
+}
+
+type orgRepositoriesClient struct {
+	client *Client
+}
+
+// Get returns the repository of the given reference.
+func (c *orgRepositoriesClient) Get(ctx context.Context, ref gitprovider.OrgRepositoryRef) (gitprovider.OrgRepository, error) {
+	project, err := projectPath(ref.OrganizationRef)
+	if err != nil {
+		return nil, err
+	}
+
+	var r apiRepository
+	if err := c.client.do(ctx, http.MethodGet, fmt.Sprintf("%s/_apis/git/repositories/%s", project, ref.RepositoryName), nil, nil, &r); err != nil {
+		return nil, err
+	}
+
+	return newRepository(c.client, ref, r), nil
+}
+
+// List is not supported.
+func (c *orgRepositoriesClient) List(_ context.Context, _ gitprovider.OrganizationRef) ([]gitprovider.OrgRepository, error) {
+	return nil, gitprovider.ErrNoProviderSupport
+}
+
+// Create creates the repository in the project of the given reference. The repository is created empty,
+// its default branch is created by the first commit.
+func (c *orgRepositoriesClient) Create(ctx context.Context, ref gitprovider.OrgRepositoryRef, req gitprovider.RepositoryInfo, _ ...gitprovider.RepositoryCreateOption) (gitprovider.OrgRepository, error) {
+	project, err := projectPath(ref.OrganizationRef)
+	if err != nil {
+		return nil, err
+	}
+
+	var p struct {
+		ID string `json:"id"`
+	}
+	if err := c.client.do(ctx, http.MethodGet, fmt.Sprintf("%s/_apis/projects/%s", ref.Organization, ref.SubOrganizations[0]), nil, nil, &p); err != nil {
+		return nil, fmt.Errorf("failed to get project %s: %w", project, err)
+	}
+
+	body := map[string]any{
+		"name": ref.RepositoryName,
+		"project": map[string]string{
+			"id": p.ID,
+		},
+	}
+
+	var r apiRepository
+	if err := c.client.do(ctx, http.MethodPost, fmt.Sprintf("%s/_apis/git/repositories", project), nil, body, &r); err != nil {
+		return nil, err
+	}
+
+	repo := newRepository(c.client, ref, r)
+	if req.DefaultBranch != nil {
+		repo.r.DefaultBranch = "refs/heads/" + *req.DefaultBranch
+	}
+
+	return repo, nil
+}
+
+// Reconcile creates the repository if it does not exist. Existing repositories are not updated.
+func (c *orgRepositoriesClient) Reconcile(ctx context.Context, ref gitprovider.OrgRepositoryRef, req gitprovider.RepositoryInfo, _ ...gitprovider.RepositoryReconcileOption) (gitprovider.OrgRepository, bool, error) {
+	repo, err := c.Get(ctx, ref)
+	if err == nil {
+		return repo, false, nil
+	}
+
+	if !errors.Is(err, gitprovider.ErrNotFound) {
+		return nil, false, err
+	}
+
+	repo, err = c.Create(ctx, ref, req)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return repo, true, nil
+}
@@ -0,0 +1,348 @@
+package azuredevops
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	pathpkg "path"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const repositoryPath = "/myorg/myproject/_apis/git/repositories"
+
+// fakeServer is a stand-in of the Azure DevOps REST API serving a single project.
+type fakeServer struct {
+	mu     sync.Mutex
+	repos  map[string]apiRepository
+	heads  map[string]string
+	files  map[string]string
+	pushes []apiPush
+	prs    []apiPullRequest
+	auth   []string
+}
+
+func newFakeServer(t *testing.T) (*fakeServer, *Client) {
+	f := &fakeServer{
+		repos: map[string]apiRepository{},
+		heads: map[string]string{},
+		files: map[string]string{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/myorg/_apis/projects/myproject", func(w http.ResponseWriter, r *http.Request) {
+		f.record(r)
+		writeJSON(w, map[string]string{"id": "project-id"})
+	})
+	mux.HandleFunc(repositoryPath, func(w http.ResponseWriter, r *http.Request) {
+		f.record(r)
+		var body struct {
+			Name    string `json:"name"`
+			Project struct {
+				ID string `json:"id"`
+			} `json:"project"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "project-id", body.Project.ID)
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		repo := apiRepository{
+			ID:        body.Name + "-id",
+			Name:      body.Name,
+			RemoteURL: "https://myorg@dev.azure.com/myorg/myproject/_git/" + body.Name,
+			SSHURL:    "git@ssh.dev.azure.com:v3/myorg/myproject/" + body.Name,
+			WebURL:    "https://dev.azure.com/myorg/myproject/_git/" + body.Name,
+		}
+		f.repos[body.Name] = repo
+		writeJSON(w, repo)
+	})
+	mux.HandleFunc(repositoryPath+"/", func(w http.ResponseWriter, r *http.Request) {
+		f.record(r)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		rest := r.URL.Path[len(repositoryPath)+1:]
+		switch rest {
+		case "mpas":
+			repo, ok := f.repos["mpas"]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			writeJSON(w, repo)
+		case "mpas-id":
+			assert.Equal(t, http.MethodDelete, r.Method)
+			delete(f.repos, "mpas")
+		case "mpas-id/refs":
+			filter := r.URL.Query().Get("filter")
+			value := []map[string]string{}
+			if head, ok := f.heads["refs/"+filter]; ok {
+				value = append(value, map[string]string{"name": "refs/" + filter, "objectId": head})
+			}
+			writeJSON(w, map[string]any{"value": value})
+		case "mpas-id/items":
+			if path := r.URL.Query().Get("path"); path != "" {
+				content, ok := f.files[path]
+				if !ok {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				writeJSON(w, map[string]any{"path": path, "content": content})
+				return
+			}
+			scope := r.URL.Query().Get("scopePath")
+			value := []map[string]any{{"path": "/", "isFolder": true}}
+			for path := range f.files {
+				if scope != "" && strings.TrimSuffix(path, "/"+pathpkg.Base(path)) != scope {
+					continue
+				}
+				value = append(value, map[string]any{"path": path})
+			}
+			writeJSON(w, map[string]any{"value": value})
+		case "mpas-id/pushes":
+			var push apiPush
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&push))
+			f.pushes = append(f.pushes, push)
+			for _, change := range push.Commits[0].Changes {
+				if change.ChangeType == "delete" {
+					delete(f.files, change.Item.Path)
+					continue
+				}
+				f.files[change.Item.Path] = change.NewContent.Content
+			}
+			sha := "0123456789abcdef0123456789abcdef0123456" + string(rune('0'+len(f.pushes)))
+			f.heads[push.RefUpdates[0].Name] = sha
+			writeJSON(w, apiPush{Commits: []apiCommit{{CommitID: sha, URL: "https://dev.azure.com/commit/" + sha}}})
+		case "mpas-id/pullrequests":
+			if r.Method == http.MethodGet {
+				assert.Equal(t, "active", r.URL.Query().Get("searchCriteria.status"))
+				value := []apiPullRequest{}
+				for _, pr := range f.prs {
+					if pr.Status == "active" {
+						value = append(value, pr)
+					}
+				}
+				writeJSON(w, map[string]any{"value": value})
+				return
+			}
+			var pr apiPullRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&pr))
+			pr.PullRequestID, pr.Status = len(f.prs)+1, "active"
+			f.prs = append(f.prs, pr)
+			writeJSON(w, pr)
+		default:
+			var id int
+			if _, err := fmt.Sscanf(rest, "mpas-id/pullrequests/%d", &id); err == nil && id > 0 && id <= len(f.prs) {
+				writeJSON(w, f.prs[id-1])
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client, err := NewClient("token", gitprovider.WithDomain(server.URL))
+	require.NoError(t, err)
+
+	return f, client
+}
+
+func (f *fakeServer) record(r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, password, _ := r.BasicAuth()
+	f.auth = append(f.auth, password+"@"+r.URL.Query().Get("api-version"))
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func newRef(subOrgs ...string) gitprovider.OrgRepositoryRef {
+	return gitprovider.OrgRepositoryRef{
+		OrganizationRef: gitprovider.OrganizationRef{
+			Organization:     "myorg",
+			SubOrganizations: subOrgs,
+		},
+		RepositoryName: "mpas",
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	client, err := NewClient("token")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultDomain, client.SupportedDomain())
+	assert.Equal(t, ProviderID, client.ProviderID())
+
+	client, err = NewClient("token", gitprovider.WithDomain("http://devops.example.com:8080"))
+	require.NoError(t, err)
+	assert.Equal(t, "devops.example.com:8080", client.SupportedDomain())
+}
+
+func TestReconcileRepository(t *testing.T) {
+	f, client := newFakeServer(t)
+	ctx := context.Background()
+
+	_, err := client.OrgRepositories().Get(ctx, newRef("myproject"))
+	assert.ErrorIs(t, err, gitprovider.ErrNotFound)
+
+	repo, created, err := client.OrgRepositories().Reconcile(ctx, newRef("myproject"), gitprovider.RepositoryInfo{
+		DefaultBranch: gitprovider.StringVar("main"),
+	})
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, "main", *repo.Get().DefaultBranch)
+
+	_, created, err = client.OrgRepositories().Reconcile(ctx, newRef("myproject"), gitprovider.RepositoryInfo{})
+	require.NoError(t, err)
+	assert.False(t, created)
+
+	for _, auth := range f.auth {
+		assert.Equal(t, "token@"+apiVersion, auth)
+	}
+
+	_, err = client.OrgRepositories().Get(ctx, newRef())
+	assert.ErrorContains(t, err, "<organization>/<project>/<repository>")
+}
+
+func TestGetCloneURL(t *testing.T) {
+	_, client := newFakeServer(t)
+
+	repo, _, err := client.OrgRepositories().Reconcile(context.Background(), newRef("myproject"), gitprovider.RepositoryInfo{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://myorg@dev.azure.com/myorg/myproject/_git/mpas", repo.(gitprovider.CloneableURL).GetCloneURL("", gitprovider.TransportTypeHTTPS))
+	assert.Equal(t, "ssh://git@ssh.dev.azure.com/v3/myorg/myproject/mpas", repo.(gitprovider.CloneableURL).GetCloneURL("", gitprovider.TransportTypeSSH))
+}
+
+func TestCreateCommit(t *testing.T) {
+	f, client := newFakeServer(t)
+	ctx := context.Background()
+
+	repo, _, err := client.OrgRepositories().Reconcile(ctx, newRef("myproject"), gitprovider.RepositoryInfo{})
+	require.NoError(t, err)
+
+	// the first commit creates the branch
+	commit, err := repo.Commits().Create(ctx, "main", "Add manifests", []gitprovider.CommitFile{
+		{Path: gitprovider.StringVar("clusters/a.yaml"), Content: gitprovider.StringVar("a")},
+		{Path: gitprovider.StringVar("clusters/b.yaml"), Content: gitprovider.StringVar("b")},
+	})
+	require.NoError(t, err)
+	require.Len(t, f.pushes, 1)
+	assert.Equal(t, emptyObjectID, f.pushes[0].RefUpdates[0].OldObjectID)
+	assert.Equal(t, "refs/heads/main", f.pushes[0].RefUpdates[0].Name)
+	assert.Equal(t, "Add manifests", f.pushes[0].Commits[0].Comment)
+	for _, change := range f.pushes[0].Commits[0].Changes {
+		assert.Equal(t, "add", change.ChangeType)
+	}
+	first := commit.Get().Sha
+
+	// existing files are edited, files without content deleted
+	commit, err = repo.Commits().Create(ctx, "main", "Update manifests", []gitprovider.CommitFile{
+		{Path: gitprovider.StringVar("clusters/a.yaml"), Content: gitprovider.StringVar("a2")},
+		{Path: gitprovider.StringVar("clusters/b.yaml")},
+		{Path: gitprovider.StringVar("clusters/c.yaml")},
+	})
+	require.NoError(t, err)
+	require.Len(t, f.pushes, 2)
+	assert.Equal(t, first, f.pushes[1].RefUpdates[0].OldObjectID)
+	assert.Equal(t, []apiChange{
+		{ChangeType: "edit", Item: apiItem{Path: "/clusters/a.yaml"}, NewContent: &apiContent{Content: "a2", ContentType: "rawtext"}},
+		{ChangeType: "delete", Item: apiItem{Path: "/clusters/b.yaml"}},
+	}, f.pushes[1].Commits[0].Changes)
+	second := commit.Get().Sha
+
+	// nothing to delete, nothing is pushed
+	commit, err = repo.Commits().Create(ctx, "main", "Remove manifests", []gitprovider.CommitFile{
+		{Path: gitprovider.StringVar("clusters/b.yaml")},
+	})
+	require.NoError(t, err)
+	assert.Len(t, f.pushes, 2)
+	assert.Equal(t, second, commit.Get().Sha)
+}
+
+func TestGetFiles(t *testing.T) {
+	_, client := newFakeServer(t)
+	ctx := context.Background()
+
+	repo, _, err := client.OrgRepositories().Reconcile(ctx, newRef("myproject"), gitprovider.RepositoryInfo{})
+	require.NoError(t, err)
+
+	_, err = repo.Commits().Create(ctx, "main", "Add patches", []gitprovider.CommitFile{
+		{Path: gitprovider.StringVar("clusters/patches/foo/args.yaml"), Content: gitprovider.StringVar("args")},
+		{Path: gitprovider.StringVar("clusters/patches/bar/args.yaml"), Content: gitprovider.StringVar("bar")},
+	})
+	require.NoError(t, err)
+
+	// only the files of the directory are returned
+	files, err := repo.Files().Get(ctx, "clusters/patches/foo", "main")
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "clusters/patches/foo/args.yaml", *files[0].Path)
+	assert.Equal(t, "args", *files[0].Content)
+}
+
+func TestPullRequests(t *testing.T) {
+	f, client := newFakeServer(t)
+	ctx := context.Background()
+
+	repo, _, err := client.OrgRepositories().Reconcile(ctx, newRef("myproject"), gitprovider.RepositoryInfo{})
+	require.NoError(t, err)
+
+	pr, err := repo.PullRequests().Create(ctx, "Bootstrap MPAS", "mpas/bootstrap-v0.1.0", "main", "Installs MPAS")
+	require.NoError(t, err)
+	assert.Equal(t, gitprovider.PullRequestInfo{
+		Title:        "Bootstrap MPAS",
+		Description:  "Installs MPAS",
+		Number:       1,
+		WebURL:       "https://dev.azure.com/myorg/myproject/_git/mpas/pullrequest/1",
+		SourceBranch: "mpas/bootstrap-v0.1.0",
+	}, pr.Get())
+	require.Len(t, f.prs, 1)
+	assert.Equal(t, "refs/heads/mpas/bootstrap-v0.1.0", f.prs[0].SourceRefName)
+	assert.Equal(t, "refs/heads/main", f.prs[0].TargetRefName)
+
+	prs, err := repo.PullRequests().List(ctx)
+	require.NoError(t, err)
+	require.Len(t, prs, 1)
+	assert.Equal(t, 1, prs[0].Get().Number)
+
+	// a completed pull request is merged and no longer listed
+	f.prs[0].Status = pullRequestStatusCompleted
+	pr, err = repo.PullRequests().Get(ctx, 1)
+	require.NoError(t, err)
+	assert.True(t, pr.Get().Merged)
+
+	prs, err = repo.PullRequests().List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, prs)
+
+	_, err = repo.PullRequests().Get(ctx, 2)
+	assert.ErrorIs(t, err, gitprovider.ErrNotFound)
+}
+
+func TestDeleteRepository(t *testing.T) {
+	f, client := newFakeServer(t)
+	ctx := context.Background()
+
+	repo, _, err := client.OrgRepositories().Reconcile(ctx, newRef("myproject"), gitprovider.RepositoryInfo{})
+	require.NoError(t, err)
+	assert.ErrorIs(t, repo.Delete(ctx), gitprovider.ErrDestructiveCallDisallowed)
+
+	client.destructiveActions = true
+	repo, err = client.OrgRepositories().Get(ctx, newRef("myproject"))
+	require.NoError(t, err)
+	require.NoError(t, repo.Delete(ctx))
+	assert.Empty(t, f.repos)
+}
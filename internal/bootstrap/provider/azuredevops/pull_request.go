@@ -0,0 +1,135 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package azuredevops
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// pullRequestStatusCompleted is the status of a merged pull request.
+const pullRequestStatusCompleted = "completed"
+
+// apiPullRequest is a pull request as returned by the REST API.
+type apiPullRequest struct {
+	PullRequestID int    `json:"pullRequestId,omitempty"`
+	Status        string `json:"status,omitempty"`
+	Title         string `json:"title"`
+	Description   string `json:"description,omitempty"`
+	SourceRefName string `json:"sourceRefName"`
+	TargetRefName string `json:"targetRefName"`
+}
+
+// PullRequests returns a client opening pull requests through the pull requests API.
+func (r *repository) PullRequests() gitprovider.PullRequestClient {
+	return &pullRequestClient{repository: r}
+}
+
+type pullRequestClient struct {
+	repository *repository
+}
+
+var _ gitprovider.PullRequestClient = &pullRequestClient{}
+
+// List lists the active pull requests, the completed and abandoned ones are not listed.
+func (c *pullRequestClient) List(ctx context.Context) ([]gitprovider.PullRequest, error) {
+	path, err := c.repository.path("pullrequests")
+	if err != nil {
+		return nil, err
+	}
+
+	var prs struct {
+		Value []apiPullRequest `json:"value"`
+	}
+	if err := c.repository.client.do(ctx, http.MethodGet, path, url.Values{"searchCriteria.status": {"active"}}, nil, &prs); err != nil {
+		return nil, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+
+	list := make([]gitprovider.PullRequest, 0, len(prs.Value))
+	for _, pr := range prs.Value {
+		list = append(list, c.pullRequest(pr))
+	}
+
+	return list, nil
+}
+
+// Create opens a pull request of the given branch to the base branch.
+func (c *pullRequestClient) Create(ctx context.Context, title, branch, baseBranch, description string) (gitprovider.PullRequest, error) {
+	path, err := c.repository.path("pullrequests")
+	if err != nil {
+		return nil, err
+	}
+
+	var pr apiPullRequest
+	if err := c.repository.client.do(ctx, http.MethodPost, path, nil, apiPullRequest{
+		Title:         title,
+		Description:   description,
+		SourceRefName: "refs/heads/" + branch,
+		TargetRefName: "refs/heads/" + baseBranch,
+	}, &pr); err != nil {
+		return nil, fmt.Errorf("failed to create pull request: %w", err)
+	}
+
+	return c.pullRequest(pr), nil
+}
+
+// Edit is not supported.
+func (c *pullRequestClient) Edit(_ context.Context, _ int, _ gitprovider.EditOptions) (gitprovider.PullRequest, error) {
+	return nil, gitprovider.ErrNoProviderSupport
+}
+
+// Get returns the pull request with the given id.
+func (c *pullRequestClient) Get(ctx context.Context, number int) (gitprovider.PullRequest, error) {
+	path, err := c.repository.path(fmt.Sprintf("pullrequests/%d", number))
+	if err != nil {
+		return nil, err
+	}
+
+	var pr apiPullRequest
+	if err := c.repository.client.do(ctx, http.MethodGet, path, nil, nil, &pr); err != nil {
+		return nil, fmt.Errorf("failed to get pull request %d: %w", number, err)
+	}
+
+	return c.pullRequest(pr), nil
+}
+
+// Merge is not supported.
+func (c *pullRequestClient) Merge(_ context.Context, _ int, _ gitprovider.MergeMethod, _ string) error {
+	return gitprovider.ErrNoProviderSupport
+}
+
+func (c *pullRequestClient) pullRequest(pr apiPullRequest) *pullRequest {
+	return &pullRequest{
+		pr: pr,
+		info: gitprovider.PullRequestInfo{
+			Title:        pr.Title,
+			Description:  pr.Description,
+			Merged:       pr.Status == pullRequestStatusCompleted,
+			Number:       pr.PullRequestID,
+			WebURL:       fmt.Sprintf("%s/pullrequest/%d", c.repository.r.WebURL, pr.PullRequestID),
+			SourceBranch: strings.TrimPrefix(pr.SourceRefName, "refs/heads/"),
+		},
+	}
+}
+
+type pullRequest struct {
+	pr   apiPullRequest
+	info gitprovider.PullRequestInfo
+}
+
+var _ gitprovider.PullRequest = &pullRequest{}
+
+func (p *pullRequest) APIObject() interface{} {
+	return &p.pr
+}
+
+func (p *pullRequest) Get() gitprovider.PullRequestInfo {
+	return p.info
+}
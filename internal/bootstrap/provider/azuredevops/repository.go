@@ -0,0 +1,407 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package azuredevops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// emptyObjectID is the object id of a branch that does not exist yet.
+const emptyObjectID = "0000000000000000000000000000000000000000"
+
+// apiRepository is a git repository as returned by the REST API.
+type apiRepository struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	DefaultBranch string `json:"defaultBranch,omitempty"`
+	RemoteURL     string `json:"remoteUrl"`
+	SSHURL        string `json:"sshUrl"`
+	WebURL        string `json:"webUrl"`
+}
+
+// repository is a repository of an Azure DevOps project.
+// The operations not needed to bootstrap MPAS are not implemented.
+type repository struct {
+	gitprovider.OrgRepository
+
+	client *Client
+	ref    gitprovider.OrgRepositoryRef
+	r      apiRepository
+}
+
+var (
+	_ gitprovider.OrgRepository = &repository{}
+	_ gitprovider.CloneableURL  = &repository{}
+)
+
+func newRepository(c *Client, ref gitprovider.OrgRepositoryRef, r apiRepository) *repository {
+	return &repository{
+		client: c,
+		ref:    ref,
+		r:      r,
+	}
+}
+
+func (r *repository) APIObject() interface{} {
+	return &r.r
+}
+
+func (r *repository) Repository() gitprovider.RepositoryRef {
+	return r.ref
+}
+
+// Get returns the default branch of the repository, the other fields are not supported.
+func (r *repository) Get() gitprovider.RepositoryInfo {
+	info := gitprovider.RepositoryInfo{}
+	if branch := strings.TrimPrefix(r.r.DefaultBranch, "refs/heads/"); branch != "" {
+		info.DefaultBranch = &branch
+	}
+
+	return info
+}
+
+// Set is not supported.
+func (r *repository) Set(_ gitprovider.RepositoryInfo) error {
+	return gitprovider.ErrNoProviderSupport
+}
+
+// Update is not supported.
+func (r *repository) Update(_ context.Context) error {
+	return gitprovider.ErrNoProviderSupport
+}
+
+// Reconcile is a no-op, the repository exists.
+func (r *repository) Reconcile(_ context.Context) (bool, error) {
+	return false, nil
+}
+
+// Delete deletes the repository, it requires destructive API calls to be enabled.
+func (r *repository) Delete(ctx context.Context) error {
+	if !r.client.destructiveActions {
+		return gitprovider.ErrDestructiveCallDisallowed
+	}
+
+	project, err := projectPath(r.ref.OrganizationRef)
+	if err != nil {
+		return err
+	}
+
+	return r.client.do(ctx, http.MethodDelete, fmt.Sprintf("%s/_apis/git/repositories/%s", project, r.r.ID), nil, nil, nil)
+}
+
+// GetCloneURL returns the clone URL of the given transport. The scp-like SSH URL
+// returned by the REST API is converted to a ssh:// URL.
+func (r *repository) GetCloneURL(_ string, transport gitprovider.TransportType) string {
+	switch transport {
+	case gitprovider.TransportTypeSSH:
+		if r.r.SSHURL == "" || strings.Contains(r.r.SSHURL, "://") {
+			return r.r.SSHURL
+		}
+
+		return "ssh://" + strings.Replace(r.r.SSHURL, ":", "/", 1)
+	default:
+		return r.r.RemoteURL
+	}
+}
+
+// DeployKeys is not supported, Azure DevOps has no deploy keys.
+func (r *repository) DeployKeys() gitprovider.DeployKeyClient {
+	return &deployKeyClient{}
+}
+
+// DeployTokens is not supported.
+func (r *repository) DeployTokens() (gitprovider.DeployTokenClient, error) {
+	return nil, gitprovider.ErrNoProviderSupport
+}
+
+// Commits returns a client creating commits through the pushes API.
+func (r *repository) Commits() gitprovider.CommitClient {
+	return &commitClient{repository: r}
+}
+
+// Files returns a client reading files through the items API.
+func (r *repository) Files() gitprovider.FileClient {
+	return &fileClient{repository: r}
+}
+
+func (r *repository) path(elem string) (string, error) {
+	project, err := projectPath(r.ref.OrganizationRef)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/_apis/git/repositories/%s/%s", project, r.r.ID, elem), nil
+}
+
+type deployKeyClient struct{}
+
+func (c *deployKeyClient) Get(_ context.Context, _ string) (gitprovider.DeployKey, error) {
+	return nil, gitprovider.ErrNoProviderSupport
+}
+
+func (c *deployKeyClient) List(_ context.Context) ([]gitprovider.DeployKey, error) {
+	return nil, gitprovider.ErrNoProviderSupport
+}
+
+func (c *deployKeyClient) Create(_ context.Context, _ gitprovider.DeployKeyInfo) (gitprovider.DeployKey, error) {
+	return nil, gitprovider.ErrNoProviderSupport
+}
+
+func (c *deployKeyClient) Reconcile(_ context.Context, _ gitprovider.DeployKeyInfo) (gitprovider.DeployKey, bool, error) {
+	return nil, false, gitprovider.ErrNoProviderSupport
+}
+
+type fileClient struct {
+	repository *repository
+}
+
+// Get returns the files directly below the given directory of the branch.
+func (c *fileClient) Get(ctx context.Context, dirPath, branch string, _ ...gitprovider.FilesGetOption) ([]*gitprovider.CommitFile, error) {
+	path, err := c.repository.path("items")
+	if err != nil {
+		return nil, err
+	}
+
+	version := func(query url.Values) url.Values {
+		query.Set("versionDescriptor.version", branch)
+		query.Set("versionDescriptor.versionType", "branch")
+		return query
+	}
+
+	var items struct {
+		Value []struct {
+			Path     string `json:"path"`
+			IsFolder bool   `json:"isFolder"`
+		} `json:"value"`
+	}
+	if err := c.repository.client.do(ctx, http.MethodGet, path, version(url.Values{
+		"scopePath":      {"/" + strings.Trim(dirPath, "/")},
+		"recursionLevel": {"OneLevel"},
+	}), nil, &items); err != nil {
+		return nil, fmt.Errorf("failed to list files of %s: %w", dirPath, err)
+	}
+
+	files := make([]*gitprovider.CommitFile, 0, len(items.Value))
+	for _, item := range items.Value {
+		if item.IsFolder {
+			continue
+		}
+
+		var content struct {
+			Content string `json:"content"`
+		}
+		if err := c.repository.client.do(ctx, http.MethodGet, path, version(url.Values{
+			"path":           {item.Path},
+			"includeContent": {"true"},
+			"$format":        {"json"},
+		}), nil, &content); err != nil {
+			return nil, fmt.Errorf("failed to get file %s: %w", item.Path, err)
+		}
+
+		files = append(files, &gitprovider.CommitFile{
+			Path:    gitprovider.StringVar(strings.TrimPrefix(item.Path, "/")),
+			Content: gitprovider.StringVar(content.Content),
+		})
+	}
+
+	return files, nil
+}
+
+type commitClient struct {
+	repository *repository
+}
+
+// ListPage is not supported.
+func (c *commitClient) ListPage(_ context.Context, _ string, _ int, _ int) ([]gitprovider.Commit, error) {
+	return nil, gitprovider.ErrNoProviderSupport
+}
+
+// Create pushes a single commit with the given files to the branch, creating the branch if it does not exist.
+// A file without content is deleted.
+func (c *commitClient) Create(ctx context.Context, branch string, message string, files []gitprovider.CommitFile) (gitprovider.Commit, error) {
+	oldObjectID, err := c.head(ctx, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := map[string]bool{}
+	if oldObjectID != emptyObjectID {
+		if existing, err = c.items(ctx, branch); err != nil {
+			return nil, err
+		}
+	}
+
+	changes := make([]apiChange, 0, len(files))
+	for _, file := range files {
+		if file.Path == nil {
+			continue
+		}
+
+		path := "/" + strings.TrimPrefix(*file.Path, "/")
+		change := apiChange{Item: apiItem{Path: path}}
+		switch {
+		case file.Content == nil:
+			if !existing[path] {
+				continue
+			}
+			change.ChangeType = "delete"
+		case existing[path]:
+			change.ChangeType = "edit"
+		default:
+			change.ChangeType = "add"
+		}
+		if file.Content != nil {
+			change.NewContent = &apiContent{Content: *file.Content, ContentType: "rawtext"}
+		}
+		changes = append(changes, change)
+	}
+
+	// nothing to push, the branch is up to date
+	if len(changes) == 0 && oldObjectID != emptyObjectID {
+		return &commit{
+			info: gitprovider.CommitInfo{
+				Sha:     oldObjectID,
+				Message: message,
+			},
+		}, nil
+	}
+
+	push := apiPush{
+		RefUpdates: []apiRefUpdate{{Name: "refs/heads/" + branch, OldObjectID: oldObjectID}},
+		Commits:    []apiCommit{{Comment: message, Changes: changes}},
+	}
+
+	path, err := c.repository.path("pushes")
+	if err != nil {
+		return nil, err
+	}
+
+	var resp apiPush
+	if err := c.repository.client.do(ctx, http.MethodPost, path, nil, push, &resp); err != nil {
+		return nil, fmt.Errorf("failed to push commit: %w", err)
+	}
+
+	if len(resp.Commits) == 0 {
+		return nil, errors.New("failed to push commit: no commit returned")
+	}
+
+	return &commit{
+		info: gitprovider.CommitInfo{
+			Sha:     resp.Commits[0].CommitID,
+			Message: message,
+			URL:     resp.Commits[0].URL,
+		},
+	}, nil
+}
+
+// head returns the object id the branch points to, the empty object id if it does not exist.
+func (c *commitClient) head(ctx context.Context, branch string) (string, error) {
+	path, err := c.repository.path("refs")
+	if err != nil {
+		return "", err
+	}
+
+	var refs struct {
+		Value []struct {
+			Name     string `json:"name"`
+			ObjectID string `json:"objectId"`
+		} `json:"value"`
+	}
+	if err := c.repository.client.do(ctx, http.MethodGet, path, url.Values{"filter": {"heads/" + branch}}, nil, &refs); err != nil {
+		return "", fmt.Errorf("failed to get branch %s: %w", branch, err)
+	}
+
+	for _, ref := range refs.Value {
+		if ref.Name == "refs/heads/"+branch {
+			return ref.ObjectID, nil
+		}
+	}
+
+	return emptyObjectID, nil
+}
+
+// items returns the paths of the files of the branch.
+func (c *commitClient) items(ctx context.Context, branch string) (map[string]bool, error) {
+	path, err := c.repository.path("items")
+	if err != nil {
+		return nil, err
+	}
+
+	var items struct {
+		Value []struct {
+			Path     string `json:"path"`
+			IsFolder bool   `json:"isFolder"`
+		} `json:"value"`
+	}
+	if err := c.repository.client.do(ctx, http.MethodGet, path, url.Values{
+		"recursionLevel":                {"Full"},
+		"versionDescriptor.version":     {branch},
+		"versionDescriptor.versionType": {"branch"},
+	}, nil, &items); err != nil {
+		return nil, fmt.Errorf("failed to list files of branch %s: %w", branch, err)
+	}
+
+	paths := make(map[string]bool, len(items.Value))
+	for _, item := range items.Value {
+		if !item.IsFolder {
+			paths[item.Path] = true
+		}
+	}
+
+	return paths, nil
+}
+
+type apiPush struct {
+	RefUpdates []apiRefUpdate `json:"refUpdates,omitempty"`
+	Commits    []apiCommit    `json:"commits"`
+}
+
+type apiRefUpdate struct {
+	Name        string `json:"name"`
+	OldObjectID string `json:"oldObjectId"`
+}
+
+type apiCommit struct {
+	CommitID string      `json:"commitId,omitempty"`
+	URL      string      `json:"url,omitempty"`
+	Comment  string      `json:"comment,omitempty"`
+	Changes  []apiChange `json:"changes,omitempty"`
+}
+
+type apiChange struct {
+	ChangeType string      `json:"changeType"`
+	Item       apiItem     `json:"item"`
+	NewContent *apiContent `json:"newContent,omitempty"`
+}
+
+type apiItem struct {
+	Path string `json:"path"`
+}
+
+type apiContent struct {
+	Content     string `json:"content"`
+	ContentType string `json:"contentType"`
+}
+
+type commit struct {
+	info gitprovider.CommitInfo
+}
+
+var _ gitprovider.Commit = &commit{}
+
+func (c *commit) APIObject() interface{} {
+	return &c.info
+}
+
+func (c *commit) Get() gitprovider.CommitInfo {
+	return c.info
+}
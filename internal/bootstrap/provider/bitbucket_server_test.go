@@ -0,0 +1,298 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/fluxcd/go-git-providers/stash"
+	"github.com/open-component-model/mpas/internal/env"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const bitbucketRepositoryPath = "/rest/api/1.0/projects/PROJ/repos"
+
+// fakeBitbucketServer is a stand-in of the Bitbucket Server REST API serving a single project.
+// The repositories are bare git repositories served with git http-backend, as the stash
+// client clones and pushes them to commit.
+type fakeBitbucketServer struct {
+	mu    sync.Mutex
+	root  string
+	url   string
+	repos map[string]stash.Repository
+	prs   []*stash.PullRequest
+	auth  []string
+}
+
+func newFakeBitbucketServer(t *testing.T) (*fakeBitbucketServer, gitprovider.Client) {
+	gitBin, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git is required to serve the repositories")
+	}
+
+	f := &fakeBitbucketServer{
+		root:  t.TempDir(),
+		repos: map[string]stash.Repository{},
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/scm/", &cgi.Handler{
+		Path: gitBin,
+		Root: "/scm",
+		Args: []string{"http-backend"},
+		Env:  []string{"GIT_PROJECT_ROOT=" + f.root, "GIT_HTTP_EXPORT_ALL=1"},
+	})
+	mux.HandleFunc("/rest/api/1.0/users/mpas", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, stash.User{Name: "mpas", Slug: "mpas", EmailAddress: "mpas@example.com"})
+	})
+	mux.HandleFunc(bitbucketRepositoryPath, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		var body stash.Repository
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if _, ok := f.repos[body.Name]; ok {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		dir := f.repositoryDir(body.Name)
+		git(t, "", "init", "--bare", dir)
+		git(t, dir, "config", "http.receivepack", "true")
+
+		repo := stash.Repository{
+			Name:    body.Name,
+			Slug:    body.Name,
+			Project: stash.Project{Key: "PROJ"},
+			Links: stash.Links{
+				Self:  []stash.Self{{Href: f.url + "/projects/PROJ/repos/" + body.Name + "/browse"}},
+				Clone: []stash.Clone{{Name: "http", Href: f.url + "/scm/proj/" + body.Name + ".git"}},
+			},
+		}
+		f.repos[body.Name] = repo
+		writeJSON(w, repo)
+	})
+	mux.HandleFunc(bitbucketRepositoryPath+"/", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		name, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, bitbucketRepositoryPath+"/"), "/")
+		repo, ok := f.repos[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		dir := f.repositoryDir(name)
+
+		switch {
+		case rest == "":
+			writeJSON(w, repo)
+		case rest == "branches" && r.Method == http.MethodPost:
+			var branch struct {
+				Name       string `json:"name"`
+				StartPoint string `json:"startPoint"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&branch))
+			git(t, dir, "update-ref", branch.Name, branch.StartPoint)
+			writeJSON(w, stash.Branch{ID: branch.Name, DisplayID: strings.TrimPrefix(branch.Name, "refs/heads/")})
+		case rest == "branches/default" && r.Method == http.MethodPut:
+			var branch struct {
+				ID string `json:"id"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&branch))
+			git(t, dir, "symbolic-ref", "HEAD", branch.ID)
+			w.WriteHeader(http.StatusNoContent)
+		case rest == "branches/default":
+			head := git(t, dir, "symbolic-ref", "HEAD")
+			writeJSON(w, stash.Branch{ID: head, DisplayID: strings.TrimPrefix(head, "refs/heads/")})
+		case strings.HasPrefix(rest, "commits/"):
+			sha := strings.TrimPrefix(rest, "commits/")
+			writeJSON(w, stash.CommitObject{
+				ID:      sha,
+				Message: git(t, dir, "log", "-1", "--format=%s", sha),
+				Author:  stash.User{Name: git(t, dir, "log", "-1", "--format=%an", sha)},
+			})
+		case rest == "pull-requests" && r.Method == http.MethodPost:
+			var pr stash.PullRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&pr))
+			pr.ID, pr.State = len(f.prs)+1, "OPEN"
+			pr.FromRef.DisplayID = strings.TrimPrefix(pr.FromRef.ID, "refs/heads/")
+			pr.ToRef.DisplayID = strings.TrimPrefix(pr.ToRef.ID, "refs/heads/")
+			pr.Links.Self = []stash.Self{{Href: fmt.Sprintf("%s/projects/PROJ/repos/%s/pull-requests/%d", f.url, name, pr.ID)}}
+			f.prs = append(f.prs, &pr)
+			writeJSON(w, pr)
+		case rest == "pull-requests":
+			values := []*stash.PullRequest{}
+			for _, pr := range f.prs {
+				if pr.State == "OPEN" {
+					values = append(values, pr)
+				}
+			}
+			writeJSON(w, map[string]any{"values": values, "isLastPage": true})
+		default:
+			var id int
+			if _, err := fmt.Sscanf(rest, "pull-requests/%d", &id); err == nil && id > 0 && id <= len(f.prs) {
+				writeJSON(w, f.prs[id-1])
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/rest/") {
+			f.mu.Lock()
+			f.auth = append(f.auth, r.Header.Get("Authorization"))
+			f.mu.Unlock()
+			// the stash client reads the user of the session from the response headers
+			w.Header().Set("X-Ausername", "mpas")
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	f.url = server.URL
+
+	client, err := New().Build(ProviderOptions{
+		Provider: env.ProviderBitbucketServer,
+		Hostname: server.URL,
+		Username: "mpas",
+		Token:    "token",
+	})
+	require.NoError(t, err)
+
+	return f, client
+}
+
+func (f *fakeBitbucketServer) repositoryDir(name string) string {
+	return filepath.Join(f.root, "proj", name+".git")
+}
+
+// git runs git in dir and returns its trimmed output.
+func git(t *testing.T, dir string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	require.NoError(t, err, stderr.String())
+	return strings.TrimSpace(string(out))
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func newBitbucketRef(client gitprovider.Client) gitprovider.OrgRepositoryRef {
+	ref := gitprovider.OrgRepositoryRef{
+		OrganizationRef: gitprovider.OrganizationRef{
+			Domain:       client.SupportedDomain(),
+			Organization: "PROJ",
+		},
+		RepositoryName: "mpas",
+	}
+	ref.SetKey("PROJ")
+	return ref
+}
+
+func TestBitbucketServerReconcileRepository(t *testing.T) {
+	f, client := newFakeBitbucketServer(t)
+	ctx := context.Background()
+
+	repo, created, err := client.OrgRepositories().Reconcile(ctx, newBitbucketRef(client), gitprovider.RepositoryInfo{
+		DefaultBranch: gitprovider.StringVar("main"),
+	})
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, "main", *repo.Get().DefaultBranch)
+	assert.Equal(t, "refs/heads/main", git(t, f.repositoryDir("mpas"), "symbolic-ref", "HEAD"))
+
+	// the bootstrap gets an existing repository before reconciling it
+	existing, err := client.OrgRepositories().Get(ctx, newBitbucketRef(client))
+	require.NoError(t, err)
+	assert.Equal(t, "main", *existing.Get().DefaultBranch)
+
+	for _, auth := range f.auth {
+		assert.Equal(t, "Bearer token", auth)
+	}
+}
+
+func TestBitbucketServerCreateCommit(t *testing.T) {
+	f, client := newFakeBitbucketServer(t)
+	ctx := context.Background()
+
+	repo, _, err := client.OrgRepositories().Reconcile(ctx, newBitbucketRef(client), gitprovider.RepositoryInfo{
+		DefaultBranch: gitprovider.StringVar("main"),
+	})
+	require.NoError(t, err)
+
+	commit, err := repo.Commits().Create(ctx, "main", "Add manifests", []gitprovider.CommitFile{
+		{Path: gitprovider.StringVar("clusters/a.yaml"), Content: gitprovider.StringVar("a")},
+		{Path: gitprovider.StringVar("clusters/b.yaml"), Content: gitprovider.StringVar("b")},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Add manifests", commit.Get().Message)
+	assert.Equal(t, "mpas", commit.Get().Author)
+
+	dir := f.repositoryDir("mpas")
+	assert.Equal(t, commit.Get().Sha, git(t, dir, "rev-parse", "refs/heads/main"))
+	assert.Equal(t, "a", git(t, dir, "show", "main:clusters/a.yaml"))
+	assert.Equal(t, "b", git(t, dir, "show", "main:clusters/b.yaml"))
+
+	// a commit to another branch leaves the default branch untouched
+	_, err = repo.Commits().Create(ctx, "mpas/bootstrap-v0.1.0", "Update manifests", []gitprovider.CommitFile{
+		{Path: gitprovider.StringVar("clusters/a.yaml"), Content: gitprovider.StringVar("a2")},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "a2", git(t, dir, "show", "mpas/bootstrap-v0.1.0:clusters/a.yaml"))
+	assert.Equal(t, "a", git(t, dir, "show", "main:clusters/a.yaml"))
+}
+
+func TestBitbucketServerPullRequests(t *testing.T) {
+	f, client := newFakeBitbucketServer(t)
+	ctx := context.Background()
+
+	repo, _, err := client.OrgRepositories().Reconcile(ctx, newBitbucketRef(client), gitprovider.RepositoryInfo{
+		DefaultBranch: gitprovider.StringVar("main"),
+	})
+	require.NoError(t, err)
+
+	pr, err := repo.PullRequests().Create(ctx, "Bootstrap MPAS", "mpas/bootstrap-v0.1.0", "main", "Installs MPAS")
+	require.NoError(t, err)
+	assert.Equal(t, gitprovider.PullRequestInfo{
+		Title:        "Bootstrap MPAS",
+		Description:  "Installs MPAS",
+		Number:       1,
+		WebURL:       f.url + "/projects/PROJ/repos/mpas/pull-requests/1",
+		SourceBranch: "mpas/bootstrap-v0.1.0",
+	}, pr.Get())
+	require.Len(t, f.prs, 1)
+	assert.Equal(t, "refs/heads/mpas/bootstrap-v0.1.0", f.prs[0].FromRef.ID)
+	assert.Equal(t, "refs/heads/main", f.prs[0].ToRef.ID)
+	assert.Equal(t, "PROJ", f.prs[0].ToRef.Repository.Project.Key)
+
+	prs, err := repo.PullRequests().List(ctx)
+	require.NoError(t, err)
+	require.Len(t, prs, 1)
+	assert.Equal(t, 1, prs[0].Get().Number)
+
+	// a merged pull request is no longer listed
+	f.prs[0].State = "MERGED"
+	pr, err = repo.PullRequests().Get(ctx, 1)
+	require.NoError(t, err)
+	assert.True(t, pr.Get().Merged)
+
+	prs, err = repo.PullRequests().List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, prs)
+}
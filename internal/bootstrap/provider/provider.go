@@ -6,11 +6,14 @@ package provider
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/fluxcd/go-git-providers/gitea"
 	"github.com/fluxcd/go-git-providers/github"
 	"github.com/fluxcd/go-git-providers/gitlab"
 	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/fluxcd/go-git-providers/stash"
+	"github.com/open-component-model/mpas/internal/bootstrap/provider/azuredevops"
 	"github.com/open-component-model/mpas/internal/env"
 )
 
@@ -28,6 +31,8 @@ func init() {
 	providers.register(env.ProviderGithub, githubProviderFunc)
 	providers.register(env.ProviderGitea, giteaProviderFunc)
 	providers.register(env.ProviderGitlab, gitlabProviderFunc)
+	providers.register(env.ProviderBitbucketServer, bitbucketServerProviderFunc)
+	providers.register(env.ProviderAzureDevOps, azureDevOpsProviderFunc)
 }
 
 // ProviderOptions contains the options for the provider
@@ -95,6 +100,60 @@ func gitlabProviderFunc(opts ProviderOptions) (gitprovider.Client, error) {
 	return client, err
 }
 
+// bitbucketServerProviderFunc returns a new gitprovider.Client for bitbucket server
+func bitbucketServerProviderFunc(opts ProviderOptions) (gitprovider.Client, error) {
+	if opts.Hostname == "" {
+		return nil, fmt.Errorf("hostname is required for bitbucket server")
+	}
+
+	// the stash client expects the hostname to be an URL
+	if !strings.Contains(opts.Hostname, "://") {
+		opts.Hostname = "https://" + opts.Hostname
+	}
+
+	// the stash client sends the token as bearer token to the REST API, and as basic auth
+	// password to git, an oauth2 transport would override both
+	o := []gitprovider.ClientOption{
+		gitprovider.WithDomain(opts.Hostname),
+		gitprovider.WithDestructiveAPICalls(opts.DestructiveActions),
+	}
+	client, err := stash.NewStashClient(opts.Username, opts.Token, o...)
+	if err != nil {
+		return nil, err
+	}
+	return &stashClient{ProviderClient: client, domain: opts.Hostname}, nil
+}
+
+// stashClient is a stash client whose supported domain is the URL of the server.
+// The stash client validates the domain of repository references against the URL
+// it was created with, while it only reports the host of that URL as supported domain.
+type stashClient struct {
+	*stash.ProviderClient
+	domain string
+}
+
+// SupportedDomain returns the URL of the bitbucket server
+func (c *stashClient) SupportedDomain() string {
+	return c.domain
+}
+
+// azureDevOpsProviderFunc returns a new gitprovider.Client for azure devops
+func azureDevOpsProviderFunc(opts ProviderOptions) (gitprovider.Client, error) {
+	// azure devops authenticates personal access tokens with basic auth, not as oauth2 tokens
+	o := []gitprovider.ClientOption{
+		gitprovider.WithDestructiveAPICalls(opts.DestructiveActions),
+	}
+	if opts.Hostname != "" {
+		o = append(o, gitprovider.WithDomain(opts.Hostname))
+	}
+
+	client, err := azuredevops.NewClient(opts.Token, o...)
+	if err != nil {
+		return nil, err
+	}
+	return client, err
+}
+
 func makeProviderOpts(opts ProviderOptions) []gitprovider.ClientOption {
 	o := []gitprovider.ClientOption{
 		gitprovider.WithOAuth2Token(opts.Token),
@@ -0,0 +1,138 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/fluxcd/go-git-providers/stash"
+	"github.com/open-component-model/mpas/internal/bootstrap/provider/azuredevops"
+	"github.com/open-component-model/mpas/internal/env"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuild(t *testing.T) {
+	testCases := []struct {
+		name       string
+		opts       ProviderOptions
+		providerID gitprovider.ProviderID
+		domain     string
+		expectErr  bool
+	}{
+		{
+			name: "bitbucket server",
+			opts: ProviderOptions{
+				Provider: env.ProviderBitbucketServer,
+				Hostname: "bitbucket.example.com",
+				Username: "mpas",
+				Token:    "token",
+			},
+			providerID: stash.ProviderID,
+			domain:     "https://bitbucket.example.com",
+		},
+		{
+			name: "bitbucket server without hostname",
+			opts: ProviderOptions{
+				Provider: env.ProviderBitbucketServer,
+				Username: "mpas",
+				Token:    "token",
+			},
+			expectErr: true,
+		},
+		{
+			name: "azure devops services",
+			opts: ProviderOptions{
+				Provider: env.ProviderAzureDevOps,
+				Token:    "token",
+			},
+			providerID: azuredevops.ProviderID,
+			domain:     azuredevops.DefaultDomain,
+		},
+		{
+			name: "azure devops server",
+			opts: ProviderOptions{
+				Provider: env.ProviderAzureDevOps,
+				Hostname: "devops.example.com",
+				Token:    "token",
+			},
+			providerID: azuredevops.ProviderID,
+			domain:     "devops.example.com",
+		},
+		{
+			name: "unknown provider",
+			opts: ProviderOptions{
+				Provider: "unknown",
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client, err := New().Build(tc.opts)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.providerID, client.ProviderID())
+			assert.Equal(t, tc.domain, client.SupportedDomain())
+		})
+	}
+}
+
+func TestBitbucketServerGetRepository(t *testing.T) {
+	var authorization string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest/api/1.0/projects/PROJ/repos/mpas", func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"name": "mpas",
+			"slug": "mpas",
+			"project": map[string]any{
+				"key":  "PROJ",
+				"name": "project",
+			},
+		})
+	})
+	mux.HandleFunc("/rest/api/1.0/projects/PROJ/repos/mpas/branches/default", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id":        "refs/heads/main",
+			"displayId": "main",
+		})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := New().Build(ProviderOptions{
+		Provider: env.ProviderBitbucketServer,
+		Hostname: server.URL,
+		Username: "mpas",
+		Token:    "token",
+	})
+	require.NoError(t, err)
+
+	ref := gitprovider.OrgRepositoryRef{
+		OrganizationRef: gitprovider.OrganizationRef{
+			Domain:       client.SupportedDomain(),
+			Organization: "PROJ",
+		},
+		RepositoryName: "mpas",
+	}
+	ref.SetKey("PROJ")
+	repo, err := client.OrgRepositories().Get(context.Background(), ref)
+	require.NoError(t, err)
+	assert.Equal(t, "mpas", repo.Repository().GetRepository())
+	assert.Equal(t, "main", *repo.Get().DefaultBranch)
+	assert.Equal(t, "Bearer token", authorization)
+
+	ref.RepositoryName = "unknown"
+	_, err = client.OrgRepositories().Get(context.Background(), ref)
+	assert.ErrorIs(t, err, gitprovider.ErrNotFound)
+}
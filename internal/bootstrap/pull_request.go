@@ -0,0 +1,296 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/fluxcd/pkg/git/repository"
+	"github.com/open-component-model/mpas/internal/env"
+	"github.com/open-component-model/mpas/internal/printer"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// WithViaPullRequest sets whether the manifests are pushed to a branch and proposed to the default branch
+// with a pull request, instead of being committed to the default branch. It implies a single commit.
+func WithViaPullRequest(viaPullRequest bool) Option {
+	return func(o *options) {
+		o.viaPullRequest = viaPullRequest
+	}
+}
+
+// WithPullRequestBranch sets the branch the manifests are pushed to when they are proposed with a pull request.
+func WithPullRequestBranch(branch string) Option {
+	return func(o *options) {
+		o.pullRequestBranch = branch
+	}
+}
+
+// WithWaitForMerge sets whether to wait for the pull request to be merged and for the merged manifests to be
+// reconciled. Otherwise the command completes once the pull request is opened.
+func WithWaitForMerge(wait bool) Option {
+	return func(o *options) {
+		o.waitForMerge = wait
+	}
+}
+
+// WithMergeTimeout sets how long to wait for the pull request to be merged.
+func WithMergeTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.mergeTimeout = timeout
+	}
+}
+
+// commitBranch returns the branch the manifests are committed to.
+func (b *Bootstrap) commitBranch() string {
+	if b.viaPullRequest {
+		return b.pullRequestBranch
+	}
+
+	return b.defaultBranch
+}
+
+// setPullRequestBranch defaults the pull request branch to one named after the given action and version,
+// e.g. mpas/bootstrap-v0.5.0.
+func (b *Bootstrap) setPullRequestBranch(action, version string) {
+	if b.viaPullRequest && b.pullRequestBranch == "" {
+		b.pullRequestBranch = fmt.Sprintf("mpas/%s-%s", action, version)
+	}
+}
+
+// preparePullRequestBranch creates the pull request branch from the default branch, unless it already exists
+// from a previous run. Flux pushes its manifests to it before the other manifests are committed.
+func (b *Bootstrap) preparePullRequestBranch(ctx context.Context) error {
+	dir, err := mkdirTempDir("mpas-branch")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	auth, err := b.gitAuthOptions()
+	if err != nil {
+		return err
+	}
+
+	gitClient, err := newGitClient(filepath.Join(dir, "branch"), auth)
+	if err != nil {
+		return err
+	}
+	if _, err := gitClient.Clone(ctx, b.url, repository.CloneOptions{
+		CheckoutStrategy: repository.CheckoutStrategy{Branch: b.pullRequestBranch},
+	}); err == nil {
+		return nil
+	}
+
+	gitClient, err = newGitClient(filepath.Join(dir, "default"), auth)
+	if err != nil {
+		return err
+	}
+	if _, err := gitClient.Clone(ctx, b.url, repository.CloneOptions{
+		CheckoutStrategy: repository.CheckoutStrategy{Branch: b.defaultBranch},
+	}); err != nil {
+		return fmt.Errorf("failed to clone repository: %w", err)
+	}
+
+	if err := gitClient.SwitchBranch(ctx, b.pullRequestBranch); err != nil {
+		return fmt.Errorf("failed to create branch %s: %w", b.pullRequestBranch, err)
+	}
+
+	if err := gitClient.Push(ctx); err != nil {
+		return fmt.Errorf("failed to push branch %s: %w", b.pullRequestBranch, err)
+	}
+
+	return nil
+}
+
+// openPullRequest opens a pull request of the pull request branch to the default branch with the given title
+// and description. A pull request of the branch that is still open is reused.
+func (b *Bootstrap) openPullRequest(ctx context.Context, title, description string) (gitprovider.PullRequest, error) {
+	prs, err := b.repository.PullRequests().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+
+	for _, pr := range prs {
+		if pr.Get().SourceBranch != b.pullRequestBranch {
+			continue
+		}
+
+		open, err := pullRequestOpen(pr)
+		if err != nil {
+			return nil, err
+		}
+		if open {
+			return pr, nil
+		}
+	}
+
+	pr, err := b.repository.PullRequests().Create(ctx, title, b.pullRequestBranch, b.defaultBranch, description)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pull request: %w", err)
+	}
+
+	return pr, nil
+}
+
+// proposeManifests opens a pull request of the committed manifests. If it is not waited for, it returns an empty sha
+// and the reconciliation is left to flux. Otherwise it returns the sha of the default branch once the pull request is merged.
+func (b *Bootstrap) proposeManifests(ctx context.Context, title, description string) (string, error) {
+	var pr gitprovider.PullRequest
+	if err := b.inSpinner(fmt.Sprintf("Opening pull request from %s to %s",
+		printer.BoldBlue(b.pullRequestBranch), printer.BoldBlue(b.defaultBranch)), func() (err error) {
+		pr, err = b.openPullRequest(ctx, title, description)
+		return err
+	}); err != nil {
+		return "", err
+	}
+
+	info := pr.Get()
+	b.printer.Printf("Pull request %s\n", printer.BoldBlue(info.WebURL))
+	if b.report != nil {
+		b.report.PullRequest = info.WebURL
+	}
+
+	// a proposed upgrade is completed by a later run if the pull request is not waited for, or not merged in time
+	if b.state != nil && b.state.Proposed != nil {
+		b.state.Proposed.PullRequest = info.Number
+		if err := saveState(ctx, b.kubeclient, b.namespace(env.DefaultFluxNamespace), b.state); err != nil {
+			return "", fmt.Errorf("failed to save bootstrap state: %w", err)
+		}
+	}
+
+	if !b.waitForMerge {
+		return "", nil
+	}
+
+	var sha string
+	if err := b.inSpinner("Waiting for the pull request to be merged", func() (err error) {
+		sha, err = b.waitForPullRequest(ctx, info.Number)
+		return err
+	}); err != nil {
+		return "", err
+	}
+
+	return sha, nil
+}
+
+// waitForPullRequest waits for the pull request with the given number to be merged, within the merge timeout,
+// and returns the head of the default branch it was merged to. It fails as soon as the pull request is closed.
+func (b *Bootstrap) waitForPullRequest(ctx context.Context, number int) (string, error) {
+	if err := wait.PollImmediateWithContext(ctx, env.DefaultPollInterval, b.mergeTimeout, func(ctx context.Context) (bool, error) {
+		pr, err := b.repository.PullRequests().Get(ctx, number)
+		if err != nil {
+			return false, fmt.Errorf("failed to get pull request %d: %w", number, err)
+		}
+		if pr.Get().Merged {
+			return true, nil
+		}
+
+		open, err := pullRequestOpen(pr)
+		if err != nil {
+			return false, err
+		}
+		if !open {
+			return false, fmt.Errorf("pull request %d was closed without being merged", number)
+		}
+
+		return false, nil
+	}); err != nil {
+		return "", fmt.Errorf("pull request %d was not merged: %w", number, err)
+	}
+
+	return b.branchHead(ctx, b.defaultBranch)
+}
+
+// resolveProposal completes the upgrade proposed by a previous run if its pull request was merged, and drops it
+// if the pull request was closed. It fails as long as the pull request is open.
+func (b *Bootstrap) resolveProposal(ctx context.Context) error {
+	p := b.state.Proposed
+	pr, err := b.repository.PullRequests().Get(ctx, p.PullRequest)
+	if err != nil {
+		return fmt.Errorf("failed to get pull request %d: %w", p.PullRequest, err)
+	}
+
+	open, err := pullRequestOpen(pr)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case pr.Get().Merged:
+		b.state.merge()
+	case open:
+		return fmt.Errorf("the upgrade to %s proposed with pull request %d is not merged yet: merge or close it first", p.Version, p.PullRequest)
+	default:
+		b.state.Proposed = nil
+	}
+
+	return saveState(ctx, b.kubeclient, b.namespace(env.DefaultFluxNamespace), b.state)
+}
+
+// pullRequestOpen returns true if the given pull request is neither merged nor closed. As gitprovider only tells
+// whether a pull request is merged, its state is read from the API object of the provider.
+func pullRequestOpen(pr gitprovider.PullRequest) (bool, error) {
+	if pr.Get().Merged {
+		return false, nil
+	}
+
+	data, err := json.Marshal(pr.APIObject())
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal pull request %d: %w", pr.Get().Number, err)
+	}
+
+	var obj struct {
+		// State is the state of the GitHub, GitLab, Gitea and Bitbucket Server pull requests
+		State string `json:"state"`
+		// Status is the status of the Azure DevOps pull requests
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return false, fmt.Errorf("failed to unmarshal pull request %d: %w", pr.Get().Number, err)
+	}
+
+	switch strings.ToLower(obj.State + obj.Status) {
+	case "open", "opened", "active":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// branchHead returns the sha of the head of the given branch of the management repository.
+func (b *Bootstrap) branchHead(ctx context.Context, branch string) (string, error) {
+	dir, err := mkdirTempDir("mpas-head")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	auth, err := b.gitAuthOptions()
+	if err != nil {
+		return "", err
+	}
+
+	gitClient, err := newGitClient(dir, auth)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := gitClient.Clone(ctx, b.url, repository.CloneOptions{
+		CheckoutStrategy: repository.CheckoutStrategy{Branch: branch},
+		ShallowClone:     true,
+	}); err != nil {
+		return "", fmt.Errorf("failed to clone repository: %w", err)
+	}
+
+	return gitClient.Head()
+}
@@ -0,0 +1,251 @@
+package bootstrap
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/fluxcd/pkg/git/gogit"
+	"github.com/open-component-model/mpas/internal/printer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPullRequestBranch(t *testing.T) {
+	b := &Bootstrap{
+		options: options{
+			defaultBranch: "main",
+		},
+	}
+
+	// without a pull request the manifests are committed to the default branch
+	b.setPullRequestBranch("bootstrap", "v0.1.0")
+	assert.Empty(t, b.pullRequestBranch)
+	assert.Equal(t, "main", b.commitBranch())
+
+	b.viaPullRequest = true
+	b.setPullRequestBranch("bootstrap", "v0.1.0")
+	assert.Equal(t, "mpas/bootstrap-v0.1.0", b.commitBranch())
+
+	// a configured branch is kept
+	b.pullRequestBranch = "mpas"
+	b.setPullRequestBranch("upgrade", "v0.2.0")
+	assert.Equal(t, "mpas", b.commitBranch())
+}
+
+func TestValidatePullRequestOptions(t *testing.T) {
+	p, err := printer.Newprinter(io.Discard)
+	require.NoError(t, err)
+	opts := &options{
+		repositoryName: "mpas",
+		printer:        p,
+		dryRun:         true,
+		outputDir:      t.TempDir(),
+	}
+
+	opts.waitForMerge = true
+	assert.ErrorContains(t, validateOptions(opts), "waiting for the merge requires the manifests to be proposed with a pull request")
+
+	opts.viaPullRequest = true
+	assert.NoError(t, validateOptions(opts))
+
+	opts.repositoryURL = "https://git.example.com/mpas.git"
+	assert.ErrorContains(t, validateOptions(opts), "pull requests cannot be opened on a plain git server")
+}
+
+func TestOpenPullRequest(t *testing.T) {
+	prs := &mockPullRequestClient{}
+	b := &Bootstrap{
+		repository: &mockGitRepository{
+			pullRequestClient: prs,
+		},
+		options: options{
+			defaultBranch:     "main",
+			viaPullRequest:    true,
+			pullRequestBranch: "mpas/bootstrap-v0.1.0",
+		},
+	}
+	ctx := context.Background()
+
+	pr, err := b.openPullRequest(ctx, "Bootstrap MPAS v0.1.0", "Installs MPAS")
+	require.NoError(t, err)
+	assert.Equal(t, 1, pr.Get().Number)
+	require.Len(t, prs.prs, 1)
+	assert.Equal(t, "main", prs.prs[0].baseBranch)
+	assert.Equal(t, "mpas/bootstrap-v0.1.0", prs.prs[0].info.SourceBranch)
+
+	// the open pull request of the branch is reused
+	pr, err = b.openPullRequest(ctx, "Bootstrap MPAS v0.1.0", "Installs MPAS")
+	require.NoError(t, err)
+	assert.Equal(t, 1, pr.Get().Number)
+	assert.Len(t, prs.prs, 1)
+
+	// a merged pull request is not
+	prs.prs[0].info.Merged = true
+	pr, err = b.openPullRequest(ctx, "Bootstrap MPAS v0.1.0", "Installs MPAS")
+	require.NoError(t, err)
+	assert.Equal(t, 2, pr.Get().Number)
+
+	// nor a closed one
+	prs.prs[1].state = "closed"
+	pr, err = b.openPullRequest(ctx, "Bootstrap MPAS v0.1.0", "Installs MPAS")
+	require.NoError(t, err)
+	assert.Equal(t, 3, pr.Get().Number)
+}
+
+func TestPullRequestOpen(t *testing.T) {
+	testCases := []struct {
+		state  string
+		merged bool
+		open   bool
+	}{
+		{state: "open", open: true},
+		{state: "opened", open: true},
+		{state: "OPEN", open: true},
+		{state: "active", open: true},
+		{state: "closed"},
+		{state: "DECLINED"},
+		{state: "abandoned"},
+		{state: "open", merged: true},
+	}
+
+	for _, tc := range testCases {
+		pr := &mockPullRequest{
+			info:  gitprovider.PullRequestInfo{Merged: tc.merged},
+			state: tc.state,
+		}
+
+		open, err := pullRequestOpen(pr)
+		require.NoError(t, err)
+		assert.Equal(t, tc.open, open, tc.state)
+	}
+}
+
+func TestWaitForPullRequest(t *testing.T) {
+	url := newBareRepository(t)
+	prs := &mockPullRequestClient{}
+	b := &Bootstrap{
+		repository: &mockGitRepository{
+			pullRequestClient: prs,
+		},
+		url: url,
+		options: options{
+			defaultBranch:     "main",
+			viaPullRequest:    true,
+			pullRequestBranch: "mpas/bootstrap-v0.1.0",
+		},
+	}
+	ctx := context.Background()
+
+	pr, err := b.openPullRequest(ctx, "Bootstrap MPAS v0.1.0", "Installs MPAS")
+	require.NoError(t, err)
+	prs.prs[0].info.Merged = true
+
+	sha, err := b.waitForPullRequest(ctx, pr.Get().Number)
+	require.NoError(t, err)
+
+	c, err := cloneFunc(url)(ctx, t.TempDir())
+	require.NoError(t, err)
+	head, err := c.Head()
+	require.NoError(t, err)
+	assert.Equal(t, head, sha)
+
+	// a closed pull request is not waited for
+	pr, err = b.openPullRequest(ctx, "Bootstrap MPAS v0.1.0", "Installs MPAS")
+	require.NoError(t, err)
+	prs.prs[1].state = "closed"
+
+	_, err = b.waitForPullRequest(ctx, pr.Get().Number)
+	assert.ErrorContains(t, err, "pull request 2 was closed without being merged")
+}
+
+func TestResolveProposal(t *testing.T) {
+	testCases := []struct {
+		name     string
+		merged   bool
+		state    string
+		version  string
+		proposed bool
+		err      string
+	}{
+		{name: "merged", merged: true, state: "closed", version: "v0.6.0"},
+		{name: "closed", state: "closed", version: "v0.5.0"},
+		{name: "open", state: "open", version: "v0.5.0", proposed: true,
+			err: "the upgrade to v0.6.0 proposed with pull request 1 is not merged yet"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			prs := &mockPullRequestClient{}
+			b := &Bootstrap{
+				repository: &mockGitRepository{
+					pullRequestClient: prs,
+				},
+				state: newBootstrapState(),
+				options: options{
+					kubeclient: fake.NewClientBuilder().Build(),
+				},
+			}
+			_, err := prs.Create(ctx, "Upgrade MPAS to v0.6.0", "mpas/upgrade-v0.6.0", "main", "")
+			require.NoError(t, err)
+			prs.prs[0].info.Merged, prs.prs[0].state = tc.merged, tc.state
+
+			b.state.Version = "v0.5.0"
+			b.state.propose("v0.6.0")
+			b.state.Proposed.PullRequest = 1
+
+			err = b.resolveProposal(ctx)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tc.version, b.state.Version)
+			assert.Equal(t, tc.proposed, b.state.Proposed != nil)
+		})
+	}
+}
+
+func TestPreparePullRequestBranch(t *testing.T) {
+	url := newBareRepository(t)
+	b := &Bootstrap{
+		url: url,
+		options: options{
+			defaultBranch:     "main",
+			viaPullRequest:    true,
+			pullRequestBranch: "mpas/bootstrap-v0.1.0",
+		},
+	}
+	ctx := context.Background()
+
+	require.NoError(t, b.preparePullRequestBranch(ctx))
+
+	// the branch is created from the default branch
+	clone := func() *gogit.Client {
+		c, err := b.gitClient(ctx, t.TempDir())
+		require.NoError(t, err)
+		return c
+	}
+	sha, err := clone().Head()
+	require.NoError(t, err)
+
+	main, err := cloneFunc(url)(ctx, t.TempDir())
+	require.NoError(t, err)
+	mainSHA, err := main.Head()
+	require.NoError(t, err)
+	assert.Equal(t, mainSHA, sha)
+
+	// the existing branch is kept with its commits
+	commit, err := newPlainGitRepository(b.gitClient, nil).Commits().Create(ctx, b.commitBranch(), "Add manifests", []gitprovider.CommitFile{
+		newCommitFile("clusters/ocm-system/ocm-controller.yaml", "kind: Deployment\n"),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, b.preparePullRequestBranch(ctx))
+	sha, err = clone().Head()
+	require.NoError(t, err)
+	assert.Equal(t, commit.Get().Sha, sha)
+}
@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/open-component-model/mpas/internal/credentials"
+	"github.com/open-component-model/mpas/internal/ocm"
+	om "github.com/open-component-model/ocm/pkg/contexts/ocm"
+)
+
+// WithRegistryCredentialHelper sets the docker credential helper, e.g. ecr-login for docker-credential-ecr-login,
+// holding the credentials of the registry and of the image registry mirror. They take precedence over the docker config.
+func WithRegistryCredentialHelper(helper string) Option {
+	return func(o *options) {
+		o.registryCredHelper = helper
+	}
+}
+
+// configureRegistryCredentials sets the credentials of the registry and of the image registry mirror held by
+// the docker credential helper, if one is set. The registry is not accessed during a dry-run from a file.
+func (b *Bootstrap) configureRegistryCredentials(ctx context.Context, octx om.Context) error {
+	if b.registryCredHelper == "" {
+		return nil
+	}
+
+	registries := []string{b.imageRegistryMirror}
+	if !b.dryRun || b.fromFile == "" {
+		registries = append(registries, b.registry)
+	}
+
+	for _, registry := range registries {
+		if registry == "" {
+			continue
+		}
+
+		creds, err := credentials.DockerCredentialHelper(ctx, b.registryCredHelper, registry)
+		if err != nil {
+			return err
+		}
+
+		if err := ocm.SetRegistryCredentials(octx, registry, creds.Username, creds.Password, creds.IdentityToken); err != nil {
+			return fmt.Errorf("failed to set credentials of %s: %w", registry, err)
+		}
+	}
+
+	return nil
+}
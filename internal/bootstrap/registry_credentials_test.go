@@ -0,0 +1,52 @@
+package bootstrap
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-component-model/ocm/pkg/contexts/credentials"
+	"github.com/open-component-model/ocm/pkg/contexts/datacontext"
+	"github.com/open-component-model/ocm/pkg/contexts/oci/identity"
+	om "github.com/open-component-model/ocm/pkg/contexts/ocm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigureRegistryCredentials(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docker-credential-test"), []byte(`#!/bin/sh
+read server
+echo "{\"ServerURL\":\"$server\",\"Username\":\"$server-user\",\"Secret\":\"$server-secret\"}"
+`), 0o755))
+
+	b := &Bootstrap{
+		options: options{
+			registry:            "ghcr.io/open-component-model",
+			imageRegistryMirror: "registry.example.com/mirror",
+			registryCredHelper:  "test",
+		},
+	}
+	octx := om.New(datacontext.MODE_SHARED)
+	require.NoError(t, b.configureRegistryCredentials(context.Background(), octx))
+
+	creds, err := identity.GetCredentials(octx, "ghcr.io", "open-component-model/component-descriptors/mpas-bootstrap-component")
+	require.NoError(t, err)
+	assert.Equal(t, "ghcr.io-user", creds.GetProperty(credentials.ATTR_USERNAME))
+	assert.Equal(t, "ghcr.io-secret", creds.GetProperty(credentials.ATTR_PASSWORD))
+
+	creds, err = identity.GetCredentials(octx, "registry.example.com", "mirror/component-descriptors/ocm-controller")
+	require.NoError(t, err)
+	assert.Equal(t, "registry.example.com-user", creds.GetProperty(credentials.ATTR_USERNAME))
+
+	// the registry is not accessed during a dry-run from a file
+	b.registryCredHelper = "missing"
+	b.imageRegistryMirror = ""
+	b.dryRun, b.fromFile = true, "bundle.tar.gz"
+	assert.NoError(t, b.configureRegistryCredentials(context.Background(), om.New(datacontext.MODE_SHARED)))
+
+	b.dryRun = false
+	assert.ErrorContains(t, b.configureRegistryCredentials(context.Background(), om.New(datacontext.MODE_SHARED)), "docker-credential-missing")
+}
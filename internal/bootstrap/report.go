@@ -0,0 +1,233 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/open-component-model/mpas/internal/env"
+	om "github.com/open-component-model/ocm/pkg/contexts/ocm"
+	"github.com/open-component-model/ocm/pkg/contexts/ocm/compdesc"
+)
+
+const (
+	reportSucceeded = "Succeeded"
+	reportFailed    = "Failed"
+
+	phaseCompleted = "Completed"
+	phaseSkipped   = "Skipped"
+	phaseFailed    = "Failed"
+
+	healthReady    = "Ready"
+	healthNotReady = "NotReady"
+	healthUnknown  = "Unknown"
+)
+
+// WithReportFile sets the file the bootstrap report is written to in JSON, whether the bootstrap succeeds or fails.
+func WithReportFile(reportFile string) Option {
+	return func(o *options) {
+		o.reportFile = reportFile
+	}
+}
+
+// report is the machine-readable result of a bootstrap.
+type report struct {
+	// Status is Succeeded or Failed.
+	Status string `json:"status"`
+	// Error is the error the bootstrap failed with.
+	Error string `json:"error,omitempty"`
+	// Repository is the URL of the management repository.
+	Repository string `json:"repository,omitempty"`
+	// Branch is the branch of the management repository the manifests are committed to.
+	Branch string `json:"branch,omitempty"`
+	// Commit is the last commit of the bootstrap in the management repository.
+	Commit string `json:"commit,omitempty"`
+	// PullRequest is the URL of the pull request proposing the manifests to the default branch.
+	PullRequest string `json:"pullRequest,omitempty"`
+	// BootstrapComponent is the bootstrap component the components are installed from.
+	BootstrapComponent componentReport `json:"bootstrapComponent"`
+	// Components are the installed components, in install order.
+	Components []componentReport `json:"components,omitempty"`
+	// Phases are the phases of the bootstrap in the order they ran.
+	Phases    []phaseReport `json:"phases,omitempty"`
+	StartedAt time.Time     `json:"startedAt"`
+	Duration  string        `json:"duration"`
+}
+
+// componentReport is the result of the installation of a component.
+type componentReport struct {
+	// Name is the name of the component reference, or of the bootstrap component.
+	Name string `json:"name"`
+	// Component is the name of the OCM component.
+	Component string `json:"component,omitempty"`
+	// Constraint is the requested version constraint of the bootstrap component.
+	Constraint string `json:"constraint,omitempty"`
+	// Version is the resolved version of the component.
+	Version string `json:"version,omitempty"`
+	// Namespace is the namespace the component is installed to.
+	Namespace string `json:"namespace,omitempty"`
+	// Images are the images of the component with their digests.
+	Images []string `json:"images,omitempty"`
+	// Health is Ready, NotReady or Unknown if the components were not waited for.
+	Health string `json:"health,omitempty"`
+}
+
+// phaseReport is the result of a bootstrap phase.
+type phaseReport struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	// Status is Completed, Skipped if it was completed by a previous run, or Failed.
+	Status string `json:"status"`
+	// SHA is the commit created by the phase in the management repository.
+	SHA      string `json:"sha,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// newReport returns a report of a bootstrap started now.
+func newReport() *report {
+	return &report{
+		BootstrapComponent: componentReport{Name: env.DefaultBootstrapComponent},
+		StartedAt:          time.Now(),
+	}
+}
+
+// recordPhase adds the result of the phase with the given name to the report.
+func (r *report) recordPhase(name, version, status, sha string, err error, started time.Time) {
+	if r == nil {
+		return
+	}
+
+	p := phaseReport{
+		Name:     name,
+		Version:  version,
+		Status:   status,
+		SHA:      sha,
+		Duration: time.Since(started).Round(time.Millisecond).String(),
+	}
+	if err != nil {
+		p.Error = err.Error()
+	}
+	if sha != "" {
+		r.Commit = sha
+	}
+	r.Phases = append(r.Phases, p)
+}
+
+// setHealth sets the health of the installed components.
+func (r *report) setHealth(health string) {
+	if r == nil {
+		return
+	}
+
+	for i := range r.Components {
+		r.Components[i].Health = health
+	}
+}
+
+// reportComponents adds the given components, with the images of their component versions, to the report.
+func (b *Bootstrap) reportComponents(ociRepo om.Repository, comps []string, refs map[string]compdesc.ComponentReference) error {
+	if b.report == nil {
+		return nil
+	}
+
+	b.report.Components = nil
+	for _, comp := range comps {
+		ref := refs[comp]
+		inst, err := installerFor(comp, ref)
+		if err != nil {
+			return err
+		}
+
+		images, err := componentImages(ociRepo, ref)
+		if err != nil {
+			return fmt.Errorf("failed to get images of %s: %w", comp, err)
+		}
+
+		b.report.Components = append(b.report.Components, componentReport{
+			Name:      comp,
+			Component: ref.ComponentName,
+			Version:   ref.GetVersion(),
+			Namespace: b.namespace(inst.namespace),
+			Images:    images,
+			Health:    healthUnknown,
+		})
+	}
+
+	return nil
+}
+
+// componentImages returns the references of the images of the given component, with their digests if known.
+func componentImages(ociRepo om.Repository, ref compdesc.ComponentReference) ([]string, error) {
+	c, err := ociRepo.LookupComponent(ref.ComponentName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get component: %w", err)
+	}
+	cv, err := c.LookupVersion(ref.GetVersion())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get component version: %w", err)
+	}
+	defer cv.Close()
+
+	var images []string
+	for _, resource := range cv.GetResources() {
+		if resource.Meta().GetType() != "ociImage" {
+			continue
+		}
+
+		image, err := getResourceRef(resource)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get resource reference: %w", err)
+		}
+		if image.Digest == "" {
+			if digest := resource.Meta().Digest; digest != nil && digest.HashAlgorithm == "SHA-256" {
+				image.Digest = "sha256:" + digest.Value
+			}
+		}
+
+		images = append(images, imageReference(image))
+	}
+
+	return images, nil
+}
+
+// imageReference returns the reference of the given image, of the form name:tag@digest.
+func imageReference(image nameTag) string {
+	ref := image.Name
+	if image.Tag != "" {
+		ref += ":" + image.Tag
+	}
+	if image.Digest != "" {
+		ref += "@" + image.Digest
+	}
+
+	return ref
+}
+
+// writeReport completes the report with the result of the bootstrap and writes it to the report file.
+func (b *Bootstrap) writeReport(err error) error {
+	b.report.Status, b.report.Error = reportSucceeded, ""
+	if err != nil {
+		b.report.Status = reportFailed
+		b.report.Error = err.Error()
+	}
+	b.report.Repository = b.url
+	b.report.Branch = b.commitBranch()
+	b.report.Duration = time.Since(b.report.StartedAt).Round(time.Millisecond).String()
+
+	data, err := json.MarshalIndent(b.report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal bootstrap report: %w", err)
+	}
+
+	if err := os.WriteFile(b.reportFile, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write bootstrap report: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package bootstrap
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/open-component-model/mpas/internal/env"
+	"github.com/open-component-model/ocm/pkg/contexts/ocm/compdesc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportComponents(t *testing.T) {
+	repo := &mockRepository{
+		cv: []*mockComponentAccess{
+			newFakeControllerComponent(env.OcmControllerName),
+		},
+	}
+	refs := map[string]compdesc.ComponentReference{
+		env.OcmControllerName: newComponentReference(t, env.OcmControllerName, "v1.0.0", nil),
+	}
+
+	b := &Bootstrap{
+		report: newReport(),
+		options: options{
+			namespaces: map[string]string{env.DefaultOCMNamespace: "tenant-ocm-system"},
+		},
+	}
+	require.NoError(t, b.reportComponents(repo, getOrderedKeys(refs), refs))
+	assert.Equal(t, []componentReport{
+		{
+			Name:      env.OcmControllerName,
+			Component: "ocm.software/mpas/" + env.OcmControllerName,
+			Version:   "v1.0.0",
+			Namespace: "tenant-ocm-system",
+			Images:    []string{"ghcr.io/new-user/ocm-controller:v1.0.0"},
+			Health:    healthUnknown,
+		},
+	}, b.report.Components)
+
+	b.report.setHealth(healthReady)
+	assert.Equal(t, healthReady, b.report.Components[0].Health)
+
+	// without a report file nothing is collected
+	b.report = nil
+	require.NoError(t, b.reportComponents(repo, getOrderedKeys(refs), refs))
+	b.report.setHealth(healthReady)
+	b.report.recordPhase("flux", "v2.1.0", phaseCompleted, "abc", nil, time.Now())
+}
+
+func TestImageReference(t *testing.T) {
+	assert.Equal(t, "ghcr.io/ocm/ocm-controller:v0.16.0", imageReference(nameTag{Name: "ghcr.io/ocm/ocm-controller", Tag: "v0.16.0"}))
+	assert.Equal(t, "registry.local:5000/ocm-controller:v0.16.0@sha256:abc",
+		imageReference(nameTag{Name: "registry.local:5000/ocm-controller", Tag: "v0.16.0", Digest: "sha256:abc"}))
+	assert.Equal(t, "ghcr.io/ocm/ocm-controller@sha256:abc", imageReference(nameTag{Name: "ghcr.io/ocm/ocm-controller", Digest: "sha256:abc"}))
+}
+
+func TestWriteReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	b := &Bootstrap{
+		url:    "https://github.com/my-org/mpas",
+		report: newReport(),
+		options: options{
+			defaultBranch: "main",
+			reportFile:    path,
+		},
+	}
+	b.report.BootstrapComponent.Constraint = "~v0.10.0"
+	b.report.BootstrapComponent.Version = "v0.10.2"
+
+	started := time.Now()
+	b.report.recordPhase("flux", "v2.1.0", phaseSkipped, "abc", nil, started)
+	b.report.recordPhase("cert-manager", "v1.13.1", phaseCompleted, "def", nil, started)
+	b.report.recordPhase("ocm-controller", "v0.16.0", phaseFailed, "", errors.New("failed to commit"), started)
+	require.NoError(t, b.writeReport(errors.New("failed to generate manifest: failed to commit")))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var r report
+	require.NoError(t, json.Unmarshal(data, &r))
+
+	assert.Equal(t, reportFailed, r.Status)
+	assert.Equal(t, "failed to generate manifest: failed to commit", r.Error)
+	assert.Equal(t, "https://github.com/my-org/mpas", r.Repository)
+	assert.Equal(t, "main", r.Branch)
+	assert.Equal(t, "def", r.Commit)
+	assert.Equal(t, componentReport{Name: env.DefaultBootstrapComponent, Constraint: "~v0.10.0", Version: "v0.10.2"}, r.BootstrapComponent)
+	require.Len(t, r.Phases, 3)
+	assert.Equal(t, phaseSkipped, r.Phases[0].Status)
+	assert.Equal(t, "ocm-controller", r.Phases[2].Name)
+	assert.Equal(t, phaseFailed, r.Phases[2].Status)
+	assert.Equal(t, "failed to commit", r.Phases[2].Error)
+	assert.NotEmpty(t, r.Phases[2].Duration)
+
+	require.NoError(t, b.writeReport(nil))
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &r))
+	assert.Equal(t, reportSucceeded, r.Status)
+}
@@ -0,0 +1,314 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/fluxcd/pkg/git"
+	"github.com/fluxcd/pkg/git/repository"
+	extgogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// sshSignatureNamespace is the namespace git signs and verifies commits with.
+	sshSignatureNamespace = "git"
+	// sshSignatureHash is the hash algorithm of the signed commits.
+	sshSignatureHash = "sha512"
+)
+
+// WithCommitAuthor sets the name and email of the author of the commits made to the management repository.
+func WithCommitAuthor(name, email string) Option {
+	return func(o *options) {
+		o.authorName = name
+		o.authorEmail = email
+	}
+}
+
+// WithGPGSigning signs the commits with the OpenPGP key of the given armored key ring, selected by the key ID.
+func WithGPGSigning(keyRing, passphrase, keyID string) Option {
+	return func(o *options) {
+		o.gpgKeyRing = keyRing
+		o.gpgPassphrase = passphrase
+		o.gpgKeyID = keyID
+	}
+}
+
+// WithSSHSigning signs the commits with the given SSH private key, decrypted with the passphrase if it is encrypted.
+func WithSSHSigning(keyFile, passphrase string) Option {
+	return func(o *options) {
+		o.sshSigningKey = keyFile
+		o.sshSigningPassphrase = passphrase
+	}
+}
+
+// committer commits to a clone of the management repository as the configured author and signs the commits
+// with the configured key. A nil committer commits as the default author without signing.
+type committer struct {
+	name  string
+	email string
+	// sign returns the armored signature of the given encoded commit, nil if the commits are not signed
+	sign func(commit []byte) (string, error)
+}
+
+// newCommitter returns the committer of the given options.
+func newCommitter(o *options) (*committer, error) {
+	c := &committer{
+		name:  o.authorName,
+		email: o.authorEmail,
+	}
+
+	switch {
+	case o.gpgKeyRing != "" && o.sshSigningKey != "":
+		return nil, fmt.Errorf("commits can be signed either with an OpenPGP or an SSH key, not both")
+	case o.gpgKeyRing != "":
+		entity, err := loadGPGEntity(o.gpgKeyRing, o.gpgPassphrase, o.gpgKeyID)
+		if err != nil {
+			return nil, err
+		}
+		c.sign = gpgSigner(entity)
+	case o.sshSigningKey != "":
+		signer, err := loadSSHSigner(o.sshSigningKey, o.sshSigningPassphrase)
+		if err != nil {
+			return nil, err
+		}
+		c.sign = sshSigner(signer)
+	case o.gpgKeyID != "" || o.gpgPassphrase != "":
+		return nil, fmt.Errorf("a key ring must be set to sign commits with an OpenPGP key")
+	}
+
+	return c, nil
+}
+
+// local returns true if the commits must be made in a local clone and pushed, as the git provider APIs commit
+// as the owner of the token and take neither an author nor a signature.
+func (c *committer) local() bool {
+	return c != nil && (c.name != "" || c.email != "" || c.sign != nil)
+}
+
+// commit commits the changes of the given clone and the given files, as the given author unless one is configured,
+// and signs the commit. It returns the sha of the commit, or of the head and git.ErrNoStagedFiles if there was
+// nothing to commit.
+func (c *committer) commit(gitClient repository.Client, author, message string, opts ...repository.CommitOption) (string, error) {
+	signature := git.Signature{Name: author}
+	if c != nil {
+		if c.name != "" {
+			signature.Name = c.name
+		}
+		signature.Email = c.email
+	}
+
+	sha, err := gitClient.Commit(git.Commit{
+		Author:  signature,
+		Message: message,
+	}, opts...)
+	if err != nil || c == nil || c.sign == nil {
+		return sha, err
+	}
+
+	return signHead(gitClient.Path(), c.sign)
+}
+
+// signHead replaces the head commit of the repository at the given path with the same commit signed
+// with the given function, and returns its sha. Git stores OpenPGP and SSH signatures alike in the gpgsig header.
+func signHead(path string, sign func([]byte) (string, error)) (string, error) {
+	repo, err := extgogit.PlainOpen(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("failed to get head: %w", err)
+	}
+
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return "", fmt.Errorf("failed to get head commit: %w", err)
+	}
+
+	unsigned := &plumbing.MemoryObject{}
+	if err := commit.EncodeWithoutSignature(unsigned); err != nil {
+		return "", fmt.Errorf("failed to encode commit: %w", err)
+	}
+	r, err := unsigned.Reader()
+	if err != nil {
+		return "", fmt.Errorf("failed to read commit: %w", err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to read commit: %w", err)
+	}
+
+	commit.PGPSignature, err = sign(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign commit: %w", err)
+	}
+
+	signed := repo.Storer.NewEncodedObject()
+	if err := commit.Encode(signed); err != nil {
+		return "", fmt.Errorf("failed to encode signed commit: %w", err)
+	}
+	hash, err := repo.Storer.SetEncodedObject(signed)
+	if err != nil {
+		return "", fmt.Errorf("failed to store signed commit: %w", err)
+	}
+
+	// the head is the checked out branch, the reference it points to is moved
+	ref, err := repo.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return "", fmt.Errorf("failed to get head: %w", err)
+	}
+	name := plumbing.HEAD
+	if ref.Type() == plumbing.SymbolicReference {
+		name = ref.Target()
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(name, hash)); err != nil {
+		return "", fmt.Errorf("failed to update %s: %w", name, err)
+	}
+
+	return hash.String(), nil
+}
+
+// loadGPGEntity returns the OpenPGP entity of the given armored key ring with the given key ID, or the first one
+// if no key ID is given, with its private keys decrypted with the passphrase.
+func loadGPGEntity(keyRing, passphrase, keyID string) (*openpgp.Entity, error) {
+	f, err := os.Open(keyRing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open key ring: %w", err)
+	}
+	defer f.Close()
+
+	entities, err := openpgp.ReadArmoredKeyRing(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key ring %s: %w", keyRing, err)
+	}
+
+	var entity *openpgp.Entity
+	for _, e := range entities {
+		if keyID == "" || strings.EqualFold(e.PrimaryKey.KeyIdString(), keyID) ||
+			strings.HasSuffix(strings.ToUpper(fmt.Sprintf("%X", e.PrimaryKey.Fingerprint)), strings.ToUpper(keyID)) {
+			entity = e
+			break
+		}
+	}
+	if entity == nil {
+		return nil, fmt.Errorf("key ring %s holds no key with ID %s", keyRing, keyID)
+	}
+	if entity.PrivateKey == nil {
+		return nil, fmt.Errorf("key ring %s holds no private key for %s", keyRing, entity.PrimaryKey.KeyIdString())
+	}
+
+	if entity.PrivateKey.Encrypted {
+		if err := entity.PrivateKey.Decrypt([]byte(passphrase)); err != nil {
+			return nil, fmt.Errorf("failed to decrypt private key %s: %w", entity.PrimaryKey.KeyIdString(), err)
+		}
+	}
+	for _, subkey := range entity.Subkeys {
+		if subkey.PrivateKey != nil && subkey.PrivateKey.Encrypted {
+			if err := subkey.PrivateKey.Decrypt([]byte(passphrase)); err != nil {
+				return nil, fmt.Errorf("failed to decrypt private subkey %s: %w", subkey.PublicKey.KeyIdString(), err)
+			}
+		}
+	}
+
+	return entity, nil
+}
+
+// gpgSigner returns a function signing commits with the given OpenPGP entity.
+func gpgSigner(entity *openpgp.Entity) func([]byte) (string, error) {
+	return func(commit []byte) (string, error) {
+		var b bytes.Buffer
+		if err := openpgp.ArmoredDetachSign(&b, entity, bytes.NewReader(commit), nil); err != nil {
+			return "", err
+		}
+
+		return b.String(), nil
+	}
+}
+
+// loadSSHSigner returns the signer of the given SSH private key file, decrypted with the passphrase if it is encrypted.
+func loadSSHSigner(keyFile, passphrase string) (ssh.Signer, error) {
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SSH signing key: %w", err)
+	}
+
+	signer, err := ssh.ParsePrivateKey(data)
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(data, []byte(passphrase))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SSH signing key %s: %w", keyFile, err)
+	}
+
+	return signer, nil
+}
+
+// sshSigner returns a function signing commits with the given SSH key in the armored SSHSIG format git verifies.
+func sshSigner(signer ssh.Signer) func([]byte) (string, error) {
+	return func(commit []byte) (string, error) {
+		digest := sha512.Sum512(commit)
+		data := append([]byte("SSHSIG"), ssh.Marshal(struct {
+			Namespace string
+			Reserved  string
+			Hash      string
+			Digest    []byte
+		}{sshSignatureNamespace, "", sshSignatureHash, digest[:]})...)
+
+		var (
+			sig *ssh.Signature
+			err error
+		)
+		// RSA keys sign with SHA-512 as SHA-1 signatures are refused by git
+		if as, ok := signer.(ssh.AlgorithmSigner); ok && signer.PublicKey().Type() == ssh.KeyAlgoRSA {
+			sig, err = as.SignWithAlgorithm(rand.Reader, data, ssh.KeyAlgoRSASHA512)
+		} else {
+			sig, err = signer.Sign(rand.Reader, data)
+		}
+		if err != nil {
+			return "", err
+		}
+
+		blob := append([]byte("SSHSIG"), ssh.Marshal(struct {
+			Version   uint32
+			PublicKey []byte
+			Namespace string
+			Reserved  string
+			Hash      string
+			Signature []byte
+		}{1, signer.PublicKey().Marshal(), sshSignatureNamespace, "", sshSignatureHash, ssh.Marshal(sig)})...)
+
+		return armorSSHSignature(blob), nil
+	}
+}
+
+// armorSSHSignature returns the given SSHSIG blob armored as by ssh-keygen.
+func armorSSHSignature(blob []byte) string {
+	encoded := base64.StdEncoding.EncodeToString(blob)
+
+	var b strings.Builder
+	b.WriteString("-----BEGIN SSH SIGNATURE-----\n")
+	for len(encoded) > 70 {
+		b.WriteString(encoded[:70] + "\n")
+		encoded = encoded[70:]
+	}
+	b.WriteString(encoded + "\n")
+	b.WriteString("-----END SSH SIGNATURE-----\n")
+
+	return b.String()
+}
@@ -0,0 +1,170 @@
+package bootstrap
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	gogitv5 "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestCommitterSignsWithGPG(t *testing.T) {
+	entity, err := openpgp.NewEntity("MPAS", "", "mpas@example.com", nil)
+	require.NoError(t, err)
+
+	var public bytes.Buffer
+	w, err := armor.Encode(&public, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.Serialize(w))
+	require.NoError(t, w.Close())
+
+	require.NoError(t, entity.EncryptPrivateKeys([]byte("secret"), nil))
+	var private bytes.Buffer
+	w, err = armor.Encode(&private, openpgp.PrivateKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.SerializePrivateWithoutSigning(w, nil))
+	require.NoError(t, w.Close())
+	keyRing := filepath.Join(t.TempDir(), "keyring.asc")
+	require.NoError(t, os.WriteFile(keyRing, private.Bytes(), 0o600))
+
+	_, err = newCommitter(&options{gpgKeyRing: keyRing, gpgPassphrase: "wrong"})
+	assert.ErrorContains(t, err, "failed to decrypt private key")
+	_, err = newCommitter(&options{gpgKeyRing: keyRing, gpgKeyID: "0123456789ABCDEF"})
+	assert.ErrorContains(t, err, "holds no key with ID 0123456789ABCDEF")
+
+	c, err := newCommitter(&options{
+		authorName:    "Bot",
+		authorEmail:   "bot@example.com",
+		gpgKeyRing:    keyRing,
+		gpgPassphrase: "secret",
+		gpgKeyID:      entity.PrimaryKey.KeyIdShortString(),
+	})
+	require.NoError(t, err)
+	assert.True(t, c.local())
+
+	commit := commitSigned(t, c)
+	assert.Equal(t, "Bot", commit.Author.Name)
+	assert.Equal(t, "bot@example.com", commit.Author.Email)
+	assert.True(t, strings.HasPrefix(commit.PGPSignature, "-----BEGIN PGP SIGNATURE-----"))
+	_, err = commit.Verify(public.String())
+	assert.NoError(t, err)
+}
+
+func TestCommitterSignsWithSSH(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	block, err := ssh.MarshalPrivateKeyWithPassphrase(priv, "", []byte("secret"))
+	require.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "id_ed25519")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(block), 0o600))
+
+	c, err := newCommitter(&options{sshSigningKey: keyFile, sshSigningPassphrase: "secret"})
+	require.NoError(t, err)
+
+	commit := commitSigned(t, c)
+	assert.Equal(t, "MPAS", commit.Author.Name, "expected the default author to be kept")
+
+	// the signature is verified as by ssh-keygen -Y verify
+	armored := strings.TrimSpace(commit.PGPSignature)
+	require.True(t, strings.HasPrefix(armored, "-----BEGIN SSH SIGNATURE-----\n"))
+	require.True(t, strings.HasSuffix(armored, "\n-----END SSH SIGNATURE-----"))
+	lines := strings.Split(armored, "\n")
+	for _, line := range lines {
+		assert.LessOrEqual(t, len(line), 70)
+	}
+	blob, err := base64.StdEncoding.DecodeString(strings.Join(lines[1:len(lines)-1], ""))
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(blob, []byte("SSHSIG")))
+
+	var sig struct {
+		Version   uint32
+		PublicKey []byte
+		Namespace string
+		Reserved  string
+		Hash      string
+		Signature []byte
+	}
+	require.NoError(t, ssh.Unmarshal(blob[len("SSHSIG"):], &sig))
+	assert.Equal(t, "git", sig.Namespace)
+	assert.Equal(t, "sha512", sig.Hash)
+
+	publicKey, err := ssh.ParsePublicKey(sig.PublicKey)
+	require.NoError(t, err)
+	expected, err := ssh.NewPublicKey(pub)
+	require.NoError(t, err)
+	assert.Equal(t, expected.Marshal(), publicKey.Marshal())
+
+	var signature ssh.Signature
+	require.NoError(t, ssh.Unmarshal(sig.Signature, &signature))
+
+	unsigned := &plumbing.MemoryObject{}
+	require.NoError(t, commit.EncodeWithoutSignature(unsigned))
+	r, err := unsigned.Reader()
+	require.NoError(t, err)
+	encoded, err := io.ReadAll(r)
+	require.NoError(t, err)
+	digest := sha512.Sum512(encoded)
+	data := append([]byte("SSHSIG"), ssh.Marshal(struct {
+		Namespace string
+		Reserved  string
+		Hash      string
+		Digest    []byte
+	}{"git", "", "sha512", digest[:]})...)
+	assert.NoError(t, publicKey.Verify(data, &signature))
+}
+
+func TestNewCommitter(t *testing.T) {
+	c, err := newCommitter(&options{})
+	require.NoError(t, err)
+	assert.False(t, c.local(), "expected the git provider APIs to be used without author nor signing key")
+
+	var nilCommitter *committer
+	assert.False(t, nilCommitter.local())
+
+	_, err = newCommitter(&options{gpgKeyRing: "keyring.asc", sshSigningKey: "id_ed25519"})
+	assert.ErrorContains(t, err, "either with an OpenPGP or an SSH key, not both")
+
+	_, err = newCommitter(&options{gpgKeyID: "0123456789ABCDEF"})
+	assert.ErrorContains(t, err, "a key ring must be set")
+}
+
+// commitSigned commits a file to a new repository with the given committer and returns the pushed commit.
+func commitSigned(t *testing.T, c *committer) *object.Commit {
+	t.Helper()
+
+	clone := cloneFunc(newBareRepository(t))
+	created, err := newPlainGitRepository(clone, c).Commits().Create(context.Background(), "main", "Add manifests",
+		[]gitprovider.CommitFile{newCommitFile("clusters/ocm-system/ocm-controller.yaml", "kind: Deployment\n")})
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	_, err = clone(context.Background(), dir)
+	require.NoError(t, err)
+	repo, err := gogitv5.PlainOpen(dir)
+	require.NoError(t, err)
+	head, err := repo.Head()
+	require.NoError(t, err)
+	assert.Equal(t, created.Get().Sha, head.Hash().String(), "expected the signed commit to be pushed")
+
+	commit, err := repo.CommitObject(head.Hash())
+	require.NoError(t, err)
+
+	return commit
+}
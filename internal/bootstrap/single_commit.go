@@ -0,0 +1,188 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/open-component-model/mpas/internal/env"
+)
+
+// WithSingleCommit sets whether the manifests generated by all installers are collected
+// and committed to the management repository at once, instead of one commit per installer.
+func WithSingleCommit(singleCommit bool) Option {
+	return func(o *options) {
+		o.singleCommit = singleCommit
+	}
+}
+
+// stagedPhase is a bootstrap phase whose files are staged but not committed yet.
+type stagedPhase struct {
+	name    string
+	version string
+}
+
+// batchRepository is a management repository that stages the files of every commit.
+// The staged files are committed at once to the wrapped repository by commitBatch.
+type batchRepository struct {
+	gitprovider.UserRepository
+
+	commitClient *batchCommitClient
+	// pending are the phases that staged files, they are completed once the files are committed
+	pending []stagedPhase
+}
+
+var _ gitprovider.UserRepository = &batchRepository{}
+
+// newBatchRepository returns a repository staging the commits to the given repository.
+func newBatchRepository(repository gitprovider.UserRepository) *batchRepository {
+	return &batchRepository{
+		UserRepository: repository,
+		commitClient: &batchCommitClient{
+			files: map[string]*string{},
+		},
+	}
+}
+
+func (r *batchRepository) Commits() gitprovider.CommitClient {
+	return r.commitClient
+}
+
+type batchCommitClient struct {
+	gitprovider.CommitClient
+
+	// paths holds the staged paths in the order they were first staged
+	paths []string
+	// files holds the staged content by path, a nil content marks a deletion
+	files map[string]*string
+	// messages holds the subjects of the staged commits
+	messages []string
+	mu       sync.Mutex
+}
+
+var _ gitprovider.CommitClient = &batchCommitClient{}
+
+// Create stages the given files, a file staged again replaces the previous content.
+// The returned commit has no sha, as nothing is committed yet.
+func (c *batchCommitClient) Create(_ context.Context, _, message string, files []gitprovider.CommitFile) (gitprovider.Commit, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, file := range files {
+		if file.Path == nil {
+			continue
+		}
+
+		if _, ok := c.files[*file.Path]; !ok {
+			c.paths = append(c.paths, *file.Path)
+		}
+		c.files[*file.Path] = file.Content
+	}
+
+	c.messages = append(c.messages, strings.SplitN(message, "\n", 2)[0])
+
+	return &localCommit{
+		info: gitprovider.CommitInfo{
+			Message: message,
+		},
+	}, nil
+}
+
+// staged returns the number of staged commits.
+func (c *batchCommitClient) staged() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.messages)
+}
+
+// get returns the staged content of the given path, a nil content marks a deletion.
+func (c *batchCommitClient) get(path string) (*string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	content, ok := c.files[path]
+	return content, ok
+}
+
+// flush returns the staged files and the subjects of the staged commits and resets the stage.
+func (c *batchCommitClient) flush() ([]gitprovider.CommitFile, []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	files := make([]gitprovider.CommitFile, 0, len(c.paths))
+	for _, path := range c.paths {
+		files = append(files, gitprovider.CommitFile{
+			Path:    gitprovider.StringVar(path),
+			Content: c.files[path],
+		})
+	}
+	messages := c.messages
+
+	c.paths = nil
+	c.files = map[string]*string{}
+	c.messages = nil
+
+	return files, messages
+}
+
+// commitBatch commits the staged files of the given bootstrap component version at once and completes the phases
+// that staged them. If nothing is staged, the given sha of the latest commit is returned.
+// Gitea cannot commit multiple files through its API, the files are committed through a local clone instead.
+func (b *Bootstrap) commitBatch(ctx context.Context, version, latestSHA string) (string, error) {
+	files, messages := b.batch.commitClient.flush()
+	if len(files) == 0 {
+		return latestSHA, nil
+	}
+
+	commitMsg := fmt.Sprintf("Add MPAS bootstrap component %s manifests\n\n- %s", version, strings.Join(messages, "\n- "))
+	if b.commitMessageAppendix != "" {
+		commitMsg = commitMsg + "\n\n" + b.commitMessageAppendix
+	}
+
+	commits := b.batch.UserRepository.Commits()
+	if b.providerID() == env.ProviderGitea {
+		commits = newPlainGitRepository(b.gitClient, b.committer).Commits()
+		if err := decodeGiteaFiles(files); err != nil {
+			return "", err
+		}
+	}
+
+	commit, err := commits.Create(ctx, b.commitBranch(), commitMsg, files)
+	if err != nil {
+		return "", fmt.Errorf("failed to commit manifests: %w", err)
+	}
+
+	sha := commit.Get().Sha
+	for _, p := range b.batch.pending {
+		b.state.complete(p.name, p.version, sha)
+	}
+	b.batch.pending = nil
+
+	return sha, nil
+}
+
+// decodeGiteaFiles decodes the contents of the given files, which were formatted for the gitea API,
+// so that they can be committed through a clone of the repository.
+func decodeGiteaFiles(files []gitprovider.CommitFile) error {
+	for i, file := range files {
+		if file.Content == nil {
+			continue
+		}
+
+		content, err := base64.StdEncoding.DecodeString(*file.Content)
+		if err != nil {
+			return fmt.Errorf("failed to decode %s: %w", *file.Path, err)
+		}
+		files[i].Content = gitprovider.StringVar(string(content))
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package bootstrap
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/open-component-model/mpas/internal/env"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitBatch(t *testing.T) {
+	mc := &mockCommitClient{
+		commit: &mockCommit{
+			sha: "sha",
+		},
+	}
+	batch := newBatchRepository(&mockGitRepository{
+		commitClient: mc,
+	})
+	b := &Bootstrap{
+		repository: batch,
+		batch:      batch,
+		state:      newBootstrapState(),
+		options: options{
+			// no provider client is needed to write the batch
+			dryRun:                true,
+			defaultBranch:         "main",
+			commitMessageAppendix: "[skip ci]",
+		},
+	}
+	b.state.Version = "v0.1.0"
+	ctx := context.Background()
+
+	// nothing staged, the latest commit is kept
+	sha, err := b.commitBatch(ctx, b.state.Version, "latest")
+	require.NoError(t, err)
+	assert.Equal(t, "latest", sha)
+	assert.Empty(t, mc.calledWidth)
+
+	commit, err := b.repository.Commits().Create(ctx, "main", "Add cert-manager v1.0.0 manifests\n\n[skip ci]", []gitprovider.CommitFile{
+		newCommitFile("clusters/cert-manager/cert-manager.yaml", "cert-manager"),
+		newCommitFile("clusters/cert-manager/cluster_issuer.yaml", "issuer"),
+	})
+	require.NoError(t, err)
+	assert.Empty(t, commit.Get().Sha)
+	b.batch.pending = append(b.batch.pending, stagedPhase{name: "cert-manager", version: "v1.0.0"})
+
+	_, err = b.repository.Commits().Create(ctx, "main", "Add ocm-controller v0.1.0 manifests", []gitprovider.CommitFile{
+		newCommitFile("clusters/ocm-system/ocm-controller.yaml", "ocm-controller"),
+		newCommitFile("clusters/cert-manager/cluster_issuer.yaml", "updated issuer"),
+		{Path: gitprovider.StringVar("clusters/old.yaml")},
+	})
+	require.NoError(t, err)
+	b.batch.pending = append(b.batch.pending, stagedPhase{name: "ocm-controller", version: "v0.1.0"})
+	assert.Equal(t, 2, batch.commitClient.staged())
+	assert.Empty(t, mc.calledWidth)
+
+	sha, err = b.commitBatch(ctx, b.state.Version, "latest")
+	require.NoError(t, err)
+	assert.Equal(t, "sha", sha)
+
+	require.Len(t, mc.calledWidth, 1)
+	assert.Equal(t, "main", mc.calledWidth[0][0])
+	assert.Equal(t, `Add MPAS bootstrap component v0.1.0 manifests
+
+- Add cert-manager v1.0.0 manifests
+- Add ocm-controller v0.1.0 manifests
+
+[skip ci]`, mc.calledWidth[0][1])
+
+	files := mc.calledWidth[0][2].([]gitprovider.CommitFile)
+	require.Len(t, files, 4)
+	assert.Equal(t, "clusters/cert-manager/cert-manager.yaml", *files[0].Path)
+	assert.Equal(t, "clusters/cert-manager/cluster_issuer.yaml", *files[1].Path)
+	assert.Equal(t, "updated issuer", *files[1].Content)
+	assert.Equal(t, "clusters/ocm-system/ocm-controller.yaml", *files[2].Path)
+	assert.Equal(t, "clusters/old.yaml", *files[3].Path)
+	assert.Nil(t, files[3].Content)
+
+	// the phases that staged the files are completed with the commit
+	p, ok := b.state.completed("cert-manager", "v1.0.0")
+	require.True(t, ok)
+	assert.Equal(t, "sha", p.SHA)
+	p, ok = b.state.completed("ocm-controller", "v0.1.0")
+	require.True(t, ok)
+	assert.Equal(t, "sha", p.SHA)
+	assert.Empty(t, b.batch.pending)
+	assert.Equal(t, 0, batch.commitClient.staged())
+}
+
+func TestDecodeGiteaFiles(t *testing.T) {
+	files := []gitprovider.CommitFile{
+		newCommitFile("clusters/ocm-system/ocm-controller.yaml", SetProviderDataFormat(env.ProviderGitea, []byte("ocm-controller"))),
+		{Path: gitprovider.StringVar("clusters/old.yaml")},
+	}
+
+	require.NoError(t, decodeGiteaFiles(files))
+	assert.Equal(t, "ocm-controller", *files[0].Content)
+	assert.Nil(t, files[1].Content)
+
+	assert.ErrorContains(t, decodeGiteaFiles([]gitprovider.CommitFile{
+		newCommitFile("clusters/ocm-system/ocm-controller.yaml", "not base64!"),
+	}), "failed to decode clusters/ocm-system/ocm-controller.yaml")
+}
@@ -0,0 +1,198 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// stateConfigMapName is the name of the ConfigMap the bootstrap state is persisted in.
+	stateConfigMapName = "mpas-bootstrap-state"
+	// stateKey is the key of the ConfigMap data holding the bootstrap state.
+	stateKey = "state.json"
+)
+
+// phase records a completed bootstrap phase.
+type phase struct {
+	// Version is the version of the component installed by the phase
+	// or the revision the phase acted on.
+	Version string `json:"version,omitempty"`
+	// SHA is the commit created by the phase in the management repository.
+	SHA string `json:"sha,omitempty"`
+	// CompletedAt is the time the phase completed.
+	CompletedAt metav1.Time `json:"completedAt"`
+}
+
+// bootstrapState holds the completed phases of a bootstrap so that a failed bootstrap
+// can be resumed where it stopped.
+type bootstrapState struct {
+	// Version is the version of the bootstrap component the components were installed from.
+	Version string `json:"version,omitempty"`
+	// Components are the names of the components installed from the bootstrap component.
+	Components []string `json:"components,omitempty"`
+	// Namespaces maps the default namespaces to the namespaces the components were installed to.
+	Namespaces map[string]string `json:"namespaces,omitempty"`
+	// ImageRegistryMirror is the registry the components were copied to with their images.
+	ImageRegistryMirror string `json:"imageRegistryMirror,omitempty"`
+	// FleetBase is the path the manifests of the components are shared at by the clusters of a fleet.
+	FleetBase string `json:"fleetBase,omitempty"`
+	// ExistingFlux is true if the flux installed in the cluster was adopted instead of installed.
+	ExistingFlux bool `json:"existingFlux,omitempty"`
+	// ExistingCertManager is true if the cert-manager installed in the cluster was adopted instead of installed.
+	ExistingCertManager bool `json:"existingCertManager,omitempty"`
+	// SyncName is the name of the flux objects syncing the management repository, if they are not named after the flux namespace.
+	SyncName string `json:"syncName,omitempty"`
+	// Patches are the components whose patches are committed to the management repository.
+	Patches []string         `json:"patches,omitempty"`
+	Phases  map[string]phase `json:"phases"`
+
+	// Proposed is the upgrade proposed with a pull request, its phases are completed once the pull request is merged.
+	Proposed *proposal `json:"proposed,omitempty"`
+}
+
+// proposal is an upgrade proposed with a pull request.
+type proposal struct {
+	// Version is the version of the bootstrap component the upgrade is proposed to.
+	Version string `json:"version"`
+	// PullRequest is the number of the pull request proposing the upgrade.
+	PullRequest int `json:"pullRequest,omitempty"`
+	// Phases are the phases whose manifests are proposed.
+	Phases map[string]phase `json:"phases,omitempty"`
+}
+
+func newBootstrapState() *bootstrapState {
+	return &bootstrapState{
+		Phases: make(map[string]phase),
+	}
+}
+
+// completed returns the phase with the given name if it was completed with the given version.
+func (s *bootstrapState) completed(name, version string) (phase, bool) {
+	p, ok := s.Phases[name]
+	if !ok || p.Version != version {
+		return phase{}, false
+	}
+
+	return p, true
+}
+
+// complete marks the phase with the given name as completed, or as proposed while an upgrade is proposed.
+func (s *bootstrapState) complete(name, version, sha string) {
+	phases := s.Phases
+	if s.Proposed != nil {
+		phases = s.Proposed.Phases
+	}
+
+	phases[name] = phase{
+		Version:     version,
+		SHA:         sha,
+		CompletedAt: metav1.NewTime(time.Now()),
+	}
+}
+
+// propose starts the proposal of an upgrade to the given version.
+func (s *bootstrapState) propose(version string) {
+	s.Proposed = &proposal{
+		Version: version,
+		Phases:  make(map[string]phase),
+	}
+}
+
+// merge completes the phases of the proposed upgrade and moves the state to its version.
+func (s *bootstrapState) merge() {
+	for name, p := range s.Proposed.Phases {
+		s.Phases[name] = p
+	}
+	s.Version = s.Proposed.Version
+	s.Proposed = nil
+}
+
+// loadState reads the bootstrap state from the cluster. An empty state is returned if none was persisted yet.
+func loadState(ctx context.Context, kubeClient client.Client, namespace string) (*bootstrapState, error) {
+	cm := &corev1.ConfigMap{}
+	if err := kubeClient.Get(ctx, client.ObjectKey{Name: stateConfigMapName, Namespace: namespace}, cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return newBootstrapState(), nil
+		}
+
+		return nil, fmt.Errorf("failed to get bootstrap state: %w", err)
+	}
+
+	state := newBootstrapState()
+	if data, ok := cm.Data[stateKey]; ok {
+		if err := json.Unmarshal([]byte(data), state); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal bootstrap state: %w", err)
+		}
+	}
+
+	if state.Phases == nil {
+		state.Phases = make(map[string]phase)
+	}
+
+	return state, nil
+}
+
+// saveState persists the bootstrap state in the cluster. As long as the namespace does not exist,
+// which is the case until flux is installed, the state is kept in memory only.
+func saveState(ctx context.Context, kubeClient client.Client, namespace string, state *bootstrapState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal bootstrap state: %w", err)
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      stateConfigMapName,
+			Namespace: namespace,
+		},
+	}
+
+	err = kubeClient.Get(ctx, client.ObjectKeyFromObject(cm), cm)
+	switch {
+	case apierrors.IsNotFound(err):
+		cm.Data = map[string]string{stateKey: string(data)}
+		if err := kubeClient.Create(ctx, cm); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+
+			return fmt.Errorf("failed to create bootstrap state: %w", err)
+		}
+	case err != nil:
+		return fmt.Errorf("failed to get bootstrap state: %w", err)
+	default:
+		cm.Data = map[string]string{stateKey: string(data)}
+		if err := kubeClient.Update(ctx, cm); err != nil {
+			return fmt.Errorf("failed to update bootstrap state: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// deleteState deletes the bootstrap state from the cluster.
+func deleteState(ctx context.Context, kubeClient client.Client, namespace string) error {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      stateConfigMapName,
+			Namespace: namespace,
+		},
+	}
+
+	if err := kubeClient.Delete(ctx, cm); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to delete bootstrap state: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package bootstrap
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestBootstrapState(t *testing.T) {
+	ctx := context.Background()
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "flux-system"}}
+	kubeClient := fake.NewClientBuilder().WithObjects(ns).Build()
+
+	state, err := loadState(ctx, kubeClient, "flux-system")
+	require.NoError(t, err)
+	assert.Empty(t, state.Phases)
+
+	state.complete("flux", "v2.0.0", "")
+	state.complete("cert-manager", "v1.13.1", "abc")
+	require.NoError(t, saveState(ctx, kubeClient, "flux-system", state))
+
+	state.complete("ocm-controller", "v0.14.0", "def")
+	require.NoError(t, saveState(ctx, kubeClient, "flux-system", state))
+
+	loaded, err := loadState(ctx, kubeClient, "flux-system")
+	require.NoError(t, err)
+	assert.Len(t, loaded.Phases, 3)
+
+	p, ok := loaded.completed("cert-manager", "v1.13.1")
+	require.True(t, ok)
+	assert.Equal(t, "abc", p.SHA)
+
+	_, ok = loaded.completed("cert-manager", "v1.14.0")
+	assert.False(t, ok, "expected a phase completed with another version to run again")
+
+	_, ok = loaded.completed("git-controller", "v0.9.0")
+	assert.False(t, ok)
+}
+
+func TestBootstrapStateProposal(t *testing.T) {
+	state := newBootstrapState()
+	state.Version = "v0.5.0"
+	state.complete("ocm-controller", "v0.14.0", "abc")
+
+	// the phases of a proposed upgrade are not completed before it is merged
+	state.propose("v0.6.0")
+	state.complete("ocm-controller", "v0.15.0", "def")
+	p, ok := state.completed("ocm-controller", "v0.14.0")
+	require.True(t, ok)
+	assert.Equal(t, "abc", p.SHA)
+	assert.Equal(t, "v0.5.0", state.Version)
+
+	state.merge()
+	assert.Nil(t, state.Proposed)
+	assert.Equal(t, "v0.6.0", state.Version)
+	p, ok = state.completed("ocm-controller", "v0.15.0")
+	require.True(t, ok)
+	assert.Equal(t, "def", p.SHA)
+}
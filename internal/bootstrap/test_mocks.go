@@ -57,7 +57,9 @@ var _ ocm.ComponentAccess = &mockComponentAccess{}
 type mockGitRepository struct {
 	gitprovider.UserRepository
 
-	commitClient gitprovider.CommitClient
+	commitClient      gitprovider.CommitClient
+	deployKeyClient   gitprovider.DeployKeyClient
+	pullRequestClient gitprovider.PullRequestClient
 }
 
 var _ gitprovider.UserRepository = &mockGitRepository{}
@@ -66,6 +68,27 @@ func (m *mockGitRepository) Commits() gitprovider.CommitClient {
 	return m.commitClient
 }
 
+func (m *mockGitRepository) DeployKeys() gitprovider.DeployKeyClient {
+	return m.deployKeyClient
+}
+
+func (m *mockGitRepository) PullRequests() gitprovider.PullRequestClient {
+	return m.pullRequestClient
+}
+
+type mockDeployKeyClient struct {
+	gitprovider.DeployKeyClient
+
+	reconciled []gitprovider.DeployKeyInfo
+}
+
+var _ gitprovider.DeployKeyClient = &mockDeployKeyClient{}
+
+func (m *mockDeployKeyClient) Reconcile(ctx context.Context, req gitprovider.DeployKeyInfo) (gitprovider.DeployKey, bool, error) {
+	m.reconciled = append(m.reconciled, req)
+	return nil, true, nil
+}
+
 type mockCommitClient struct {
 	gitprovider.CommitClient
 
@@ -96,6 +119,66 @@ func (m *mockCommit) Get() gitprovider.CommitInfo {
 
 var _ gitprovider.Commit = &mockCommit{}
 
+type mockPullRequestClient struct {
+	gitprovider.PullRequestClient
+
+	prs []*mockPullRequest
+}
+
+var _ gitprovider.PullRequestClient = &mockPullRequestClient{}
+
+func (m *mockPullRequestClient) List(ctx context.Context) ([]gitprovider.PullRequest, error) {
+	prs := make([]gitprovider.PullRequest, 0, len(m.prs))
+	for _, pr := range m.prs {
+		prs = append(prs, pr)
+	}
+
+	return prs, nil
+}
+
+func (m *mockPullRequestClient) Create(ctx context.Context, title, branch, baseBranch, description string) (gitprovider.PullRequest, error) {
+	pr := &mockPullRequest{
+		info: gitprovider.PullRequestInfo{
+			Title:        title,
+			Description:  description,
+			Number:       len(m.prs) + 1,
+			WebURL:       fmt.Sprintf("https://github.com/mpas/mpas/pull/%d", len(m.prs)+1),
+			SourceBranch: branch,
+		},
+		baseBranch: baseBranch,
+		state:      "open",
+	}
+	m.prs = append(m.prs, pr)
+
+	return pr, nil
+}
+
+func (m *mockPullRequestClient) Get(ctx context.Context, number int) (gitprovider.PullRequest, error) {
+	if number < 1 || number > len(m.prs) {
+		return nil, gitprovider.ErrNotFound
+	}
+
+	return m.prs[number-1], nil
+}
+
+type mockPullRequest struct {
+	gitprovider.PullRequest
+
+	info       gitprovider.PullRequestInfo
+	baseBranch string
+	state      string
+}
+
+var _ gitprovider.PullRequest = &mockPullRequest{}
+
+func (m *mockPullRequest) APIObject() interface{} {
+	return map[string]string{"state": m.state}
+}
+
+func (m *mockPullRequest) Get() gitprovider.PullRequestInfo {
+	return m.info
+}
+
 type mockKustomizer struct {
 	out []byte
 	err error
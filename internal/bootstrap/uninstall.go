@@ -0,0 +1,336 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/fluxcd/flux2/v2/pkg/log"
+	"github.com/fluxcd/flux2/v2/pkg/uninstall"
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/fluxcd/pkg/git"
+	"github.com/fluxcd/pkg/git/gogit"
+	"github.com/fluxcd/pkg/git/repository"
+	productv1alpha1 "github.com/open-component-model/mpas-product-controller/api/v1alpha1"
+	projectv1alpha1 "github.com/open-component-model/mpas-project-controller/api/v1alpha1"
+	"github.com/open-component-model/mpas/internal/env"
+	"github.com/open-component-model/mpas/internal/printer"
+	rep1alpha1 "github.com/open-component-model/replication-controller/api/v1alpha1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// WithDeleteRepository sets whether to delete the management repository on uninstall
+func WithDeleteRepository(deleteRepository bool) Option {
+	return func(o *options) {
+		o.deleteRepository = deleteRepository
+	}
+}
+
+// WithKeepCertManager sets whether to keep cert-manager installed on uninstall
+func WithKeepCertManager(keep bool) Option {
+	return func(o *options) {
+		o.keepCertManager = keep
+	}
+}
+
+// WithKeepExternalSecrets sets whether to keep external-secrets installed on uninstall
+func WithKeepExternalSecrets(keep bool) Option {
+	return func(o *options) {
+		o.keepExternalSecrets = keep
+	}
+}
+
+// Uninstall tears down the MPAS installation of the cluster targeted by the kubeconfig.
+// The MPAS resources are deleted first while their controllers are still running, the controllers
+// are then pruned by flux by removing their manifests from the management repository before flux itself
+// is uninstalled. The management repository is only deleted if requested.
+func (b *Bootstrap) Uninstall(ctx context.Context) error {
+	b.printer.Printf("Running %s ...\n",
+		printer.BoldBlue("mpas uninstall"))
+
+	state, err := loadState(ctx, b.kubeclient, b.namespace(env.DefaultFluxNamespace))
+	if err != nil {
+		return fmt.Errorf("failed to load bootstrap state: %w", err)
+	}
+
+	if err := b.adoptNamespaces(state); err != nil {
+		return err
+	}
+	b.state = state
+
+	if err := b.inSpinner("Deleting MPAS resources", func() error {
+		return b.deleteResources(ctx)
+	}); err != nil {
+		return fmt.Errorf("failed to delete MPAS resources: %w", err)
+	}
+
+	if err := b.prepareUninstallRepository(ctx); err != nil {
+		return err
+	}
+
+	dir, err := mkdirTempDir("mpas-uninstall")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	gitClient, err := b.gitClient(ctx, dir)
+	if err != nil {
+		return err
+	}
+	defer gitClient.Close()
+
+	keep := []string{b.namespace(env.DefaultFluxNamespace)}
+	if b.keepCertManager {
+		keep = append(keep, b.namespace(env.DefaultCertManagerNamespace))
+	}
+	if b.keepExternalSecrets {
+		keep = append(keep, b.namespace(env.DefaultExternalSecretsNamespace))
+	}
+
+	if err := b.inSpinner("Removing component manifests", func() error {
+		sha, err := b.removeManifests(ctx, gitClient, "Remove MPAS component manifests", keep)
+		if err != nil {
+			return err
+		}
+
+		if sha == "" {
+			return nil
+		}
+
+		return b.syncManagementRepository(ctx, sha)
+	}); err != nil {
+		return fmt.Errorf("failed to remove component manifests: %w", err)
+	}
+
+	// an adopted flux is kept, only the sync objects created by the bootstrap are removed
+	var keepSync []string
+	if state.ExistingFlux {
+		if err := b.inSpinner(fmt.Sprintf("Removing the MPAS sync configuration from %s", printer.BoldBlue(env.FluxName)), func() error {
+			return b.removeFluxSync(ctx)
+		}); err != nil {
+			return fmt.Errorf("failed to remove the sync configuration from flux: %w", err)
+		}
+
+		// the reused sync manifests belong to flux
+		if b.syncName() == b.namespace(env.DefaultFluxNamespace) {
+			keepSync = append(keepSync, b.namespace(env.DefaultFluxNamespace))
+		}
+	} else if err := b.inSpinner(fmt.Sprintf("Uninstalling %s", printer.BoldBlue(env.FluxName)), func() error {
+		return b.uninstallFlux(ctx)
+	}); err != nil {
+		return fmt.Errorf("failed to uninstall flux: %w", err)
+	}
+
+	if err := b.inSpinner("Removing remaining manifests", func() error {
+		_, err := b.removeManifests(ctx, gitClient, "Remove MPAS bootstrap manifests", keepSync)
+		return err
+	}); err != nil {
+		return fmt.Errorf("failed to remove manifests: %w", err)
+	}
+
+	if b.deleteRepository {
+		if err := b.inSpinner(fmt.Sprintf("Deleting management repository %s", printer.BoldBlue(b.repositoryName)), func() error {
+			return b.DeleteManagementRepository(ctx)
+		}); err != nil {
+			return err
+		}
+	}
+
+	b.printer.Printf("\n🎉 %s\n", printer.BoldBlue("Uninstall completed successfully!"))
+
+	return nil
+}
+
+// deleteResources deletes the MPAS resources and waits for them to be gone. The generators are deleted first
+// so that they do not create new deployments, the projects last as they own the namespaces of the other resources.
+func (b *Bootstrap) deleteResources(ctx context.Context) error {
+	lists := []client.ObjectList{
+		&productv1alpha1.ProductDeploymentGeneratorList{},
+		&productv1alpha1.ProductDeploymentList{},
+		&rep1alpha1.ComponentSubscriptionList{},
+		&projectv1alpha1.ProjectList{},
+	}
+
+	for _, list := range lists {
+		if err := b.deleteAll(ctx, list); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteAll deletes all the objects of the given list kind in all namespaces and waits for them to be gone.
+// A kind that is not installed in the cluster is ignored.
+func (b *Bootstrap) deleteAll(ctx context.Context, list client.ObjectList) error {
+	if err := b.kubeclient.List(ctx, list); err != nil {
+		if apimeta.IsNoMatchError(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to list resources: %w", err)
+	}
+
+	objs, err := apimeta.ExtractList(list)
+	if err != nil {
+		return fmt.Errorf("failed to extract resources: %w", err)
+	}
+
+	for _, o := range objs {
+		obj, ok := o.(client.Object)
+		if !ok {
+			continue
+		}
+		if err := b.kubeclient.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete %s/%s: %w", obj.GetNamespace(), obj.GetName(), err)
+		}
+	}
+
+	return wait.PollImmediateWithContext(ctx, env.DefaultPollInterval, b.timeout, func(ctx context.Context) (bool, error) {
+		if err := b.kubeclient.List(ctx, list); err != nil {
+			return false, err
+		}
+
+		return apimeta.LenList(list) == 0, nil
+	})
+}
+
+// generatedNamespaces are the namespaces below the target path of the management repository
+// the bootstrap writes the manifests to.
+var generatedNamespaces = []string{
+	env.DefaultFluxNamespace,
+	env.DefaultCertManagerNamespace,
+	env.DefaultExternalSecretsNamespace,
+	env.DefaultOCMNamespace,
+	env.DefaultMPASNamespace,
+}
+
+// removeManifests removes the manifests generated by the bootstrap from the management repository, except for those
+// of the given namespaces, along with the patches of the components and pushes the change. It returns the sha of the commit or an empty sha
+// if there was nothing to remove.
+func (b *Bootstrap) removeManifests(ctx context.Context, gitClient *gogit.Client, msg string, keep []string) (string, error) {
+	for _, ns := range generatedNamespaces {
+		ns = b.namespace(ns)
+		if slices.Contains(keep, ns) {
+			continue
+		}
+
+		if err := os.RemoveAll(filepath.Join(gitClient.Path(), b.targetPath, ns)); err != nil {
+			return "", fmt.Errorf("failed to remove %s manifests: %w", ns, err)
+		}
+	}
+
+	if err := os.RemoveAll(filepath.Join(gitClient.Path(), b.targetPath, patchesRepositoryDir)); err != nil {
+		return "", fmt.Errorf("failed to remove patches: %w", err)
+	}
+
+	if b.commitMessageAppendix != "" {
+		msg = msg + "\n\n" + b.commitMessageAppendix
+	}
+
+	sha, err := b.committer.commit(gitClient, "MPAS", msg)
+	if err != nil {
+		if errors.Is(err, git.ErrNoStagedFiles) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to commit removal: %w", err)
+	}
+
+	if err := gitClient.Push(ctx); err != nil {
+		return "", fmt.Errorf("failed to push removal: %w", err)
+	}
+
+	return sha, nil
+}
+
+// uninstallFlux removes the flux controllers, the finalizers of the flux resources, the flux CRDs
+// and the flux namespace. The workloads reconciled by flux are not deleted.
+func (b *Bootstrap) uninstallFlux(ctx context.Context) error {
+	logger := log.NopLogger{}
+	if err := uninstall.Components(ctx, logger, b.kubeclient, b.namespace(env.DefaultFluxNamespace), false); err != nil {
+		return err
+	}
+
+	if err := uninstall.Finalizers(ctx, logger, b.kubeclient, false); err != nil {
+		return err
+	}
+
+	if err := uninstall.CustomResourceDefinitions(ctx, logger, b.kubeclient, false); err != nil {
+		return err
+	}
+
+	return uninstall.Namespace(ctx, logger, b.kubeclient, b.namespace(env.DefaultFluxNamespace), false)
+}
+
+// gitClient returns a git client for the branch of the management repository the manifests are committed to, cloned into dir.
+func (b *Bootstrap) gitClient(ctx context.Context, dir string) (*gogit.Client, error) {
+	auth, err := b.gitAuthOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	gitClient, err := newGitClient(dir, auth)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := gitClient.Clone(ctx, b.url, repository.CloneOptions{
+		CheckoutStrategy: repository.CheckoutStrategy{
+			Branch: b.commitBranch(),
+		},
+	}); err != nil {
+		return nil, fmt.Errorf("failed to clone repository: %w", err)
+	}
+
+	return gitClient, nil
+}
+
+// prepareUninstallRepository sets the existing management repository and its clone URL.
+func (b *Bootstrap) prepareUninstallRepository(ctx context.Context) error {
+	if b.isPlainGit() {
+		b.setPlainGitURLs()
+		b.repository = newPlainGitRepository(b.gitClient, b.committer)
+		return nil
+	}
+
+	repo, err := b.getRepository(ctx)
+	if err != nil {
+		return err
+	}
+	b.repository = repo
+
+	b.url, err = b.getCloneURL(repo, gitprovider.TransportTypeHTTPS)
+	return err
+}
+
+// getRepository returns the existing management repository.
+func (b *Bootstrap) getRepository(ctx context.Context) (gitprovider.UserRepository, error) {
+	var (
+		repo gitprovider.UserRepository
+		err  error
+	)
+	switch ref := b.repositoryRef().(type) {
+	case gitprovider.UserRepositoryRef:
+		repo, err = b.providerClient.UserRepositories().Get(ctx, ref)
+	case gitprovider.OrgRepositoryRef:
+		var orgRepo gitprovider.OrgRepository
+		orgRepo, err = b.providerClient.OrgRepositories().Get(ctx, ref)
+		repo = orgRepo
+	default:
+		return nil, fmt.Errorf("unsupported repository reference %T", ref)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Git repository %q: %w", b.repositoryName, err)
+	}
+
+	return repo, nil
+}
@@ -0,0 +1,45 @@
+package bootstrap
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	productv1alpha1 "github.com/open-component-model/mpas-product-controller/api/v1alpha1"
+	projectv1alpha1 "github.com/open-component-model/mpas-project-controller/api/v1alpha1"
+	"github.com/open-component-model/mpas/internal/kubeutils"
+	rep1alpha1 "github.com/open-component-model/replication-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDeleteResources(t *testing.T) {
+	scheme, err := kubeutils.NewScheme()
+	require.NoError(t, err)
+
+	kubeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&productv1alpha1.ProductDeploymentGenerator{ObjectMeta: metav1.ObjectMeta{Name: "generator", Namespace: "mpas-project"}},
+		&productv1alpha1.ProductDeployment{ObjectMeta: metav1.ObjectMeta{Name: "deployment", Namespace: "mpas-project"}},
+		&rep1alpha1.ComponentSubscription{ObjectMeta: metav1.ObjectMeta{Name: "subscription", Namespace: "mpas-project"}},
+		&projectv1alpha1.Project{ObjectMeta: metav1.ObjectMeta{Name: "project", Namespace: "mpas-system"}},
+	).Build()
+
+	b := &Bootstrap{
+		options: options{
+			kubeclient: kubeClient,
+			timeout:    10 * time.Second,
+		},
+	}
+
+	require.NoError(t, b.deleteResources(context.Background()))
+
+	projects := &projectv1alpha1.ProjectList{}
+	require.NoError(t, kubeClient.List(context.Background(), projects))
+	assert.Empty(t, projects.Items)
+
+	deployments := &productv1alpha1.ProductDeploymentList{}
+	require.NoError(t, kubeClient.List(context.Background(), deployments))
+	assert.Empty(t, deployments.Items)
+}
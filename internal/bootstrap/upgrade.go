@@ -0,0 +1,504 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/open-component-model/mpas/internal/env"
+	"github.com/open-component-model/mpas/internal/kubeutils"
+	"github.com/open-component-model/mpas/internal/printer"
+	om "github.com/open-component-model/ocm/pkg/contexts/ocm"
+	"github.com/open-component-model/ocm/pkg/contexts/ocm/compdesc"
+	"github.com/open-component-model/ocm/pkg/contexts/ocm/utils"
+)
+
+// upgradeFromLabel is the label of a component reference of the bootstrap component holding the semver
+// constraint the installed version of the component must match to be upgraded to the referenced version.
+const upgradeFromLabel = "mpas.ocm.software/upgrade-from"
+
+// WithUpgradeVersion sets the version, or semver constraint, of the bootstrap component to upgrade to
+func WithUpgradeVersion(version string) Option {
+	return func(o *options) {
+		o.upgradeVersion = version
+	}
+}
+
+// componentUpgrade describes the version change of an installed component.
+type componentUpgrade struct {
+	name string
+	from string
+	to   string
+}
+
+func (c componentUpgrade) changed() bool {
+	return c.from != c.to
+}
+
+// Upgrade moves the installation of the cluster targeted by the kubeconfig to a newer version of
+// the bootstrap component. The installed versions are read from the bootstrap state, only the manifests of
+// the components whose version changed are regenerated and committed to the management repository,
+// with the patches committed to it. With a pull request, they are proposed to the default branch instead.
+func (b *Bootstrap) Upgrade(ctx context.Context) error {
+	octx := om.DefaultContext()
+	if _, err := utils.Configure(octx, ""); err != nil {
+		return fmt.Errorf("failed to configure ocm context: %w", err)
+	}
+	// set default log level to 1 which is ERROR level to avoid printing INFO messages
+	octx.LoggingContext().SetDefaultLevel(1)
+
+	b.printer.Printf("Running %s ...\n",
+		printer.BoldBlue("mpas upgrade"))
+
+	state, err := loadState(ctx, b.kubeclient, b.namespace(env.DefaultFluxNamespace))
+	if err != nil {
+		return fmt.Errorf("failed to load bootstrap state: %w", err)
+	}
+
+	if err := b.adoptNamespaces(state); err != nil {
+		return err
+	}
+	state.Namespaces = b.namespaces
+	b.adoptImageRegistryMirror(state)
+	if err := b.configureRegistryCredentials(ctx, octx); err != nil {
+		return err
+	}
+	if b.fleetBase == "" {
+		b.fleetBase = state.FleetBase
+	}
+	state.FleetBase = b.fleetBase
+	// the adopted flux and cert-manager are not part of the installed components and are left as they are
+	b.existingFlux, b.existingCertManager = state.ExistingFlux, state.ExistingCertManager
+
+	installed := installedComponents(state)
+	if len(installed) == 0 {
+		return fmt.Errorf("no installed components found in the bootstrap state, was the cluster bootstrapped with mpas?")
+	}
+	b.state = state
+
+	b.components = make([]string, 0, len(installed))
+	for name := range installed {
+		b.components = append(b.components, name)
+	}
+
+	if err := b.inSpinner(fmt.Sprintf("Preparing Management repository %s",
+		printer.BoldBlue(b.repositoryName)), func() error {
+		if b.isPlainGit() {
+			return b.preparePlainGitRepository(ctx)
+		}
+
+		repo, err := b.getRepository(ctx)
+		if err != nil {
+			return err
+		}
+
+		b.url, err = b.getCloneURL(repo, gitprovider.TransportTypeHTTPS)
+		if err != nil {
+			return err
+		}
+		b.repository = b.commitLocally(repo)
+
+		// keep pulling over SSH if flux was bootstrapped with a deploy key
+		return b.detectSSH(ctx)
+	}); err != nil {
+		return fmt.Errorf("failed to prepare management repository: %w", err)
+	}
+
+	// an upgrade proposed by a previous run is completed once its pull request is merged
+	if state.Proposed != nil {
+		if err := b.inSpinner(fmt.Sprintf("Checking pull request %d proposing the upgrade to %s",
+			state.Proposed.PullRequest, printer.BoldBlue(state.Proposed.Version)), func() error {
+			return b.resolveProposal(ctx)
+		}); err != nil {
+			return fmt.Errorf("failed to check the proposed upgrade: %w", err)
+		}
+		installed = installedComponents(state)
+	}
+
+	// the patches committed when bootstrapping are reapplied to the upgraded components
+	if len(state.Patches) > 0 {
+		if err := b.inSpinner("Fetching component patches", func() error {
+			b.patches, err = b.fetchPatches(ctx, state.Patches)
+			return err
+		}); err != nil {
+			return fmt.Errorf("failed to fetch component patches: %w", err)
+		}
+	}
+
+	var (
+		version string
+		refs    map[string]compdesc.ComponentReference
+		ociRepo om.Repository
+	)
+	if err := b.inSpinner(fmt.Sprintf("Fetching bootstrap component from %s",
+		printer.BoldBlue(b.registry)), func() error {
+		ociRepo, err = b.componentRepository(octx)
+		if err != nil {
+			return fmt.Errorf("failed to create component repository: %w", err)
+		}
+
+		version, refs, err = b.fetchBootstrapComponentReferences(ociRepo, b.upgradeVersion)
+		return err
+	}); err != nil {
+		return fmt.Errorf("failed to fetch bootstrap components: %w", err)
+	}
+
+	if err := checkBootstrapUpgrade(state.Version, version); err != nil {
+		return err
+	}
+
+	upgrades, err := planUpgrades(installed, refs)
+	if err != nil {
+		return err
+	}
+
+	b.printUpgrades(state.Version, version, upgrades)
+
+	if !hasChanges(upgrades) {
+		b.printer.Printf("\nAll components are up to date\n")
+		return nil
+	}
+
+	if b.imageRegistryMirror != "" {
+		changed := make(map[string]compdesc.ComponentReference)
+		for _, u := range upgrades {
+			if u.changed() {
+				changed[u.name] = refs[u.name]
+			}
+		}
+
+		ociRepo, err = b.mirrorComponents(ctx, octx, ociRepo, changed, "mirror", version)
+		if err != nil {
+			return err
+		}
+	}
+
+	b.setPullRequestBranch("upgrade", version)
+	if b.viaPullRequest {
+		if err := b.inSpinner(fmt.Sprintf("Preparing branch %s", printer.BoldBlue(b.pullRequestBranch)), func() error {
+			return b.preparePullRequestBranch(ctx)
+		}); err != nil {
+			return fmt.Errorf("failed to prepare pull request branch: %w", err)
+		}
+
+		// the upgraded manifests are proposed at once, their phases are completed once the pull request is merged
+		b.batch = newBatchRepository(b.repository)
+		b.repository = b.batch
+		b.state.propose(version)
+	}
+
+	var (
+		latestSHA           string
+		upgradedCertManager bool
+		// compNs holds the deployments per namespace in installation order, the namespace manifest
+		// is only generated with the first component of a namespace
+		compNs   = make(map[string][]string)
+		upgraded = make(map[string][]string)
+	)
+	for _, u := range upgrades {
+		ref := refs[u.name]
+
+		var (
+			ns          string
+			deployments []string
+		)
+		if u.name != env.FluxName && u.name != env.CertManagerName {
+			inst, err := installerFor(u.name, ref)
+			if err != nil {
+				return err
+			}
+			ns, deployments = b.namespace(inst.namespace), inst.deployments
+		}
+
+		if u.changed() {
+			sha, err := b.inPhase(ctx, u.name, u.to, fmt.Sprintf("Upgrading %s from %s to %s",
+				printer.BoldBlue(u.name), printer.BoldBlue(u.from), printer.BoldBlue(u.to)), func() (string, error) {
+				return b.upgradeComponent(ctx, ociRepo, u.name, ref, ns, compNs)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to upgrade %s: %w", u.name, err)
+			}
+
+			if sha != "" {
+				latestSHA = sha
+			}
+
+			if u.name == env.CertManagerName {
+				upgradedCertManager = true
+			}
+
+			if ns != "" {
+				upgraded[ns] = append(upgraded[ns], deployments...)
+			}
+		}
+
+		if ns != "" {
+			compNs[ns] = append(compNs[ns], deployments...)
+		}
+	}
+
+	sha, err := b.inPhase(ctx, "bootstrap-version", version, fmt.Sprintf("Recording bootstrap component version %s",
+		printer.BoldBlue(version)), func() (string, error) {
+		return b.commitBootstrapVersion(ctx, version, b.upgradeVersion)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record bootstrap component version: %w", err)
+	}
+	latestSHA = sha
+
+	if b.viaPullRequest {
+		latestSHA, err = b.inPhase(ctx, "commit", version, "Committing upgraded manifests", func() (string, error) {
+			return b.commitBatch(ctx, version, latestSHA)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to commit upgraded manifests: %w", err)
+		}
+
+		latestSHA, err = b.proposeManifests(ctx, fmt.Sprintf("Upgrade MPAS to %s", version),
+			fmt.Sprintf("Upgrades the components of the MPAS bootstrap component from %s to %s.", state.Version, version))
+		if err != nil {
+			return fmt.Errorf("failed to propose upgraded manifests: %w", err)
+		}
+
+		if !b.waitForMerge {
+			b.printer.Printf("\n")
+			b.printer.Printf("Upgrade to %s proposed successfully, flux upgrades the components once the pull request is merged!\n",
+				printer.BoldBlue(version))
+
+			return nil
+		}
+		b.state.merge()
+	}
+
+	// flux waits for its own reconciliation, a sync is only required for the committed manifests
+	if latestSHA != "" {
+		if err := b.inSpinner("Reconciling upgraded components", func() error {
+			return b.syncManagementRepository(ctx, latestSHA)
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := b.inSpinner("Waiting for upgraded components to be ready", func() error {
+		if upgradedCertManager {
+			if err := kubeutils.ReportComponentsHealth(ctx, b.restClientGetter, b.timeout, []string{
+				certManager,
+				certManagerCAInjector,
+				certManagerWebhook,
+			}, b.namespace(env.DefaultCertManagerNamespace)); err != nil {
+				return fmt.Errorf("failed to report health, please try again in a few minutes: %w", err)
+			}
+		}
+
+		for ns, comps := range upgraded {
+			if err := kubeutils.ReportComponentsHealth(ctx, b.restClientGetter, b.timeout, comps, ns); err != nil {
+				return fmt.Errorf("failed to report health, please try again in a few minutes: %w", err)
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to wait for components to be ready: %w", err)
+	}
+
+	b.state.Version = version
+	if err := saveState(ctx, b.kubeclient, b.namespace(env.DefaultFluxNamespace), b.state); err != nil {
+		return fmt.Errorf("failed to save bootstrap state: %w", err)
+	}
+
+	b.printer.Printf("\n")
+	b.printer.Printf("Upgrade to %s completed successfully!\n", printer.BoldBlue(version))
+
+	return nil
+}
+
+// upgradeComponent regenerates the manifests of the given component and returns the sha of the commit.
+// Flux pushes its manifests itself and waits for their reconciliation, no sha is returned for it. When the upgrade
+// is proposed with a pull request, its manifests are proposed with the others and applied once it is merged.
+func (b *Bootstrap) upgradeComponent(ctx context.Context, ociRepo om.Repository, name string, ref compdesc.ComponentReference, ns string, compNs map[string][]string) (string, error) {
+	switch name {
+	case env.FluxName:
+		return "", b.installFlux(ctx, ociRepo, ref, b.viaPullRequest)
+	case env.CertManagerName:
+		return b.installCertManager(ctx, ociRepo, ref)
+	default:
+		return b.generateControllerManifest(ctx, ociRepo, name, ref, ns, compNs)
+	}
+}
+
+// generateControllerManifest generates the manifest of the given component and commits it.
+func (b *Bootstrap) generateControllerManifest(ctx context.Context, ociRepo om.Repository, comp string, ref compdesc.ComponentReference, ns string, compNs map[string][]string) (string, error) {
+	patches, err := b.componentPatches(comp)
+	if err != nil {
+		return "", err
+	}
+
+	manifest, err := generateManifest(ociRepo, comp, ref, b.namespaces, patches)
+	if err != nil {
+		return "", err
+	}
+
+	return b.installComponent(ctx, ref, ns, compNs, manifest)
+}
+
+func (b *Bootstrap) printUpgrades(from, to string, upgrades []componentUpgrade) {
+	if from == "" {
+		from = "unknown"
+	}
+
+	b.printer.Printf("\nUpgrading bootstrap component from %s to %s\n", printer.BoldBlue(from), printer.BoldBlue(to))
+	for _, u := range upgrades {
+		if u.changed() {
+			b.printer.Printf("  %s: %s -> %s\n", u.name, u.from, printer.BoldBlue(u.to))
+		} else {
+			b.printer.Printf("  %s: %s (unchanged)\n", u.name, u.from)
+		}
+	}
+	b.printer.Printf("\n")
+}
+
+// installedComponents returns the installed components with their versions as recorded in the bootstrap state.
+// A state recorded by an older version does not list the installed components, they are the known
+// components among its phases.
+func installedComponents(state *bootstrapState) map[string]string {
+	installed := make(map[string]string)
+	for name, p := range state.Phases {
+		if state.Components != nil {
+			if !slices.Contains(state.Components, name) {
+				continue
+			}
+		} else if _, ok := installers[name]; !ok && name != env.FluxName && name != env.CertManagerName {
+			continue
+		}
+		installed[name] = p.Version
+	}
+
+	return installed
+}
+
+// planUpgrades returns the version changes of the installed components, flux and cert-manager first
+// followed by the other components in installation order. It fails if a component cannot be upgraded
+// to the referenced version.
+func planUpgrades(installed map[string]string, refs map[string]compdesc.ComponentReference) ([]componentUpgrade, error) {
+	names := make([]string, 0, len(installed))
+	for name := range installed {
+		if name != env.FluxName && name != env.CertManagerName {
+			names = append(names, name)
+		}
+	}
+	names, err := installOrder(names, refs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to order components: %w", err)
+	}
+	names = append([]string{env.FluxName, env.CertManagerName}, names...)
+
+	var upgrades []componentUpgrade
+	for _, name := range names {
+		from, ok := installed[name]
+		if !ok {
+			continue
+		}
+
+		ref, ok := refs[name]
+		if !ok {
+			return nil, fmt.Errorf("component %s is not part of the target bootstrap component", name)
+		}
+
+		if err := checkComponentUpgrade(name, from, ref); err != nil {
+			return nil, err
+		}
+
+		upgrades = append(upgrades, componentUpgrade{
+			name: name,
+			from: from,
+			to:   ref.GetVersion(),
+		})
+	}
+
+	return upgrades, nil
+}
+
+// checkBootstrapUpgrade refuses to move the installation to an older bootstrap component.
+func checkBootstrapUpgrade(installed, target string) error {
+	if installed == "" {
+		return nil
+	}
+
+	from, err := semver.NewVersion(installed)
+	if err != nil {
+		return fmt.Errorf("failed to parse installed bootstrap component version %q: %w", installed, err)
+	}
+
+	to, err := semver.NewVersion(target)
+	if err != nil {
+		return fmt.Errorf("failed to parse bootstrap component version %q: %w", target, err)
+	}
+
+	if to.LessThan(from) {
+		return fmt.Errorf("refusing to downgrade the bootstrap component from %s to %s", installed, target)
+	}
+
+	return nil
+}
+
+// checkComponentUpgrade returns an error if the installed version of a component cannot be upgraded to the
+// referenced version. Downgrades are refused. If the reference carries the upgrade-from label, the installed version
+// must match its constraint, otherwise a major version may not be skipped.
+func checkComponentUpgrade(name, installed string, ref compdesc.ComponentReference) error {
+	from, err := semver.NewVersion(installed)
+	if err != nil {
+		return fmt.Errorf("failed to parse installed version %q of %s: %w", installed, name, err)
+	}
+
+	to, err := semver.NewVersion(ref.GetVersion())
+	if err != nil {
+		return fmt.Errorf("failed to parse version %q of %s: %w", ref.GetVersion(), name, err)
+	}
+
+	if to.LessThan(from) {
+		return fmt.Errorf("refusing to downgrade %s from %s to %s", name, installed, ref.GetVersion())
+	}
+
+	var constraint string
+	ok, err := ref.GetLabels().GetValue(upgradeFromLabel, &constraint)
+	if err != nil {
+		return fmt.Errorf("failed to read label %s of %s: %w", upgradeFromLabel, name, err)
+	}
+
+	if ok {
+		c, err := semver.NewConstraint(constraint)
+		if err != nil {
+			return fmt.Errorf("invalid constraint %q in label %s of %s: %w", constraint, upgradeFromLabel, name, err)
+		}
+
+		if !c.Check(from) {
+			return fmt.Errorf("%s %s cannot be upgraded to %s, the installed version must match %q: upgrade to an intermediate version first",
+				name, installed, ref.GetVersion(), constraint)
+		}
+
+		return nil
+	}
+
+	if to.Major() > from.Major()+1 {
+		return fmt.Errorf("%s %s cannot be upgraded to %s, major versions cannot be skipped: upgrade to an intermediate version first",
+			name, installed, ref.GetVersion())
+	}
+
+	return nil
+}
+
+func hasChanges(upgrades []componentUpgrade) bool {
+	for _, u := range upgrades {
+		if u.changed() {
+			return true
+		}
+	}
+
+	return false
+}
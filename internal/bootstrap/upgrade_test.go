@@ -0,0 +1,139 @@
+package bootstrap
+
+import (
+	"testing"
+
+	"github.com/open-component-model/mpas/internal/env"
+	"github.com/open-component-model/ocm/pkg/contexts/ocm/compdesc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newComponentReference(t *testing.T, name, version string, labels map[string]string) compdesc.ComponentReference {
+	ref := compdesc.ComponentReference{
+		ElementMeta: compdesc.ElementMeta{
+			Name:    name,
+			Version: version,
+		},
+		ComponentName: "ocm.software/mpas/" + name,
+	}
+	for k, v := range labels {
+		require.NoError(t, ref.Labels.Set(k, v))
+	}
+
+	return ref
+}
+
+func TestPlanUpgrades(t *testing.T) {
+	installed := map[string]string{
+		env.OcmControllerName: "v0.16.0",
+		env.FluxName:          "v2.0.0",
+		env.CertManagerName:   "v1.13.1",
+		env.GitControllerName: "v0.9.0",
+	}
+
+	testCases := []struct {
+		name     string
+		refs     map[string]compdesc.ComponentReference
+		expected []componentUpgrade
+		err      string
+	}{
+		{
+			name: "upgrades in installation order",
+			refs: map[string]compdesc.ComponentReference{
+				env.OcmControllerName: newComponentReference(t, env.OcmControllerName, "v0.17.0", nil),
+				env.FluxName:          newComponentReference(t, env.FluxName, "v2.1.0", nil),
+				env.CertManagerName:   newComponentReference(t, env.CertManagerName, "v1.13.1", nil),
+				env.GitControllerName: newComponentReference(t, env.GitControllerName, "v0.9.0", nil),
+			},
+			expected: []componentUpgrade{
+				{name: env.FluxName, from: "v2.0.0", to: "v2.1.0"},
+				{name: env.CertManagerName, from: "v1.13.1", to: "v1.13.1"},
+				// git-controller depends on ocm-controller
+				{name: env.OcmControllerName, from: "v0.16.0", to: "v0.17.0"},
+				{name: env.GitControllerName, from: "v0.9.0", to: "v0.9.0"},
+			},
+		},
+		{
+			name: "refuses downgrades",
+			refs: map[string]compdesc.ComponentReference{
+				env.OcmControllerName: newComponentReference(t, env.OcmControllerName, "v0.15.0", nil),
+				env.FluxName:          newComponentReference(t, env.FluxName, "v2.0.0", nil),
+				env.CertManagerName:   newComponentReference(t, env.CertManagerName, "v1.13.1", nil),
+				env.GitControllerName: newComponentReference(t, env.GitControllerName, "v0.9.0", nil),
+			},
+			err: "refusing to downgrade ocm-controller from v0.16.0 to v0.15.0",
+		},
+		{
+			name: "refuses to skip a major version",
+			refs: map[string]compdesc.ComponentReference{
+				env.OcmControllerName: newComponentReference(t, env.OcmControllerName, "v0.16.0", nil),
+				env.FluxName:          newComponentReference(t, env.FluxName, "v4.0.0", nil),
+				env.CertManagerName:   newComponentReference(t, env.CertManagerName, "v1.13.1", nil),
+				env.GitControllerName: newComponentReference(t, env.GitControllerName, "v0.9.0", nil),
+			},
+			err: "major versions cannot be skipped",
+		},
+		{
+			name: "refuses versions not matching the upgrade-from label",
+			refs: map[string]compdesc.ComponentReference{
+				env.OcmControllerName: newComponentReference(t, env.OcmControllerName, "v0.20.0", map[string]string{
+					upgradeFromLabel: ">=0.18.0",
+				}),
+				env.FluxName:          newComponentReference(t, env.FluxName, "v2.0.0", nil),
+				env.CertManagerName:   newComponentReference(t, env.CertManagerName, "v1.13.1", nil),
+				env.GitControllerName: newComponentReference(t, env.GitControllerName, "v0.9.0", nil),
+			},
+			err: `the installed version must match ">=0.18.0"`,
+		},
+		{
+			name: "fails if an installed component is missing",
+			refs: map[string]compdesc.ComponentReference{
+				env.FluxName:          newComponentReference(t, env.FluxName, "v2.0.0", nil),
+				env.CertManagerName:   newComponentReference(t, env.CertManagerName, "v1.13.1", nil),
+				env.GitControllerName: newComponentReference(t, env.GitControllerName, "v0.9.0", nil),
+			},
+			err: "component ocm-controller is not part of the target bootstrap component",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			upgrades, err := planUpgrades(installed, tc.refs)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, upgrades)
+		})
+	}
+}
+
+func TestInstalledComponents(t *testing.T) {
+	state := newBootstrapState()
+	state.complete(env.FluxName, "v2.0.0", "")
+	state.complete(env.OcmControllerName, "v0.16.0", "abc")
+	state.complete("certificates", "", "def")
+	state.complete("sync-components", "def", "def")
+
+	assert.Equal(t, map[string]string{
+		env.FluxName:          "v2.0.0",
+		env.OcmControllerName: "v0.16.0",
+	}, installedComponents(state))
+
+	// components without a registered installer are only known from the recorded components
+	state.complete("my-org/foo-controller", "v1.0.0", "ghi")
+	state.Components = []string{env.FluxName, env.OcmControllerName, "my-org/foo-controller"}
+	assert.Equal(t, map[string]string{
+		env.FluxName:            "v2.0.0",
+		env.OcmControllerName:   "v0.16.0",
+		"my-org/foo-controller": "v1.0.0",
+	}, installedComponents(state))
+}
+
+func TestCheckBootstrapUpgrade(t *testing.T) {
+	assert.NoError(t, checkBootstrapUpgrade("", "v1.0.0"))
+	assert.NoError(t, checkBootstrapUpgrade("v1.0.0", "v1.1.0"))
+	assert.ErrorContains(t, checkBootstrapUpgrade("v1.1.0", "v1.0.0"), "refusing to downgrade")
+}
@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/open-component-model/mpas/internal/env"
+	"github.com/open-component-model/mpas/internal/ocm"
+	om "github.com/open-component-model/ocm/pkg/contexts/ocm"
+)
+
+// WithVerifyKey sets the public key file the signature of the bootstrap component is verified with.
+// The components are only installed if the bootstrap component and the components it references are
+// unchanged since it was signed.
+func WithVerifyKey(verifyKey string) Option {
+	return func(o *options) {
+		o.verifyKey = verifyKey
+	}
+}
+
+// WithVerifySignatureName sets the name of the signature of the bootstrap component to verify,
+// it may be omitted if the bootstrap component has a single signature.
+func WithVerifySignatureName(name string) Option {
+	return func(o *options) {
+		o.verifySignatureName = name
+	}
+}
+
+// verifyBootstrapComponent verifies the signature of the given version of the bootstrap component,
+// and the digests of the components it references, with the public key.
+func (b *Bootstrap) verifyBootstrapComponent(ociRepo om.Repository, version string) error {
+	publicKey, err := os.ReadFile(b.verifyKey)
+	if err != nil {
+		return fmt.Errorf("failed to read verification key: %w", err)
+	}
+
+	cv, err := ocm.FetchComponentVersion(ociRepo, env.DefaultBootstrapComponent, version)
+	if err != nil {
+		return err
+	}
+	defer cv.Close()
+
+	return ocm.VerifyComponentVersion(ociRepo, cv, b.verifySignatureName, publicKey)
+}
@@ -0,0 +1,102 @@
+package bootstrap
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-component-model/mpas/internal/env"
+	"github.com/open-component-model/mpas/internal/ocm"
+	"github.com/open-component-model/mpas/internal/printer"
+	"github.com/open-component-model/ocm/pkg/common/accessio"
+	"github.com/open-component-model/ocm/pkg/contexts/datacontext"
+	om "github.com/open-component-model/ocm/pkg/contexts/ocm"
+	"github.com/open-component-model/ocm/pkg/contexts/ocm/signing"
+	rsahandler "github.com/open-component-model/ocm/pkg/signing/handlers/rsa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writePublicKey writes the public key of the given private key to a file and returns its path.
+func writePublicKey(t *testing.T, privateKey *rsa.PrivateKey) string {
+	data, err := rsahandler.KeyData(&privateKey.PublicKey)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "key.pub")
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	return path
+}
+
+func TestVerifyBootstrapComponent(t *testing.T) {
+	octx := om.New(datacontext.MODE_SHARED)
+	repo, err := ocm.CreateCTF(octx, filepath.Join(t.TempDir(), "ctf"), accessio.FormatDirectory)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	comp, err := ocm.NewComponent(octx, "ocm.software/ocm-controller", "v0.1.0", ocm.WithProvider("ocm"))
+	require.NoError(t, err)
+	require.NoError(t, comp.AddToCTF(repo))
+	require.NoError(t, comp.Close())
+
+	comp, err = ocm.NewComponent(octx, env.DefaultBootstrapComponent, "v0.1.0", ocm.WithProvider("ocm"))
+	require.NoError(t, err)
+	require.NoError(t, comp.AddToCTF(repo))
+	require.NoError(t, comp.AddResource(ocm.WithResourceName(env.OcmControllerName),
+		ocm.WithResourceType("componentReference"),
+		ocm.WithComponentName("ocm.software/ocm-controller"),
+		ocm.WithResourceVersion("v0.1.0"),
+	))
+	require.NoError(t, comp.Close())
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	b := &Bootstrap{
+		options: options{
+			verifyKey: writePublicKey(t, privateKey),
+		},
+	}
+	assert.ErrorContains(t, b.verifyBootstrapComponent(repo, "v0.1.0"), "has 0 signatures")
+
+	cv, err := repo.LookupComponentVersion(env.DefaultBootstrapComponent, "v0.1.0")
+	require.NoError(t, err)
+	_, err = signing.SignComponentVersion(cv, "mpas", signing.PrivateKey("mpas", privateKey), signing.Resolver(repo))
+	require.NoError(t, err)
+	require.NoError(t, cv.Close())
+
+	assert.NoError(t, b.verifyBootstrapComponent(repo, "v0.1.0"))
+
+	b.verifySignatureName = "release"
+	assert.ErrorContains(t, b.verifyBootstrapComponent(repo, "v0.1.0"), "has no signature release")
+
+	b.verifySignatureName = "mpas"
+	b.verifyKey = writePublicKey(t, otherKey)
+	assert.ErrorContains(t, b.verifyBootstrapComponent(repo, "v0.1.0"),
+		"failed to verify signature mpas of component "+env.DefaultBootstrapComponent+":v0.1.0")
+
+	b.verifyKey = filepath.Join(t.TempDir(), "missing.pub")
+	assert.ErrorContains(t, b.verifyBootstrapComponent(repo, "v0.1.0"), "failed to read verification key")
+}
+
+func TestValidateVerifySignatureName(t *testing.T) {
+	p, err := printer.Newprinter(io.Discard)
+	require.NoError(t, err)
+
+	opts := &options{
+		repositoryName:      "mpas",
+		printer:             p,
+		dryRun:              true,
+		outputDir:           t.TempDir(),
+		verifySignatureName: "mpas",
+	}
+	assert.ErrorContains(t, validateOptions(opts), "the verification key must be set to verify the signature mpas")
+
+	opts.verifyKey = "key.pub"
+	assert.NoError(t, validateOptions(opts))
+}
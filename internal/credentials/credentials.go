@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package credentials reads the tokens authenticating against the git providers and the registries
+// from pluggable sources: environment variables, files, git credential helpers, docker credential helpers
+// and exec plugins.
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Source is a source of a token.
+type Source interface {
+	// Token returns the token held by the source, empty if it holds none.
+	Token(ctx context.Context) (string, error)
+}
+
+// SourceFunc is a function implementing Source.
+type SourceFunc func(ctx context.Context) (string, error)
+
+// Token returns the token returned by the function.
+func (f SourceFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// Chain returns a source returning the token of the first of the given sources holding one.
+func Chain(sources ...Source) Source {
+	return SourceFunc(func(ctx context.Context) (string, error) {
+		for _, s := range sources {
+			token, err := s.Token(ctx)
+			if err != nil {
+				return "", err
+			}
+			if token != "" {
+				return token, nil
+			}
+		}
+
+		return "", nil
+	})
+}
+
+// Env returns a source reading the token from the given environment variable.
+func Env(name string) Source {
+	return SourceFunc(func(_ context.Context) (string, error) {
+		return os.Getenv(name), nil
+	})
+}
+
+// File returns a source reading the token from the given file, with the surrounding whitespace removed,
+// e.g. a mounted secret. It holds no token if no file is given.
+func File(path string) Source {
+	return SourceFunc(func(_ context.Context) (string, error) {
+		if path == "" {
+			return "", nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read token file: %w", err)
+		}
+
+		token := strings.TrimSpace(string(data))
+		if token == "" {
+			return "", fmt.Errorf("token file %s is empty", path)
+		}
+
+		return token, nil
+	})
+}
@@ -0,0 +1,146 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeScript writes an executable shell script with the given name and body to the given directory.
+func writeScript(t *testing.T, dir, name, body string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0o755))
+
+	return path
+}
+
+func TestChain(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("MPAS_TEST_TOKEN", "env-token")
+
+	file := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(file, []byte("file-token\n"), 0o600))
+
+	token, err := Chain(File(""), Env("MPAS_TEST_TOKEN")).Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "env-token", token)
+
+	token, err = Chain(File(file), Env("MPAS_TEST_TOKEN")).Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "file-token", token)
+
+	token, err = Chain(Env("MPAS_TEST_UNSET_TOKEN")).Token(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, token)
+
+	_, err = Chain(File(filepath.Join(t.TempDir(), "missing")), Env("MPAS_TEST_TOKEN")).Token(ctx)
+	assert.ErrorContains(t, err, "failed to read token file")
+
+	empty := filepath.Join(t.TempDir(), "empty")
+	require.NoError(t, os.WriteFile(empty, []byte("\n"), 0o600))
+	_, err = File(empty).Token(ctx)
+	assert.ErrorContains(t, err, "is empty")
+}
+
+func TestExec(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	// the plugin echoes the requested URL as token
+	plugin := writeScript(t, dir, "plugin", fmt.Sprintf(`url=$(echo "$%s" | sed 's/.*"url":"\([^"]*\)".*/\1/')
+echo "{\"apiVersion\":\"%s\",\"kind\":\"%s\",\"status\":{\"token\":\"$1-$url\"}}"
+`, ExecInfoEnv, ExecAPIVersion, ExecKind))
+
+	token, err := Exec(plugin, []string{"token"}, "https://github.com").Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-https://github.com", token)
+
+	token, err = Exec("", nil, "https://github.com").Token(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, token)
+
+	wrongKind := writeScript(t, dir, "wrong-kind", `echo '{"apiVersion":"client.authentication.k8s.io/v1","kind":"ExecCredential","status":{"token":"token"}}'`)
+	_, err = Exec(wrongKind, nil, "https://github.com").Token(ctx)
+	assert.ErrorContains(t, err, "expected mpas.ocm.software/v1alpha1 ExecCredential")
+
+	noToken := writeScript(t, dir, "no-token", fmt.Sprintf(`echo '{"apiVersion":"%s","kind":"%s"}'`, ExecAPIVersion, ExecKind))
+	_, err = Exec(noToken, nil, "https://github.com").Token(ctx)
+	assert.ErrorContains(t, err, "returned no token")
+
+	failing := writeScript(t, dir, "failing", "exit 1\n")
+	_, err = Exec(failing, nil, "https://github.com").Token(ctx)
+	assert.ErrorContains(t, err, "failed to run exec plugin")
+}
+
+func TestGitCredentialHelper(t *testing.T) {
+	ctx := context.Background()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	// the helper holds a password for github.com only
+	helper := writeScript(t, home, "helper", `while read line; do
+  [ -z "$line" ] && break
+  case "$line" in host=*) host=${line#host=};; esac
+done
+if [ "$1" = "get" ] && [ "$host" = "github.com" ]; then
+  echo username=mpas
+  echo password=helper-token
+fi
+`)
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".gitconfig"), []byte(fmt.Sprintf("[credential]\n\thelper = %s\n", helper)), 0o600))
+
+	token, err := GitCredentialHelper("https://github.com/open-component-model/mpas").Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "helper-token", token)
+
+	// git is not allowed to prompt for the missing credentials, the next source is asked instead
+	token, err = GitCredentialHelper("https://gitlab.com").Token(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, token)
+
+	t.Setenv("GITLAB_TOKEN", "env-token")
+	token, err = Chain(GitCredentialHelper("https://gitlab.com"), Env("GITLAB_TOKEN")).Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "env-token", token)
+
+	_, err = GitCredentialHelper("gitlab.com").Token(ctx)
+	assert.ErrorContains(t, err, "must have a scheme and a host")
+}
+
+func TestDockerCredentialHelper(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	writeScript(t, dir, "docker-credential-test", `read server
+case "$server" in
+  ghcr.io) echo "{\"ServerURL\":\"$server\",\"Username\":\"mpas\",\"Secret\":\"secret\"}";;
+  registry.example.com) echo "{\"ServerURL\":\"$server\",\"Username\":\"<token>\",\"Secret\":\"identity\"}";;
+  *) echo "credentials not found in native keychain"; exit 1;;
+esac
+`)
+
+	creds, err := DockerCredentialHelper(ctx, "test", "ghcr.io/open-component-model/mpas-bootstrap-component")
+	require.NoError(t, err)
+	assert.Equal(t, &RegistryCredentials{Username: "mpas", Password: "secret"}, creds)
+
+	creds, err = DockerCredentialHelper(ctx, "test", "https://registry.example.com/mpas")
+	require.NoError(t, err)
+	assert.Equal(t, &RegistryCredentials{IdentityToken: "identity"}, creds)
+
+	_, err = DockerCredentialHelper(ctx, "test", "quay.io/mpas")
+	assert.ErrorContains(t, err, "credentials not found in native keychain")
+}
@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// dockerIdentityTokenUsername is the username returned by the docker credential helpers for an identity token.
+const dockerIdentityTokenUsername = "<token>"
+
+// RegistryCredentials are the credentials authenticating against a registry.
+type RegistryCredentials struct {
+	Username string
+	Password string
+	// IdentityToken is the token exchanged for access tokens, set instead of the username and password.
+	IdentityToken string
+}
+
+// DockerCredentialHelper returns the credentials of the given registry held by the docker credential helper
+// of the given name, e.g. ecr-login for docker-credential-ecr-login, run with the get command.
+func DockerCredentialHelper(ctx context.Context, helper, registry string) (*RegistryCredentials, error) {
+	// the helpers are asked for the host of the registry
+	server := strings.SplitN(strings.TrimPrefix(strings.TrimPrefix(registry, "https://"), "http://"), "/", 2)[0]
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(server)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// the helpers report errors, such as missing credentials, on the standard output
+		return nil, fmt.Errorf("failed to get credentials of %s from docker-credential-%s: %w: %s",
+			server, helper, err, strings.TrimSpace(stdout.String()+stderr.String()))
+	}
+
+	var out struct {
+		ServerURL string `json:"ServerURL"`
+		Username  string `json:"Username"`
+		Secret    string `json:"Secret"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return nil, fmt.Errorf("failed to decode the output of docker-credential-%s: %w", helper, err)
+	}
+
+	if out.Username == dockerIdentityTokenUsername {
+		return &RegistryCredentials{IdentityToken: out.Secret}, nil
+	}
+
+	return &RegistryCredentials{
+		Username: out.Username,
+		Password: out.Secret,
+	}, nil
+}
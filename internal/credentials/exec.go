@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+const (
+	// ExecAPIVersion is the API version of the ExecCredential exchanged with the exec plugins.
+	ExecAPIVersion = "mpas.ocm.software/v1alpha1"
+	// ExecKind is the kind of the ExecCredential exchanged with the exec plugins.
+	ExecKind = "ExecCredential"
+	// ExecInfoEnv is the environment variable holding the ExecCredential passed to the exec plugins.
+	ExecInfoEnv = "MPAS_EXEC_INFO"
+)
+
+// ExecCredential is exchanged with the exec plugins, as the ExecCredential of the kubectl exec credential plugins.
+// The plugin is passed the ExecCredential with its spec in the MPAS_EXEC_INFO environment variable and writes
+// the ExecCredential with its status to its standard output.
+type ExecCredential struct {
+	APIVersion string                `json:"apiVersion"`
+	Kind       string                `json:"kind"`
+	Spec       ExecCredentialSpec    `json:"spec"`
+	Status     *ExecCredentialStatus `json:"status,omitempty"`
+}
+
+// ExecCredentialSpec holds what the token is requested for.
+type ExecCredentialSpec struct {
+	// URL is the URL the token authenticates against.
+	URL string `json:"url"`
+	// Interactive is true if the plugin may prompt the user on the standard input.
+	Interactive bool `json:"interactive"`
+}
+
+// ExecCredentialStatus holds the token returned by the plugin.
+type ExecCredentialStatus struct {
+	// Token is the token authenticating against the URL.
+	Token string `json:"token"`
+}
+
+// Exec returns a source running the given exec plugin command with the given arguments to get the token
+// authenticating against the given URL. It holds no token if no command is given.
+// The standard error of the plugin is passed through, so that it can report to the user.
+func Exec(command string, args []string, url string) Source {
+	return SourceFunc(func(ctx context.Context) (string, error) {
+		if command == "" {
+			return "", nil
+		}
+
+		info, err := json.Marshal(ExecCredential{
+			APIVersion: ExecAPIVersion,
+			Kind:       ExecKind,
+			Spec: ExecCredentialSpec{
+				URL: url,
+			},
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal exec credential: %w", err)
+		}
+
+		var stdout bytes.Buffer
+		cmd := exec.CommandContext(ctx, command, args...)
+		cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%s", ExecInfoEnv, info))
+		cmd.Stdout = &stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("failed to run exec plugin %s: %w", command, err)
+		}
+
+		var cred ExecCredential
+		if err := json.Unmarshal(stdout.Bytes(), &cred); err != nil {
+			return "", fmt.Errorf("failed to decode the output of exec plugin %s: %w", command, err)
+		}
+		if cred.APIVersion != ExecAPIVersion || cred.Kind != ExecKind {
+			return "", fmt.Errorf("exec plugin %s returned %s %s, expected %s %s", command, cred.APIVersion, cred.Kind, ExecAPIVersion, ExecKind)
+		}
+		if cred.Status == nil || cred.Status.Token == "" {
+			return "", fmt.Errorf("exec plugin %s returned no token", command)
+		}
+
+		return cred.Status.Token, nil
+	})
+}
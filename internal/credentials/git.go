@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package credentials
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// GitCredentialHelper returns a source asking the git credential helpers configured for the given URL
+// for its password, with git credential fill. Git does not prompt for it if no helper holds it, but fails,
+// the source then holds no token.
+func GitCredentialHelper(rawURL string) Source {
+	return SourceFunc(func(ctx context.Context) (string, error) {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse URL %q: %w", rawURL, err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return "", fmt.Errorf("URL %q must have a scheme and a host", rawURL)
+		}
+
+		var input bytes.Buffer
+		fmt.Fprintf(&input, "protocol=%s\nhost=%s\n", u.Scheme, u.Host)
+		if path := strings.Trim(u.Path, "/"); path != "" {
+			fmt.Fprintf(&input, "path=%s\n", path)
+		}
+		if u.User != nil && u.User.Username() != "" {
+			fmt.Fprintf(&input, "username=%s\n", u.User.Username())
+		}
+		input.WriteString("\n")
+
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, "git", "credential", "fill")
+		cmd.Stdin = &input
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		// the helpers are only asked, the user is never prompted
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GCM_INTERACTIVE=never")
+		err = cmd.Run()
+
+		scanner := bufio.NewScanner(&stdout)
+		for scanner.Scan() {
+			if password, ok := strings.CutPrefix(scanner.Text(), "password="); ok {
+				return password, nil
+			}
+		}
+
+		// git exits with an error if it would have to prompt for the missing password
+		var exitErr *exec.ExitError
+		if err != nil && !errors.As(err, &exitErr) {
+			return "", fmt.Errorf("failed to get credentials of %s from the git credential helpers: %w: %s",
+				u.Host, err, strings.TrimSpace(stderr.String()))
+		}
+
+		return "", nil
+	})
+}
@@ -44,6 +44,18 @@ const (
 	DefaultBootstrapComponentLocation = "ghcr.io/open-component-model/mpas-bootstrap-component"
 	// DefaultBootstrapBundleLocation is the default location of the bootstrap bundle.
 	DefaultBootstrapBundleLocation = DefaultBootstrapComponentLocation + "-bundle"
+	// MinKubernetesVersion is the minimum version of the Kubernetes server the components are installed to.
+	MinKubernetesVersion = "1.28.0"
+	// MinFluxVersion is the minimum version of an existing flux adopted by the bootstrap,
+	// the oldest release supporting MinKubernetesVersion.
+	MinFluxVersion = "2.1.0"
+	// MinCertManagerVersion is the minimum version of an existing cert-manager adopted by the bootstrap,
+	// the oldest release supporting MinKubernetesVersion.
+	MinCertManagerVersion = "1.13.0"
+	// ExistingFluxSyncName is the name of the flux objects syncing the management repository in an existing flux.
+	ExistingFluxSyncName = "mpas"
+	// DefaultFleetBase is the default path of the manifests shared by the clusters of a fleet.
+	DefaultFleetBase = "fleet/base"
 	// DefaultFluxHost is the default host for the flux components.
 	DefaultFluxHost = "ghcr.io/fluxcd"
 	// DefaultCertManagerHost is the default host for the cert-manager components.
@@ -69,6 +81,18 @@ const (
 	GiteaTokenVar = "GITEA_TOKEN"
 	// GitlabTokenVar is the name of the environment variable to use to get the gitlab token.
 	GitlabTokenVar = "GITLAB_TOKEN"
+	// BitbucketServerTokenVar is the name of the environment variable to use to get the bitbucket server token.
+	BitbucketServerTokenVar = "BITBUCKET_SERVER_TOKEN"
+	// AzureDevOpsTokenVar is the name of the environment variable to use to get the azure devops token.
+	AzureDevOpsTokenVar = "AZURE_DEVOPS_TOKEN"
+	// GitPasswordVar is the name of the environment variable to use to get the password of a plain git server.
+	GitPasswordVar = "GIT_PASSWORD"
+	// GPGPassphraseVar is the name of the environment variable to use to get the passphrase of the OpenPGP signing key.
+	GPGPassphraseVar = "MPAS_GPG_PASSPHRASE"
+	// SSHSigningKeyPassphraseVar is the name of the environment variable to use to get the passphrase of the SSH signing key.
+	SSHSigningKeyPassphraseVar = "MPAS_SSH_SIGNING_KEY_PASSPHRASE"
+	// PrivateKeyPassphraseVar is the name of the environment variable to use to get the passphrase of the private key file.
+	PrivateKeyPassphraseVar = "MPAS_PRIVATE_KEY_PASSPHRASE"
 )
 
 const (
@@ -78,6 +102,10 @@ const (
 	ProviderGitea = "gitea"
 	// ProviderGitlab is the gitlab provider.
 	ProviderGitlab = "gitlab"
+	// ProviderBitbucketServer is the bitbucket server and data center provider.
+	ProviderBitbucketServer = "bitbucket-server"
+	// ProviderAzureDevOps is the azure devops provider.
+	ProviderAzureDevOps = "azure-devops"
 )
 
 const (
@@ -130,4 +158,6 @@ var (
 	DefaultKubeAPIBurst = 300
 	// DefaultPollInterval is the default poll interval.
 	DefaultPollInterval = 2 * time.Second
+	// DefaultConcurrency is the default number of component manifests generated concurrently.
+	DefaultConcurrency = 4
 )
@@ -6,7 +6,10 @@ package fs
 
 import (
 	"archive/tar"
+	"bufio"
+	"bytes"
 	"compress/gzip"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -101,3 +104,100 @@ func tarGzip(src string, writers ...io.Writer) error {
 		return f.Close()
 	})
 }
+
+// ExtractArchive extracts the tar archive at the given path, gzip compressed or not, to the given destination directory.
+// Entries are confined to the destination: absolute paths, paths escaping it and links are rejected, and anything
+// that is neither a file nor a directory is ignored. The gzip checksum is verified, a corrupted archive fails.
+func ExtractArchive(src, dst string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open archive %q: %w", src, err)
+	}
+	defer f.Close()
+
+	var r io.Reader = bufio.NewReader(f)
+	if magic, err := r.(*bufio.Reader).Peek(2); err == nil && bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gzr, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("failed to read gzip archive %q: %w", src, err)
+		}
+		defer gzr.Close()
+		r = gzr
+	}
+
+	if err := untar(r, dst); err != nil {
+		return fmt.Errorf("failed to extract archive %q: %w", src, err)
+	}
+
+	// the gzip checksum is only verified once the stream is read to its end
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return fmt.Errorf("failed to read archive %q: %w", src, err)
+	}
+
+	return nil
+}
+
+func untar(r io.Reader, dst string) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar header: %w", err)
+		}
+
+		target, err := confinedPath(dst, header.Name)
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return fmt.Errorf("failed to create directory %q: %w", target, err)
+			}
+		case tar.TypeReg:
+			if err := writeFile(tr, target); err != nil {
+				return err
+			}
+		case tar.TypeSymlink, tar.TypeLink:
+			return fmt.Errorf("archive entry %q is a link, links are not supported", header.Name)
+		}
+	}
+}
+
+// confinedPath returns the path of the archive entry with the given name in the destination directory,
+// or an error if it is absolute or escapes the destination.
+func confinedPath(dst, name string) (string, error) {
+	if filepath.IsAbs(name) || strings.HasPrefix(name, "/") {
+		return "", fmt.Errorf("archive entry %q has an absolute path", name)
+	}
+
+	target := filepath.Join(dst, name)
+	rel, err := filepath.Rel(dst, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("archive entry %q escapes the destination directory", name)
+	}
+
+	return target, nil
+}
+
+func writeFile(r io.Reader, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory %q: %w", filepath.Dir(target), err)
+	}
+
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create file %q: %w", target, err)
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write file %q: %w", target, err)
+	}
+
+	return f.Close()
+}
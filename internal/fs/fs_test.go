@@ -5,11 +5,16 @@
 package fs
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"os"
 	"path"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_CreateArchive(t *testing.T) {
@@ -68,6 +73,75 @@ func Test_CreateArchive(t *testing.T) {
 	}
 }
 
+func Test_ExtractArchive(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := path.Join(tmpDir, "src")
+	require.NoError(t, createNestedDirs(src, []string{"blobs"}))
+	require.NoError(t, os.WriteFile(path.Join(src, "artifact-index.json"), []byte(`{"schemaVersion":1}`), 0o644))
+	require.NoError(t, os.WriteFile(path.Join(src, "blobs", "sha256.1234"), []byte("blob"), 0o644))
+
+	archive, err := CreateArchive(src, "extract-test.tar.gz")
+	require.NoError(t, err)
+	defer os.Remove(archive)
+
+	dst := path.Join(tmpDir, "dst")
+	require.NoError(t, ExtractArchive(archive, dst))
+	content, err := os.ReadFile(path.Join(dst, "blobs", "sha256.1234"))
+	require.NoError(t, err)
+	assert.Equal(t, "blob", string(content))
+
+	// a truncated archive fails the gzip checksum
+	data, err := os.ReadFile(archive)
+	require.NoError(t, err)
+	truncated := path.Join(tmpDir, "truncated.tar.gz")
+	require.NoError(t, os.WriteFile(truncated, data[:len(data)-6], 0o644))
+	assert.Error(t, ExtractArchive(truncated, path.Join(tmpDir, "truncated")))
+}
+
+func Test_ExtractArchiveConfined(t *testing.T) {
+	testCases := []struct {
+		name   string
+		header tar.Header
+		err    string
+	}{
+		{
+			name:   "path traversal",
+			header: tar.Header{Name: "../../escape.txt", Typeflag: tar.TypeReg, Mode: 0o644},
+			err:    "escapes the destination directory",
+		},
+		{
+			name:   "absolute path",
+			header: tar.Header{Name: "/etc/escape.txt", Typeflag: tar.TypeReg, Mode: 0o644},
+			err:    "has an absolute path",
+		},
+		{
+			name:   "symlink",
+			header: tar.Header{Name: "link", Linkname: "/etc/passwd", Typeflag: tar.TypeSymlink},
+			err:    "links are not supported",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			var buf bytes.Buffer
+			gzw := gzip.NewWriter(&buf)
+			tw := tar.NewWriter(gzw)
+			require.NoError(t, tw.WriteHeader(&tc.header))
+			require.NoError(t, tw.Close())
+			require.NoError(t, gzw.Close())
+
+			archive := filepath.Join(tmpDir, "archive.tar.gz")
+			require.NoError(t, os.WriteFile(archive, buf.Bytes(), 0o644))
+
+			dst := filepath.Join(tmpDir, "nested", "dst")
+			assert.ErrorContains(t, ExtractArchive(archive, dst), tc.err)
+			_, err := os.Stat(filepath.Join(tmpDir, "escape.txt"))
+			assert.True(t, os.IsNotExist(err))
+		})
+	}
+}
+
 func createDir(tmpDir, dir string) error {
 	err := os.Mkdir(path.Join(tmpDir, dir), 0o755)
 	if err != nil {
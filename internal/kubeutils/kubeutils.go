@@ -22,6 +22,7 @@ import (
 	ocmv1alpha1 "github.com/open-component-model/ocm-controller/api/v1alpha1"
 	rep1alpha1 "github.com/open-component-model/replication-controller/api/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
@@ -47,6 +48,7 @@ func init() {
 	apiList = append(apiList, apiextensionsv1.AddToScheme)
 	apiList = append(apiList, corev1.AddToScheme)
 	apiList = append(apiList, rbacv1.AddToScheme)
+	apiList = append(apiList, authorizationv1.AddToScheme)
 	apiList = append(apiList, appsv1.AddToScheme)
 	apiList = append(apiList, networkingv1.AddToScheme)
 	apiList = append(apiList, sourcev1.AddToScheme)
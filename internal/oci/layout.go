@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package oci
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// IsLayout returns true if the given directory is an OCI image layout.
+func IsLayout(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ocispec.ImageLayoutFile))
+	return err == nil
+}
+
+// BundleFromLayout returns the path of the bootstrap bundle archive held by the OCI image layout at the given directory,
+// e.g. copied from the bundle repository with oras copy --to-oci-layout. The layout must hold a single bundle manifest,
+// the digests of the manifest and of the archive are verified.
+func BundleFromLayout(dir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ocispec.ImageLayoutFile))
+	if err != nil {
+		return "", fmt.Errorf("failed to read OCI layout file: %w", err)
+	}
+	var layout ocispec.ImageLayout
+	if err := json.Unmarshal(data, &layout); err != nil {
+		return "", fmt.Errorf("failed to decode OCI layout file: %w", err)
+	}
+	if layout.Version != ocispec.ImageLayoutVersion {
+		return "", fmt.Errorf("unsupported OCI layout version %s", layout.Version)
+	}
+
+	data, err = os.ReadFile(filepath.Join(dir, "index.json"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read OCI layout index: %w", err)
+	}
+	var index ocispec.Index
+	if err := json.Unmarshal(data, &index); err != nil {
+		return "", fmt.Errorf("failed to decode OCI layout index: %w", err)
+	}
+	if len(index.Manifests) != 1 {
+		return "", fmt.Errorf("the OCI layout holds %d manifests, expected the bootstrap bundle only", len(index.Manifests))
+	}
+
+	data, err = readBlob(dir, index.Manifests[0])
+	if err != nil {
+		return "", err
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return "", fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	if manifest.ArtifactType != BundleArtifactType && manifest.Config.MediaType != BundleArtifactType {
+		return "", fmt.Errorf("the OCI layout does not hold a bootstrap bundle, expected artifact type %s", BundleArtifactType)
+	}
+	if len(manifest.Layers) != 1 || manifest.Layers[0].MediaType != ocispec.MediaTypeImageLayerGzip {
+		return "", fmt.Errorf("the bootstrap bundle must have a single %s layer", ocispec.MediaTypeImageLayerGzip)
+	}
+
+	layer := manifest.Layers[0]
+	if err := layer.Digest.Validate(); err != nil {
+		return "", fmt.Errorf("invalid digest %s: %w", layer.Digest, err)
+	}
+	f, err := os.Open(blobPath(dir, layer.Digest))
+	if err != nil {
+		return "", fmt.Errorf("failed to open bundle blob: %w", err)
+	}
+	defer f.Close()
+	if err := verify(f, layer); err != nil {
+		return "", err
+	}
+
+	return f.Name(), nil
+}
+
+// readBlob reads the blob of the given descriptor from the OCI layout and verifies its digest.
+func readBlob(dir string, desc ocispec.Descriptor) ([]byte, error) {
+	if err := desc.Digest.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid digest %s: %w", desc.Digest, err)
+	}
+
+	data, err := os.ReadFile(blobPath(dir, desc.Digest))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %w", desc.Digest, err)
+	}
+
+	if int64(len(data)) != desc.Size || desc.Digest.Algorithm().FromBytes(data) != desc.Digest {
+		return nil, fmt.Errorf("blob %s does not match its digest", desc.Digest)
+	}
+
+	return data, nil
+}
+
+// verify reads the given blob and verifies its size and digest against the given descriptor.
+func verify(r io.Reader, desc ocispec.Descriptor) error {
+	verifier := desc.Digest.Verifier()
+	n, err := io.Copy(verifier, r)
+	if err != nil {
+		return fmt.Errorf("failed to read blob %s: %w", desc.Digest, err)
+	}
+	if n != desc.Size || !verifier.Verified() {
+		return fmt.Errorf("blob %s does not match its digest", desc.Digest)
+	}
+
+	return nil
+}
+
+// blobPath returns the path of the blob with the given valid digest in the OCI layout.
+func blobPath(dir string, d digest.Digest) string {
+	return filepath.Join(dir, ocispec.ImageBlobsDir, d.Algorithm().String(), d.Encoded())
+}
@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package oci
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_BundleFromLayout(t *testing.T) {
+	testCases := []struct {
+		name         string
+		artifactType string
+		tamper       bool
+		err          string
+	}{
+		{
+			name:         "bundle",
+			artifactType: BundleArtifactType,
+		},
+		{
+			name:         "tampered bundle",
+			artifactType: BundleArtifactType,
+			tamper:       true,
+			err:          "does not match its digest",
+		},
+		{
+			name:         "other artifact",
+			artifactType: "application/vnd.oci.image.config.v1+json",
+			err:          "the OCI layout does not hold a bootstrap bundle",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			layer := writeLayout(t, dir, tc.artifactType, []byte("bundle"))
+			require.True(t, IsLayout(dir))
+
+			if tc.tamper {
+				require.NoError(t, os.WriteFile(blobPath(dir, layer.Digest), []byte("tampered"), 0o644))
+			}
+
+			bundle, err := BundleFromLayout(dir)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, blobPath(dir, layer.Digest), bundle)
+		})
+	}
+
+	assert.False(t, IsLayout(t.TempDir()))
+}
+
+// writeLayout writes an OCI image layout holding an artifact of the given type with the given layer.
+func writeLayout(t *testing.T, dir, artifactType string, content []byte) ocispec.Descriptor {
+	t.Helper()
+
+	writeBlob := func(mediaType string, data []byte) ocispec.Descriptor {
+		desc := ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.FromBytes(data),
+			Size:      int64(len(data)),
+		}
+		path := blobPath(dir, desc.Digest)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, data, 0o644))
+		return desc
+	}
+
+	layer := writeBlob(ocispec.MediaTypeImageLayerGzip, content)
+	config := writeBlob(artifactType, []byte("{}"))
+	manifest, err := json.Marshal(ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    config,
+		Layers:    []ocispec.Descriptor{layer},
+	})
+	require.NoError(t, err)
+
+	index, err := json.Marshal(ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		Manifests: []ocispec.Descriptor{writeBlob(ocispec.MediaTypeImageManifest, manifest)},
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.json"), index, 0o644))
+
+	layout, err := json.Marshal(ocispec.ImageLayout{Version: ocispec.ImageLayoutVersion})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ocispec.ImageLayoutFile), layout, 0o644))
+
+	return layer
+}
@@ -21,6 +21,9 @@ import (
 	"oras.land/oras-go/v2/registry/remote/retry"
 )
 
+// BundleArtifactType is the artifact type of the bootstrap bundle.
+const BundleArtifactType = "ocm.software/bundle"
+
 type Repository struct {
 	RepositoryURL string
 	Username      string
@@ -43,8 +46,7 @@ func (r *Repository) PushArtifact(ctx context.Context, src, version string) erro
 	}
 	fileDescriptors = append(fileDescriptors, fileDescriptor)
 
-	artifactType := "ocm.software/bundle"
-	manifestDescriptor, err := oras.Pack(ctx, fs, artifactType, fileDescriptors, oras.PackOptions{
+	manifestDescriptor, err := oras.Pack(ctx, fs, BundleArtifactType, fileDescriptors, oras.PackOptions{
 		PackImageManifest: true,
 	})
 
@@ -138,6 +140,32 @@ func resolveCredentials(username string, password string, reg string) (func(cont
 	return creds, nil
 }
 
+// Ping checks that the registry of the repository is reachable with the resolved credentials.
+func (r *Repository) Ping(ctx context.Context) error {
+	regName, _, err := repoRef(r.RepositoryURL)
+	if err != nil {
+		return err
+	}
+
+	reg, err := remote.NewRegistry(regName)
+	if err != nil {
+		return err
+	}
+	reg.PlainHTTP = r.PlainHTTP
+
+	creds, err := resolveCredentials(r.Username, r.Password, regName)
+	if err != nil {
+		return err
+	}
+	reg.Client = &auth.Client{
+		Client:     retry.DefaultClient,
+		Cache:      auth.DefaultCache,
+		Credential: creds,
+	}
+
+	return reg.Ping(ctx)
+}
+
 // GetLatestVersion returns the latest version of the component with the given name.
 func (r *Repository) GetLatestVersion(ctx context.Context) (string, error) {
 	reg, repo, err := repoRef(r.RepositoryURL)
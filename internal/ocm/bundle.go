@@ -0,0 +1,146 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ocm
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/open-component-model/mpas/internal/fs"
+	"github.com/open-component-model/mpas/internal/oci"
+	"github.com/open-component-model/ocm/pkg/contexts/oci/repositories/ctf/format"
+	"github.com/opencontainers/go-digest"
+)
+
+// artifactIndex is the index of the artifacts of a CTF.
+type artifactIndex struct {
+	Artifacts []struct {
+		Repository string `json:"repository"`
+		Tag        string `json:"tag,omitempty"`
+		Digest     string `json:"digest,omitempty"`
+	} `json:"artifacts"`
+}
+
+// ResolveBundle returns the CTF directory of the bootstrap bundle at the given path, which is either
+// the tar.gz archive exported with mpas bootstrap --export, a CTF directory, or an OCI image layout holding
+// the archive. Archives are extracted to a temporary directory, removed by the returned cleanup function.
+// The digests of the blobs of the CTF are verified.
+func ResolveBundle(path string) (string, func(), error) {
+	cleanup := func() {}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return "", cleanup, fmt.Errorf("failed to read bundle: %w", err)
+	}
+
+	archive := path
+	switch {
+	case fi.IsDir() && isCTF(path):
+		archive = ""
+	case fi.IsDir() && oci.IsLayout(path):
+		archive, err = oci.BundleFromLayout(path)
+		if err != nil {
+			return "", cleanup, fmt.Errorf("failed to read bundle from OCI layout %q: %w", path, err)
+		}
+	case fi.IsDir():
+		return "", cleanup, fmt.Errorf("%q is neither a CTF directory nor an OCI image layout", path)
+	}
+
+	dir := path
+	if archive != "" {
+		dir, err = os.MkdirTemp("", "mpas-bundle-")
+		if err != nil {
+			return "", cleanup, fmt.Errorf("failed to create bundle directory: %w", err)
+		}
+		cleanup = func() { os.RemoveAll(dir) }
+
+		if err := fs.ExtractArchive(archive, dir); err != nil {
+			cleanup()
+			return "", func() {}, err
+		}
+		if !isCTF(dir) {
+			cleanup()
+			return "", func() {}, fmt.Errorf("the archive %q does not hold a CTF", path)
+		}
+	}
+
+	if err := verifyCTF(dir); err != nil {
+		cleanup()
+		return "", func() {}, fmt.Errorf("failed to verify bundle %q: %w", path, err)
+	}
+
+	return dir, cleanup, nil
+}
+
+// isCTF returns true if the given directory is a CTF.
+func isCTF(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, format.ArtifactIndexFileName))
+	return err == nil
+}
+
+// verifyCTF verifies that the blobs of the CTF at the given directory match their digests,
+// and that the blobs of the indexed artifacts are present.
+func verifyCTF(dir string) error {
+	data, err := os.ReadFile(filepath.Join(dir, format.ArtifactIndexFileName))
+	if err != nil {
+		return fmt.Errorf("failed to read artifact index: %w", err)
+	}
+	var index artifactIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return fmt.Errorf("failed to decode artifact index: %w", err)
+	}
+
+	blobsDir := filepath.Join(dir, format.BlobsDirectoryName)
+	for _, artifact := range index.Artifacts {
+		if artifact.Digest == "" {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(blobsDir, strings.Replace(artifact.Digest, ":", ".", 1))); err != nil {
+			return fmt.Errorf("artifact %s:%s is missing: %w", artifact.Repository, artifact.Tag, err)
+		}
+	}
+
+	entries, err := os.ReadDir(blobsDir)
+	if err != nil {
+		return fmt.Errorf("failed to read blobs: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		d := digest.Digest(strings.Replace(entry.Name(), ".", ":", 1))
+		if err := d.Validate(); err != nil {
+			return fmt.Errorf("blob %s is not named after its digest: %w", entry.Name(), err)
+		}
+		if err := verifyFile(filepath.Join(blobsDir, entry.Name()), d); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// verifyFile verifies that the content of the given file matches the given digest.
+func verifyFile(path string, d digest.Digest) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open blob %s: %w", d, err)
+	}
+	defer f.Close()
+
+	actual, err := d.Algorithm().FromReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to read blob %s: %w", d, err)
+	}
+	if actual != d {
+		return fmt.Errorf("blob %s does not match its digest", d)
+	}
+
+	return nil
+}
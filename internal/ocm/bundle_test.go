@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ocm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-component-model/mpas/internal/fs"
+	"github.com/open-component-model/ocm/pkg/common/accessio"
+	"github.com/open-component-model/ocm/pkg/contexts/datacontext"
+	om "github.com/open-component-model/ocm/pkg/contexts/ocm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ResolveBundle(t *testing.T) {
+	tmpdir := t.TempDir()
+	octx := om.New(datacontext.MODE_SHARED)
+	ctfPath := filepath.Join(tmpdir, "ctf")
+
+	repo, err := CreateCTF(octx, ctfPath, accessio.FormatDirectory)
+	require.NoError(t, err)
+	comp, err := NewComponent(octx, "github.com/ocm/bootstrap", "v0.8.3", WithProvider("ocm"))
+	require.NoError(t, err)
+	require.NoError(t, comp.AddToCTF(repo))
+	fPath, err := writeFile(tmpdir, []byte("hello world"))
+	require.NoError(t, err)
+	require.NoError(t, comp.AddResource(WithResourceName("my-file"),
+		WithResourceType("file"),
+		WithResourcePath(fPath),
+		WithResourceVersion("v0.8.3"),
+	))
+	require.NoError(t, comp.Close())
+	require.NoError(t, repo.Close())
+
+	dir, cleanup, err := ResolveBundle(ctfPath)
+	require.NoError(t, err)
+	cleanup()
+	assert.Equal(t, ctfPath, dir, "expected the CTF directory to be used as is")
+
+	archive, err := fs.CreateArchive(ctfPath, "resolve-bundle-test.tar.gz")
+	require.NoError(t, err)
+	defer os.Remove(archive)
+
+	dir, cleanup, err = ResolveBundle(archive)
+	require.NoError(t, err)
+	bundle, err := RepositoryFromCTF(dir)
+	require.NoError(t, err)
+	cv, err := FetchLatestComponentVersion(bundle, "github.com/ocm/bootstrap")
+	require.NoError(t, err)
+	assert.Equal(t, "v0.8.3", cv.GetVersion())
+	require.NoError(t, cv.Close())
+	bundle.Close()
+	cleanup()
+	_, err = os.Stat(dir)
+	assert.True(t, os.IsNotExist(err), "expected the extracted bundle to be removed")
+
+	// a tampered blob fails the verification
+	blobs, err := os.ReadDir(filepath.Join(ctfPath, "blobs"))
+	require.NoError(t, err)
+	require.NotEmpty(t, blobs)
+	require.NoError(t, os.WriteFile(filepath.Join(ctfPath, "blobs", blobs[0].Name()), []byte("tampered"), 0o644))
+	_, _, err = ResolveBundle(ctfPath)
+	assert.ErrorContains(t, err, "does not match its digest")
+
+	_, _, err = ResolveBundle(tmpdir)
+	assert.ErrorContains(t, err, "is neither a CTF directory nor an OCI image layout")
+}
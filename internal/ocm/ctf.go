@@ -13,6 +13,7 @@ import (
 	"github.com/open-component-model/ocm/pkg/common/accessio"
 	"github.com/open-component-model/ocm/pkg/common/accessobj"
 	"github.com/open-component-model/ocm/pkg/contexts/ocm"
+	"github.com/open-component-model/ocm/pkg/contexts/ocm/compdesc"
 	"github.com/open-component-model/ocm/pkg/contexts/ocm/repositories/ctf"
 	"github.com/open-component-model/ocm/pkg/contexts/ocm/transfer"
 	"github.com/open-component-model/ocm/pkg/contexts/ocm/transfer/transferhandler"
@@ -50,20 +51,7 @@ func Transfer(octx ocm.Context, repo, target ocm.Repository, writer io.Writer) (
 
 	printer := common.NewPrinter(writer)
 	closure := transfer.TransportClosure{}
-	transferopts := &standard.Options{}
-	if err := transferhandler.From(octx.ConfigContext(), transferopts); err != nil {
-		return fmt.Errorf("failed to create transfer handler: %w", err)
-	}
-
-	if err := transferhandler.ApplyOptions(transferopts,
-		standard.Recursive(true),
-		standard.ResourcesByValue(true),
-		standard.Overwrite(),
-		standard.Resolver(target)); err != nil {
-		return fmt.Errorf("failed to apply options: %w", err)
-	}
-
-	transferHandler, err := standard.New(transferopts)
+	transferHandler, err := newTransferHandler(octx, target)
 	if err != nil {
 		return err
 	}
@@ -107,3 +95,57 @@ func Transfer(octx ocm.Context, repo, target ocm.Repository, writer io.Writer) (
 
 	return nil
 }
+
+// TransferReferences transfers the component versions of the given references, with their resources
+// by value, from a repository to a target repository. The images of the transferred component versions
+// refer to their copies in the target repository.
+func TransferReferences(octx ocm.Context, repo, target ocm.Repository, refs []compdesc.ComponentReference, writer io.Writer) (rerr error) {
+	var finalize finalizer.Finalizer
+	defer finalize.FinalizeWithErrorPropagation(&rerr)
+
+	printer := common.NewPrinter(writer)
+	closure := transfer.TransportClosure{}
+	transferHandler, err := newTransferHandler(octx, target)
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range refs {
+		loop := finalize.Nested()
+
+		cv, err := repo.LookupComponentVersion(ref.GetComponentName(), ref.GetVersion())
+		if err != nil {
+			return fmt.Errorf("cannot get version %s for component %s: %w", ref.GetVersion(), ref.GetComponentName(), err)
+		}
+		loop.Close(cv)
+
+		if err := transfer.TransferVersion(printer, closure, cv, target, transferHandler); err != nil {
+			return fmt.Errorf("cannot transfer version %s for component %s: %w", ref.GetVersion(), ref.GetComponentName(), err)
+		}
+
+		if err := loop.Finalize(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// newTransferHandler returns a handler transferring component versions recursively, with their resources
+// by value, to the given target repository.
+func newTransferHandler(octx ocm.Context, target ocm.Repository) (transferhandler.TransferHandler, error) {
+	transferopts := &standard.Options{}
+	if err := transferhandler.From(octx.ConfigContext(), transferopts); err != nil {
+		return nil, fmt.Errorf("failed to create transfer handler: %w", err)
+	}
+
+	if err := transferhandler.ApplyOptions(transferopts,
+		standard.Recursive(true),
+		standard.ResourcesByValue(true),
+		standard.Overwrite(),
+		standard.Resolver(target)); err != nil {
+		return nil, fmt.Errorf("failed to apply options: %w", err)
+	}
+
+	return standard.New(transferopts)
+}
@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ocm
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-component-model/ocm/pkg/common/accessio"
+	"github.com/open-component-model/ocm/pkg/contexts/datacontext"
+	om "github.com/open-component-model/ocm/pkg/contexts/ocm"
+	"github.com/open-component-model/ocm/pkg/contexts/ocm/compdesc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_TransferReferences(t *testing.T) {
+	tmpdir := t.TempDir()
+	name := "github.com/ocm/test"
+	octx := om.New(datacontext.MODE_SHARED)
+
+	source, err := CreateCTF(octx, filepath.Join(tmpdir, "source"), accessio.FormatDirectory)
+	require.NoError(t, err)
+	defer source.Close()
+
+	comp, err := NewComponent(octx, name, "v0.8.3", WithProvider("ocm"))
+	require.NoError(t, err)
+	require.NoError(t, comp.AddToCTF(source))
+
+	fPath, err := writeFile(tmpdir, []byte("hello world"))
+	require.NoError(t, err)
+	require.NoError(t, comp.AddResource(WithResourceName("my-file"),
+		WithResourceType("file"),
+		WithResourcePath(fPath),
+		WithResourceVersion("v0.1.0"),
+	))
+	require.NoError(t, comp.Close())
+
+	target, err := CreateCTF(octx, filepath.Join(tmpdir, "target"), accessio.FormatDirectory)
+	require.NoError(t, err)
+	defer target.Close()
+
+	ref := compdesc.ComponentReference{
+		ElementMeta: compdesc.ElementMeta{
+			Name:    "test",
+			Version: "v0.8.3",
+		},
+		ComponentName: name,
+	}
+	require.NoError(t, TransferReferences(octx, source, target, []compdesc.ComponentReference{ref}, io.Discard))
+
+	cv, err := target.LookupComponentVersion(name, "v0.8.3")
+	require.NoError(t, err)
+	defer cv.Close()
+
+	res, err := cv.GetResource(map[string]string{"name": "my-file"})
+	require.NoError(t, err)
+	m, err := res.AccessMethod()
+	require.NoError(t, err)
+	defer m.Close()
+	data, err := m.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(data))
+
+	ref.Version = "v0.9.0"
+	assert.ErrorContains(t, TransferReferences(octx, source, target, []compdesc.ComponentReference{ref}, io.Discard),
+		"cannot get version v0.9.0 for component github.com/ocm/test")
+}
@@ -11,7 +11,6 @@ import (
 	"strings"
 
 	"github.com/Masterminds/semver/v3"
-	"github.com/open-component-model/mpas/internal/env"
 	"github.com/open-component-model/ocm/pkg/common/accessobj"
 	"github.com/open-component-model/ocm/pkg/contexts/credentials"
 	"github.com/open-component-model/ocm/pkg/contexts/credentials/repositories/dockerconfig"
@@ -26,34 +25,59 @@ import (
 // FetchLatestComponentVersion fetches the latest version of the component with the given name.
 // It returns the component version access and an error if the component version cannot be fetched.
 func FetchLatestComponentVersion(repo ocm.Repository, name string) (ocm.ComponentVersionAccess, error) {
+	return FetchComponentVersion(repo, name, "")
+}
+
+// FetchComponentVersion fetches the highest version of the component with the given name that matches
+// the given semver constraint. The latest version is fetched if the constraint is empty.
+// It returns the component version access and an error if the component version cannot be fetched.
+func FetchComponentVersion(repo ocm.Repository, name, constraint string) (ocm.ComponentVersionAccess, error) {
 	c, err := repo.LookupComponent(name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to lookup component %q: %w", name, err)
 	}
-	ver, err := fetchLatestComponentVersion(c, name)
+	ver, err := fetchMatchingComponentVersion(c, name, constraint)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch latest version of component %q: %w", name, err)
+		return nil, fmt.Errorf("failed to fetch version of component %q: %w", name, err)
 	}
 	cv, err := c.LookupVersion(ver.Original())
 	if err != nil {
-		return nil, fmt.Errorf("failed to lookup version %q of component %q: %w", ver.String(), env.DefaultBootstrapComponent, err)
+		return nil, fmt.Errorf("failed to lookup version %q of component %q: %w", ver.String(), name, err)
 	}
 
 	return cv, nil
 }
 
-func fetchLatestComponentVersion(c ocm.ComponentAccess, name string) (*semver.Version, error) {
+func fetchMatchingComponentVersion(c ocm.ComponentAccess, name, constraint string) (*semver.Version, error) {
+	var check *semver.Constraints
+	if constraint != "" {
+		var err error
+		check, err = semver.NewConstraint(constraint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version constraint %q: %w", constraint, err)
+		}
+	}
+
 	vnames, err := c.ListVersions()
 	if err != nil {
 		return nil, fmt.Errorf("failed to list versions of component %q: %w", name, err)
 	}
-	vs := make([]*semver.Version, len(vnames))
-	for i, vname := range vnames {
+	vs := make([]*semver.Version, 0, len(vnames))
+	for _, vname := range vnames {
 		v, err := semver.NewVersion(vname)
 		if err != nil {
 			return nil, err
 		}
-		vs[i] = v
+		if check != nil && !check.Check(v) {
+			continue
+		}
+		vs = append(vs, v)
+	}
+	if len(vs) == 0 {
+		if check != nil {
+			return nil, fmt.Errorf("no version of component %q matches %q", name, constraint)
+		}
+		return nil, fmt.Errorf("no version of component %q found", name)
 	}
 	sort.Sort(semver.Collection(vs))
 	ver := vs[len(vs)-1]
@@ -106,6 +130,27 @@ func MakeRepositoryWithDockerConfig(octx ocm.Context, repositoryURL, dockerconfi
 	return makeOCIRepository(octx, regURL.Host, regURL.Path)
 }
 
+// SetRegistryCredentials sets the credentials of the repository at the given repositoryURL, either a username
+// and password or an identity token. They take precedence over the credentials of the docker config of the registry,
+// as they match the path of the repository.
+func SetRegistryCredentials(octx ocm.Context, repositoryURL, username, password, identityToken string) error {
+	regURL, err := parseURL(repositoryURL)
+	if err != nil {
+		return err
+	}
+
+	creds := credentials.DirectCredentials{}
+	if identityToken != "" {
+		creds[credentials.ATTR_IDENTITY_TOKEN] = identityToken
+	} else {
+		creds[credentials.ATTR_USERNAME] = username
+		creds[credentials.ATTR_PASSWORD] = password
+	}
+
+	octx.CredentialsContext().SetCredentialsForConsumer(identity.GetConsumerId(regURL.Host+regURL.Path, ""), creds)
+	return nil
+}
+
 // MakeOCIRepository creates a repository for the given repositoryURL.
 func MakeOCIRepository(octx ocm.Context, repositoryURL string) (ocm.Repository, error) {
 	regURL, err := parseURL(repositoryURL)
@@ -5,12 +5,18 @@
 package ocm
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/open-component-model/ocm/pkg/contexts/credentials"
+	"github.com/open-component-model/ocm/pkg/contexts/datacontext"
+	"github.com/open-component-model/ocm/pkg/contexts/oci/identity"
 	"github.com/open-component-model/ocm/pkg/contexts/ocm"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -66,11 +72,58 @@ func Test_FetchLatestComponentVersion(t *testing.T) {
 	require.NoError(t, err)
 	c, err := repo.LookupComponent("test")
 	require.NoError(t, err)
-	cv, err := fetchLatestComponentVersion(c, "test")
+	cv, err := fetchMatchingComponentVersion(c, "test", "")
 	require.NoError(t, err)
 	assert.Equal(t, versions[len(versions)-1], cv.Original())
 }
 
+func Test_FetchMatchingComponentVersion(t *testing.T) {
+	versions := []string{"v0.1.0", "v0.2.0", "v0.2.1", "v0.3.0", "v1.0.0-rc.1", "v1.0.0"}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/ocm/test/component-descriptors/test/tags/list" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		data, err := json.Marshal(nameTag{Name: "ocm/test", Tags: versions})
+		require.NoError(t, err)
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	}))
+	defer srv.Close()
+
+	octx := ocm.DefaultContext()
+	repo, err := makeOCIRepository(octx, srv.URL, "ocm/test")
+	require.NoError(t, err)
+	c, err := repo.LookupComponent("test")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		constraint string
+		expected   string
+		err        string
+	}{
+		{constraint: "", expected: "v1.0.0"},
+		{constraint: "v0.2.0", expected: "v0.2.0"},
+		{constraint: "~0.2.0", expected: "v0.2.1"},
+		{constraint: "<1.0.0", expected: "v0.3.0"},
+		{constraint: ">2.0.0", err: "no version of component \"test\" matches \">2.0.0\""},
+		{constraint: "not-a-constraint", err: "invalid version constraint"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.constraint, func(t *testing.T) {
+			v, err := fetchMatchingComponentVersion(c, "test", tc.constraint)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, v.Original())
+		})
+	}
+}
+
 func Test_ParseURL(t *testing.T) {
 	testCases := []struct {
 		name         string
@@ -106,3 +159,30 @@ func Test_ParseURL(t *testing.T) {
 		})
 	}
 }
+
+func Test_SetRegistryCredentials(t *testing.T) {
+	dir := t.TempDir()
+	dockerConfig := filepath.Join(dir, "config.json")
+	require.NoError(t, os.WriteFile(dockerConfig, []byte(`{"auths":{"ghcr.io":{"auth":"`+base64.StdEncoding.EncodeToString([]byte("docker:config"))+`"}}}`), 0o600))
+
+	octx := ocm.New(datacontext.MODE_SHARED)
+	repo, err := MakeRepositoryWithDockerConfig(octx, "ghcr.io/open-component-model/mpas", dockerConfig)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	creds, err := identity.GetCredentials(octx, "ghcr.io", "open-component-model/mpas/component-descriptors/mpas-bootstrap-component")
+	require.NoError(t, err)
+	assert.Equal(t, "docker", creds.GetProperty(credentials.ATTR_USERNAME))
+
+	// the credentials of the repository take precedence over the docker config
+	require.NoError(t, SetRegistryCredentials(octx, "ghcr.io/open-component-model/mpas", "helper", "secret", ""))
+	creds, err = identity.GetCredentials(octx, "ghcr.io", "open-component-model/mpas/component-descriptors/mpas-bootstrap-component")
+	require.NoError(t, err)
+	assert.Equal(t, "helper", creds.GetProperty(credentials.ATTR_USERNAME))
+	assert.Equal(t, "secret", creds.GetProperty(credentials.ATTR_PASSWORD))
+
+	require.NoError(t, SetRegistryCredentials(octx, "https://registry.example.com/mpas", "", "", "identity"))
+	creds, err = identity.GetCredentials(octx, "registry.example.com", "mpas/component-descriptors/mpas-bootstrap-component")
+	require.NoError(t, err)
+	assert.Equal(t, "identity", creds.GetProperty(credentials.ATTR_IDENTITY_TOKEN))
+}
@@ -0,0 +1,39 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ocm
+
+import (
+	"fmt"
+
+	"github.com/open-component-model/ocm/pkg/contexts/ocm"
+	"github.com/open-component-model/ocm/pkg/contexts/ocm/signing"
+)
+
+// VerifyComponentVersion verifies the signature with the given name of the component version against the
+// given public key, and recursively the digests of the components it references, which are resolved in the
+// given repository. The signature name may be omitted if the component version has a single signature.
+func VerifyComponentVersion(repo ocm.Repository, cv ocm.ComponentVersionAccess, signatureName string, publicKey []byte) error {
+	if signatureName == "" {
+		signatures := cv.GetDescriptor().Signatures
+		if len(signatures) != 1 {
+			return fmt.Errorf("component %s:%s has %d signatures, the name of the signature to verify must be given",
+				cv.GetName(), cv.GetVersion(), len(signatures))
+		}
+		signatureName = signatures[0].Name
+	}
+
+	if cv.GetDescriptor().GetSignatureIndex(signatureName) < 0 {
+		return fmt.Errorf("component %s:%s has no signature %s", cv.GetName(), cv.GetVersion(), signatureName)
+	}
+
+	if _, err := signing.VerifyComponentVersion(cv, signatureName,
+		signing.PublicKey(signatureName, publicKey),
+		signing.Resolver(repo),
+	); err != nil {
+		return fmt.Errorf("failed to verify signature %s of component %s:%s: %w", signatureName, cv.GetName(), cv.GetVersion(), err)
+	}
+
+	return nil
+}
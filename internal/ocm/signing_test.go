@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ocm
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-component-model/ocm/pkg/common/accessio"
+	"github.com/open-component-model/ocm/pkg/contexts/datacontext"
+	om "github.com/open-component-model/ocm/pkg/contexts/ocm"
+	"github.com/open-component-model/ocm/pkg/contexts/ocm/signing"
+	rsahandler "github.com/open-component-model/ocm/pkg/signing/handlers/rsa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_VerifyComponentVersion(t *testing.T) {
+	tmpdir := t.TempDir()
+	octx := om.New(datacontext.MODE_SHARED)
+
+	repo, err := CreateCTF(octx, filepath.Join(tmpdir, "ctf"), accessio.FormatDirectory)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	ref, err := NewComponent(octx, "github.com/ocm/controller", "v0.1.0", WithProvider("ocm"))
+	require.NoError(t, err)
+	require.NoError(t, ref.AddToCTF(repo))
+	fPath, err := writeFile(tmpdir, []byte("hello world"))
+	require.NoError(t, err)
+	require.NoError(t, ref.AddResource(WithResourceName("my-file"),
+		WithResourceType("file"),
+		WithResourcePath(fPath),
+		WithResourceVersion("v0.1.0"),
+	))
+	require.NoError(t, ref.Close())
+
+	comp, err := NewComponent(octx, "github.com/ocm/bootstrap", "v0.8.3", WithProvider("ocm"))
+	require.NoError(t, err)
+	require.NoError(t, comp.AddToCTF(repo))
+	require.NoError(t, comp.AddResource(WithResourceName("controller"),
+		WithResourceType("componentReference"),
+		WithComponentName("github.com/ocm/controller"),
+		WithResourceVersion("v0.1.0"),
+	))
+	require.NoError(t, comp.Close())
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	publicKey, err := rsahandler.KeyData(&privateKey.PublicKey)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherPublicKey, err := rsahandler.KeyData(&otherKey.PublicKey)
+	require.NoError(t, err)
+
+	cv, err := repo.LookupComponentVersion("github.com/ocm/bootstrap", "v0.8.3")
+	require.NoError(t, err)
+	defer cv.Close()
+
+	assert.ErrorContains(t, VerifyComponentVersion(repo, cv, "", publicKey),
+		"component github.com/ocm/bootstrap:v0.8.3 has 0 signatures, the name of the signature to verify must be given")
+
+	_, err = signing.SignComponentVersion(cv, "mpas", signing.PrivateKey("mpas", privateKey), signing.Resolver(repo))
+	require.NoError(t, err)
+
+	assert.NoError(t, VerifyComponentVersion(repo, cv, "", publicKey))
+	assert.NoError(t, VerifyComponentVersion(repo, cv, "mpas", publicKey))
+	assert.ErrorContains(t, VerifyComponentVersion(repo, cv, "other", publicKey),
+		"component github.com/ocm/bootstrap:v0.8.3 has no signature other")
+	assert.ErrorContains(t, VerifyComponentVersion(repo, cv, "mpas", otherPublicKey),
+		"failed to verify signature mpas of component github.com/ocm/bootstrap:v0.8.3")
+
+	// a referenced component changed after signing invalidates the signature of the bootstrap component
+	changed, err := NewComponent(octx, "github.com/ocm/controller", "v0.1.0", WithProvider("ocm"))
+	require.NoError(t, err)
+	require.NoError(t, changed.AddToCTF(repo))
+	fPath, err = writeFile(tmpdir, []byte("hello mpas"))
+	require.NoError(t, err)
+	require.NoError(t, changed.AddResource(WithResourceName("my-file"),
+		WithResourceType("file"),
+		WithResourcePath(fPath),
+		WithResourceVersion("v0.1.0"),
+	))
+	require.NoError(t, changed.Close())
+
+	assert.ErrorContains(t, VerifyComponentVersion(repo, cv, "mpas", publicKey),
+		"failed to verify signature mpas of component github.com/ocm/bootstrap:v0.8.3")
+}
@@ -89,6 +89,11 @@ func (p *Printer) PrintSpinner(message string) error {
 	return nil
 }
 
+// UpdateSpinner updates the message of the running spinner. It is safe to call concurrently.
+func (p *Printer) UpdateSpinner(message string) {
+	p.spinner.Message(message)
+}
+
 func (p *Printer) StopSpinner(message string) error {
 	p.spinner.StopMessage(message)
 	err := p.spinner.Stop()